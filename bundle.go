@@ -0,0 +1,286 @@
+package pbparser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// BundleOptions holds the knobs which can be tweaked while bundling a proto file
+// along with its imports via the Bundle() api.
+type BundleOptions struct {
+	// PrefixOnCollision resolves a collision between the name of a type pulled in
+	// from another package and the name of some other type in the bundle by
+	// prefixing the pulled in type with the (CamelCase-d) name of its package.
+	// If not set, such collisions result in an Error.
+	PrefixOnCollision bool
+	// KeepWellKnownImports keeps the imports of the well-known types (i.e. the
+	// ones under google/protobuf/) as imports instead of pulling in their types.
+	KeepWellKnownImports bool
+}
+
+// Bundle parses the given entry proto file (provided by the given ImportModuleProvider
+// as are its transitive imports) and returns a self-contained ProtoFile in which the
+// types referenced from the imported files (directly or transitively) are pulled into
+// the package of the entry file. References to the pulled in types are rewritten to
+// fully qualified ones & the imports are dropped. The bundled ProtoFile is verified
+// before being returned.
+func Bundle(entry string, p ImportModuleProvider, opts BundleOptions) (ProtoFile, error) {
+	if p == nil {
+		return ProtoFile{}, errors.New("ImportModuleProvider is required to bundle a proto file")
+	}
+	r, err := p.Provide(entry)
+	if err != nil {
+		msg := fmt.Sprintf("ImportModuleReader is unable to provide content of module %v. Reason:: %v", entry, err.Error())
+		return ProtoFile{}, errors.New(msg)
+	}
+	pf, err := Parse(r, p)
+	if err != nil {
+		return ProtoFile{}, err
+	}
+
+	b := bundler{
+		opts:  opts,
+		decls: make(map[string]bundleDecl),
+		names: make(map[string]string),
+	}
+	if err := b.load(&pf, p); err != nil {
+		return ProtoFile{}, err
+	}
+
+	// figure out the types to pull in by following the references...
+	if err := b.follow(&pf); err != nil {
+		return ProtoFile{}, err
+	}
+
+	// name the pulled in types...
+	taken := make(map[string]bool)
+	for _, me := range pf.Messages {
+		taken[me.Name] = true
+	}
+	for _, ee := range pf.Enums {
+		taken[ee.Name] = true
+	}
+	for _, se := range pf.Services {
+		taken[se.Name] = true
+	}
+	for _, top := range b.pulled {
+		d := b.decls[top]
+		name := d.name
+		if taken[name] {
+			if !b.opts.PrefixOnCollision {
+				msg := fmt.Sprintf("Type %v can't be pulled in as the name %v is already taken in the bundle", top, name)
+				return ProtoFile{}, errors.New(msg)
+			}
+			name = goCamelCase(strings.Replace(d.pkg, ".", "_", -1)) + name
+			if taken[name] {
+				msg := fmt.Sprintf("Type %v can't be pulled in as the names %v & %v are already taken in the bundle", top, d.name, name)
+				return ProtoFile{}, errors.New(msg)
+			}
+		}
+		taken[name] = true
+		b.names[top] = qualify(pf.PackageName, name)
+	}
+
+	// rewrite the references to (and within) the pulled in types; this needs to be done
+	// before the pulled in types get re-qualified as the references are resolved relative
+	// to where they were declared...
+	rewrite := func(all bool) func(typeRef) {
+		return func(ref typeRef) {
+			qname, found := resolveTypeName(ref.scope, ref.name, b.defined)
+			if !found {
+				return
+			}
+			top := b.decls[qname].top
+			if newTop, pulled := b.names[top]; pulled {
+				ref.set(newTop + qname[len(top):])
+			} else if all {
+				ref.set(qname)
+			}
+		}
+	}
+	walkFileRefs(&pf, rewrite(false))
+	var msgs []MessageElement
+	var enums []EnumElement
+	for _, top := range b.pulled {
+		d := b.decls[top]
+		if d.msg != nil {
+			walkMessageRefs(d.msg, rewrite(true))
+			me := *d.msg
+			me.Name = b.names[top][len(qualify(pf.PackageName, "")):]
+			qualifyMessage(&me, pf.PackageName)
+			msgs = append(msgs, me)
+		} else {
+			ee := *d.enum
+			ee.Name = b.names[top][len(qualify(pf.PackageName, "")):]
+			ee.QualifiedName = b.names[top]
+			enums = append(enums, ee)
+		}
+	}
+
+	pf.Messages = append(pf.Messages, msgs...)
+	pf.Enums = append(pf.Enums, enums...)
+	pf.Dependencies = nil
+	pf.PublicDependencies = nil
+	pf.WeakDependencies = nil
+	if b.usesWellKnownTypes {
+		pf.Dependencies = b.wellKnownImports
+	}
+
+	if err := verify(&pf, p, parseOptions{}); err != nil {
+		return ProtoFile{}, err
+	}
+	return pf, nil
+}
+
+// bundleDecl describes a message or an enum declared in one of the files being bundled.
+type bundleDecl struct {
+	name string // name of the type if top-level
+	top  string // qualified name of the top-level type it is nested in (or is)
+	pkg  string
+	file string
+	msg  *MessageElement // set only for top-level messages
+	enum *EnumElement    // set only for top-level enums
+}
+
+type bundler struct {
+	opts               BundleOptions
+	decls              map[string]bundleDecl
+	pulled             []string          // qualified names of the top-level types pulled in, in order
+	names              map[string]string // qualified name of a pulled in type -> its new qualified name
+	wellKnownImports   []string
+	usesWellKnownTypes bool
+}
+
+// load parses the transitive imports of the given proto file and records the
+// types declared in all of them.
+func (b *bundler) load(pf *ProtoFile, p ImportModuleProvider) error {
+	if err := b.index(pf, ""); err != nil {
+		return err
+	}
+	seen := make(map[string]bool)
+	queue := pf.imports()
+	for len(queue) > 0 {
+		d := queue[0]
+		queue = queue[1:]
+		if seen[d] {
+			continue
+		}
+		seen[d] = true
+		if b.opts.KeepWellKnownImports && isWellKnownImport(d) {
+			b.wellKnownImports = append(b.wellKnownImports, d)
+			continue
+		}
+
+		r, err := p.Provide(d)
+		if err != nil {
+			msg := fmt.Sprintf("ImportModuleReader is unable to provide content of dependency module %v. Reason:: %v", d, err.Error())
+			return errors.New(msg)
+		}
+		dpf := ProtoFile{}
+		if err := parse(r, &dpf, parseOptions{}); err != nil {
+			msg := fmt.Sprintf("Unable to parse dependency %v. Reason:: %v", d, err.Error())
+			return errors.New(msg)
+		}
+		if err := b.index(&dpf, d); err != nil {
+			return err
+		}
+		queue = append(queue, dpf.imports()...)
+	}
+	return nil
+}
+
+// index records the types declared in the given proto file.
+func (b *bundler) index(pf *ProtoFile, file string) error {
+	add := func(qname string, d bundleDecl) error {
+		if other, found := b.decls[qname]; found {
+			msg := fmt.Sprintf("Type %v is declared in both %v and %v", qname, other.file, file)
+			return errors.New(msg)
+		}
+		b.decls[qname] = d
+		return nil
+	}
+	var addNested func(me MessageElement, top string) error
+	addNested = func(me MessageElement, top string) error {
+		for _, ee := range me.Enums {
+			if err := add(ee.QualifiedName, bundleDecl{top: top, pkg: pf.PackageName, file: file}); err != nil {
+				return err
+			}
+		}
+		for _, nested := range me.Messages {
+			if err := add(nested.QualifiedName, bundleDecl{top: top, pkg: pf.PackageName, file: file}); err != nil {
+				return err
+			}
+			if err := addNested(nested, top); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for i := range pf.Messages {
+		me := &pf.Messages[i]
+		d := bundleDecl{name: me.Name, top: me.QualifiedName, pkg: pf.PackageName, file: file, msg: me}
+		if err := add(me.QualifiedName, d); err != nil {
+			return err
+		}
+		if err := addNested(*me, me.QualifiedName); err != nil {
+			return err
+		}
+	}
+	for i := range pf.Enums {
+		ee := &pf.Enums[i]
+		d := bundleDecl{name: ee.Name, top: ee.QualifiedName, pkg: pf.PackageName, file: file, enum: ee}
+		if err := add(ee.QualifiedName, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *bundler) defined(qname string) bool {
+	_, found := b.decls[qname]
+	return found
+}
+
+// follow follows the references from the entry proto file (transitively) to find
+// out the types which need to be pulled in from the imported files.
+func (b *bundler) follow(pf *ProtoFile) error {
+	var err error
+	seen := make(map[string]bool)
+	var visit func(ref typeRef)
+	visit = func(ref typeRef) {
+		if err != nil {
+			return
+		}
+		qname, found := resolveTypeName(ref.scope, ref.name, b.defined)
+		if !found {
+			if b.opts.KeepWellKnownImports && strings.HasPrefix(strings.TrimPrefix(ref.name, "."), wellKnownTypesPrefix) {
+				b.usesWellKnownTypes = true
+				return
+			}
+			msg := fmt.Sprintf("Unable to resolve type %v referenced via %v in %v", ref.name, ref.via, ref.owner)
+			err = errors.New(msg)
+			return
+		}
+		d := b.decls[qname]
+		if d.file == "" || seen[d.top] {
+			return
+		}
+		seen[d.top] = true
+		b.pulled = append(b.pulled, d.top)
+		if top := b.decls[d.top]; top.msg != nil {
+			walkMessageRefs(top.msg, visit)
+		}
+	}
+	walkFileRefs(pf, visit)
+	return err
+}
+
+// qualify returns the qualified name of the given top-level name in the given package.
+func qualify(pkg string, name string) string {
+	if pkg == "" {
+		return name
+	}
+	return pkg + "." + name
+}
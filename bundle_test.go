@@ -0,0 +1,133 @@
+package pbparser_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/tallstoat/pbparser"
+)
+
+// mapImportModuleProvider provides the content of modules from a map.
+type mapImportModuleProvider map[string]string
+
+func (m mapImportModuleProvider) Provide(module string) (io.Reader, error) {
+	content, found := m[module]
+	if !found {
+		return nil, errors.New("no such module: " + module)
+	}
+	return strings.NewReader(content), nil
+}
+
+var bundleModules = mapImportModuleProvider{
+	"entry.proto": `
+syntax = "proto3";
+package shop;
+
+import "common/money.proto";
+import "google/protobuf/timestamp.proto";
+
+message Price {
+  string label = 1;
+}
+
+message Order {
+  common.Price total = 1;
+  repeated Price discounts = 2;
+  google.protobuf.Timestamp placed_at = 3;
+}
+`,
+	"common/money.proto": `
+syntax = "proto3";
+package common;
+
+import "common/currency.proto";
+
+message Price {
+  int64 units = 1;
+  Currency currency = 2;
+  message Breakdown {
+    map<string, Price> parts = 1;
+  }
+  Breakdown breakdown = 3;
+}
+
+message Unused {
+  string id = 1;
+}
+`,
+	"common/currency.proto": `
+syntax = "proto3";
+package common;
+
+enum Currency {
+  USD = 0;
+  EUR = 1;
+}
+`,
+	"google/protobuf/timestamp.proto": `
+syntax = "proto3";
+package google.protobuf;
+
+message Timestamp {
+  int64 seconds = 1;
+  int32 nanos = 2;
+}
+`,
+}
+
+func TestBundle(t *testing.T) {
+	if _, err := pbparser.Bundle("entry.proto", bundleModules, pbparser.BundleOptions{}); err == nil ||
+		!strings.Contains(err.Error(), "Type common.Price can't be pulled in as the name Price is already taken") {
+		t.Errorf("Expected error on name collision, Actual: %v", err)
+	}
+
+	pf, err := pbparser.Bundle("entry.proto", bundleModules, pbparser.BundleOptions{PrefixOnCollision: true, KeepWellKnownImports: true})
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+
+	if len(pf.Dependencies) != 1 || pf.Dependencies[0] != "google/protobuf/timestamp.proto" {
+		t.Errorf("Expected only the well-known import to be kept, Actual: %v", pf.Dependencies)
+	}
+	var names []string
+	for _, me := range pf.Messages {
+		names = append(names, me.QualifiedName)
+	}
+	for _, en := range pf.Enums {
+		names = append(names, en.QualifiedName)
+	}
+	if actual := strings.Join(names, " "); actual != "shop.Price shop.Order shop.CommonPrice shop.Currency" {
+		t.Errorf("Expected types: shop.Price shop.Order shop.CommonPrice shop.Currency, Actual: %v", actual)
+	}
+
+	order := pf.Messages[1]
+	price := pf.Messages[2]
+	var tests = []struct {
+		actual   string
+		expected string
+	}{
+		{actual: order.Fields[0].Type.Name(), expected: "shop.CommonPrice"},
+		{actual: order.Fields[1].Type.Name(), expected: "Price"},
+		{actual: order.Fields[2].Type.Name(), expected: "google.protobuf.Timestamp"},
+		{actual: price.Fields[1].Type.Name(), expected: "shop.Currency"},
+		{actual: price.Fields[2].Type.Name(), expected: "shop.CommonPrice.Breakdown"},
+		{actual: price.Messages[0].QualifiedName, expected: "shop.CommonPrice.Breakdown"},
+		{actual: price.Messages[0].Fields[0].Type.Name(), expected: "map<string, shop.CommonPrice>"},
+	}
+	for _, tt := range tests {
+		if tt.actual != tt.expected {
+			t.Errorf("Expected: %v, Actual: %v", tt.expected, tt.actual)
+		}
+	}
+
+	// without keeping the well-known imports, the well-known types get pulled in too...
+	pf, err = pbparser.Bundle("entry.proto", bundleModules, pbparser.BundleOptions{PrefixOnCollision: true})
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	if len(pf.Dependencies) != 0 || pf.Messages[len(pf.Messages)-1].QualifiedName != "shop.Timestamp" {
+		t.Errorf("Expected the well-known types to be pulled in, Actual: %v", pf.Messages)
+	}
+}
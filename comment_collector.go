@@ -0,0 +1,85 @@
+package pbparser
+
+// The comment collector. We use this to accumulate the comments read in between
+// two declarations and decide which of them trail the previous declaration, which
+// lead the next declaration and which are detached from both. The rules followed
+// are the same as the ones protoc uses to populate SourceCodeInfo :-
+//
+//   - a comment on the same line as the end of the previous declaration trails it.
+//   - a comment block on the line(s) right after the previous declaration trails it,
+//     if it is separated from the next declaration by a blank line.
+//   - a comment block which immediately precedes the next declaration (no blank line
+//     in between) leads it.
+//   - everything else is detached.
+//
+// A series of line comments on consecutive lines is treated as a single comment
+// whereas each block comment is a comment on its own.
+type commentCollector struct {
+	buf             string
+	lines           lineRange // the lines spanned by the comment being accumulated
+	hasComment      bool
+	isBlockComment  bool
+	canAttachToPrev bool
+	trailing        string
+	trailingLines   lineRange
+	detached        []string
+}
+
+// lineRange is a range of (1-based) lines in a protobuf file; both inclusive.
+type lineRange struct {
+	start int
+	end   int
+}
+
+// addLineComment adds a line comment (on the given line) to the comment being accumulated.
+func (cc *commentCollector) addLineComment(s string, line int) {
+	if cc.hasComment && cc.isBlockComment {
+		cc.flush()
+	}
+	if cc.hasComment {
+		cc.buf += " " + s
+		cc.lines.end = line
+	} else {
+		cc.buf = s
+		cc.lines = lineRange{start: line, end: line}
+	}
+	cc.hasComment = true
+	cc.isBlockComment = false
+}
+
+// addBlockComment starts a new comment with the given block comment (spanning the given lines).
+func (cc *commentCollector) addBlockComment(s string, start int, end int) {
+	cc.flush()
+	cc.buf = s
+	cc.lines = lineRange{start: start, end: end}
+	cc.hasComment = true
+	cc.isBlockComment = true
+}
+
+// flush hands the accumulated comment over as either the trailing comment of the
+// previous declaration (if still possible) or as a detached comment.
+func (cc *commentCollector) flush() {
+	if !cc.hasComment {
+		return
+	}
+	if cc.canAttachToPrev {
+		cc.trailing = cc.buf
+		cc.trailingLines = cc.lines
+		cc.canAttachToPrev = false
+	} else {
+		cc.detached = append(cc.detached, cc.buf)
+	}
+	cc.buf = ""
+	cc.hasComment = false
+}
+
+// detachFromPrev ensures no further comments get attached to the previous declaration.
+func (cc *commentCollector) detachFromPrev() {
+	cc.canAttachToPrev = false
+}
+
+// documentation returns the documentation for the next declaration i.e. the
+// comment accumulated so far as leading along with any detached comments.
+func (cc *commentCollector) documentation() Documentation {
+	return Documentation{Leading: cc.buf, Detached: cc.detached}
+}
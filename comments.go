@@ -0,0 +1,163 @@
+package pbparser
+
+// commentOwner records the lines spanned by the leading or trailing comment of an
+// element along with the qualified name of the element.
+type commentOwner struct {
+	lines lineRange
+	owner string
+}
+
+// CommentsFor returns the documentation of the element with the given qualified
+// name in the given proto file. The element can be a message, a field (including a
+// field within a oneof or an extend declaration), a oneof, an enum, an enum constant,
+// a service or a rpc; howsoever deep it is nested. The enum constants and the rpcs
+// are named relative to their enum and service respectively e.g. pkg.Enum.CONSTANT
+// and pkg.Service.Method. The fields of extend declarations are named relative to
+// the scope the extend declaration is in.
+//
+// An empty Documentation is returned if there is no such element.
+func CommentsFor(pf *ProtoFile, qualifiedName string) Documentation {
+	var doc Documentation
+	walkDocs(pf, func(qname string, d Documentation) {
+		if qname == qualifiedName {
+			doc = d
+		}
+	})
+	return doc
+}
+
+// OwnerOfCommentAt returns the qualified name (as accepted by CommentsFor) of the
+// element whose leading or trailing comment spans the given (1-based) line. Detached
+// comments are not owned by any element. This relies on the proto file having been
+// produced by the Parse() or the ParseFile() api.
+func (pf *ProtoFile) OwnerOfCommentAt(line int) (string, bool) {
+	for _, co := range pf.commentOwners {
+		if line >= co.lines.start && line <= co.lines.end {
+			return co.owner, true
+		}
+	}
+	return "", false
+}
+
+// walkDocs visits the documentation of all the named elements in the given proto file.
+func walkDocs(pf *ProtoFile, visit func(qname string, doc Documentation)) {
+	prefix := qualify(pf.PackageName, "")
+	walkEnumDocs(pf.Enums, visit)
+	for _, me := range pf.Messages {
+		walkMessageDocs(me, visit)
+	}
+	for _, se := range pf.Services {
+		visit(se.QualifiedName, se.Documentation)
+		for _, rpc := range se.RPCs {
+			visit(se.QualifiedName+"."+rpc.Name, rpc.Documentation)
+		}
+	}
+	walkExtendDocs(prefix, pf.ExtendDeclarations, visit)
+}
+
+func walkMessageDocs(me MessageElement, visit func(qname string, doc Documentation)) {
+	visit(me.QualifiedName, me.Documentation)
+	for _, f := range me.Fields {
+		visit(me.QualifiedName+"."+f.Name, f.Documentation)
+	}
+	for _, oo := range me.OneOfs {
+		visit(me.QualifiedName+"."+oo.Name, oo.Documentation)
+		for _, f := range oo.Fields {
+			visit(me.QualifiedName+"."+f.Name, f.Documentation)
+		}
+	}
+	walkEnumDocs(me.Enums, visit)
+	walkExtendDocs(me.QualifiedName+".", me.ExtendDeclarations, visit)
+	for _, nested := range me.Messages {
+		walkMessageDocs(nested, visit)
+	}
+}
+
+func walkEnumDocs(enums []EnumElement, visit func(qname string, doc Documentation)) {
+	for _, ee := range enums {
+		visit(ee.QualifiedName, ee.Documentation)
+		for _, ec := range ee.EnumConstants {
+			visit(ee.QualifiedName+"."+ec.Name, ec.Documentation)
+		}
+	}
+}
+
+func walkExtendDocs(prefix string, extends []ExtendElement, visit func(qname string, doc Documentation)) {
+	for _, ee := range extends {
+		for _, f := range ee.Fields {
+			visit(prefix+f.Name, f.Documentation)
+		}
+	}
+}
+
+// StripDocumentation clears the documentation (leading, trailing & detached comments)
+// of every element of the proto file howsoever deep it is nested; along with the record
+// of which element owns the comment on which line.
+func (pf *ProtoFile) StripDocumentation() {
+	stripEnumDocs(pf.Enums)
+	stripMessageDocs(pf.Messages)
+	for i := range pf.Services {
+		se := &pf.Services[i]
+		se.Documentation = Documentation{}
+		for j := range se.RPCs {
+			se.RPCs[j].Documentation = Documentation{}
+		}
+	}
+	stripExtendDocs(pf.ExtendDeclarations)
+	stripRawDeclarationDocs(pf.RawDeclarations)
+	pf.commentOwners = nil
+}
+
+func stripMessageDocs(msgs []MessageElement) {
+	for i := range msgs {
+		me := &msgs[i]
+		me.Documentation = Documentation{}
+		stripFieldDocs(me.Fields)
+		for j := range me.OneOfs {
+			me.OneOfs[j].Documentation = Documentation{}
+			stripFieldDocs(me.OneOfs[j].Fields)
+		}
+		for j := range me.Extensions {
+			me.Extensions[j].Documentation = Documentation{}
+		}
+		for j := range me.ReservedRanges {
+			me.ReservedRanges[j].Documentation = Documentation{}
+		}
+		stripEnumDocs(me.Enums)
+		stripExtendDocs(me.ExtendDeclarations)
+		stripRawDeclarationDocs(me.RawDeclarations)
+		stripMessageDocs(me.Messages)
+	}
+}
+
+func stripFieldDocs(fields []FieldElement) {
+	for i := range fields {
+		fields[i].Documentation = Documentation{}
+	}
+}
+
+func stripEnumDocs(enums []EnumElement) {
+	for i := range enums {
+		ee := &enums[i]
+		ee.Documentation = Documentation{}
+		for j := range ee.EnumConstants {
+			ee.EnumConstants[j].Documentation = Documentation{}
+		}
+		for j := range ee.ReservedRanges {
+			ee.ReservedRanges[j].Documentation = Documentation{}
+		}
+	}
+}
+
+func stripExtendDocs(extends []ExtendElement) {
+	for i := range extends {
+		extends[i].Documentation = Documentation{}
+		stripFieldDocs(extends[i].Fields)
+	}
+}
+
+func stripRawDeclarationDocs(raws []RawDeclaration) {
+	for i := range raws {
+		raws[i].Documentation = Documentation{}
+	}
+}
@@ -0,0 +1,121 @@
+package pbparser_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tallstoat/pbparser"
+)
+
+func TestCommentOwners(t *testing.T) {
+	pf, err := pbparser.ParseFile("./resources/comment-owners.proto")
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+
+	var ownerTests = []struct {
+		line  int
+		owner string
+	}{
+		{line: 5},
+		{line: 7, owner: "owners.Order"},
+		{line: 8, owner: "owners.Order"},
+		{line: 9, owner: "owners.Order.id"},
+		{line: 11, owner: "owners.Order.items"},
+		{line: 12, owner: "owners.Order.items"},
+		{line: 15, owner: "owners.Order.Item"},
+		{line: 17, owner: "owners.Order.Item.sku"},
+		{line: 19, owner: "owners.Order.Item.State"},
+		{line: 21, owner: "owners.Order.Item.State.STATE_UNKNOWN"},
+		{line: 22, owner: "owners.Order.Item.State.STATE_SHIPPED"},
+		{line: 27, owner: "owners.Order.payment"},
+		{line: 29, owner: "owners.Order.card"},
+		{line: 31, owner: "owners.Order.voucher"},
+		{line: 34},
+		{line: 36},
+		{line: 39, owner: "owners.Orders"},
+		{line: 41, owner: "owners.Orders.Place"},
+		{line: 42, owner: "owners.Orders.Place"},
+		{line: 45},
+		{line: 47},
+	}
+	for _, tt := range ownerTests {
+		owner, found := pf.OwnerOfCommentAt(tt.line)
+		if owner != tt.owner || found != (tt.owner != "") {
+			t.Errorf("Line: %v, Expected owner: %q, Actual: %q (found: %v)", tt.line, tt.owner, owner, found)
+		}
+	}
+
+	var docTests = []struct {
+		qname    string
+		leading  string
+		trailing string
+	}{
+		{qname: "owners.Order", leading: "Leading comment for Order.", trailing: "Trailing comment for Order."},
+		{qname: "owners.Order.items", leading: "Block comment\n     leading items."},
+		{qname: "owners.Order.Item.sku", leading: "Leading comment for sku."},
+		{qname: "owners.Order.Item.State.STATE_UNKNOWN", trailing: "Trailing comment for STATE_UNKNOWN."},
+		{qname: "owners.Order.payment", leading: "Leading comment for payment."},
+		{qname: "owners.Order.voucher", trailing: "Trailing comment for voucher."},
+		{qname: "owners.Orders.Place", leading: "Leading comment for Place. Another line for Place."},
+		{qname: "owners.Order.note"},
+		{qname: "owners.Missing"},
+	}
+	for _, tt := range docTests {
+		doc := pbparser.CommentsFor(&pf, tt.qname)
+		if doc.Leading != tt.leading || doc.Trailing != tt.trailing {
+			t.Errorf("Element: %v, Expected: %q/%q, Actual: %q/%q", tt.qname, tt.leading, tt.trailing, doc.Leading, doc.Trailing)
+		}
+	}
+	if doc := pbparser.CommentsFor(&pf, "owners.Order.note"); len(doc.Detached) != 1 {
+		t.Errorf("Expected a detached comment for note, Actual: %v", doc.Detached)
+	}
+}
+
+func TestStripDocumentation(t *testing.T) {
+	for _, file := range []string{"./resources/comments.proto", "./resources/comment-owners.proto", "./resources/descriptor.proto"} {
+		pf, err := pbparser.ParseFile(file)
+		if err != nil {
+			t.Fatalf("%v", err.Error())
+		}
+		if n := countDocumented(reflect.ValueOf(pf)); n == 0 {
+			t.Fatalf("Expected documented elements in %v", file)
+		}
+
+		pf.StripDocumentation()
+		if n := countDocumented(reflect.ValueOf(pf)); n != 0 {
+			t.Errorf("Expected no documented elements in %v after stripping, Actual: %v", file, n)
+		}
+		for line := 1; line < 1000; line++ {
+			if owner, found := pf.OwnerOfCommentAt(line); found {
+				t.Errorf("Expected no comment owners in %v after stripping, Actual: %v on line %v", file, owner, line)
+				break
+			}
+		}
+	}
+}
+
+// countDocumented counts the non empty Documentation values found within the given
+// value howsoever deep.
+func countDocumented(v reflect.Value) int {
+	n := 0
+	switch v.Kind() {
+	case reflect.Struct:
+		if doc, ok := v.Interface().(pbparser.Documentation); ok {
+			if doc.Leading != "" || doc.Trailing != "" || len(doc.Detached) > 0 {
+				return 1
+			}
+			return 0
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath == "" {
+				n += countDocumented(v.Field(i))
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			n += countDocumented(v.Index(i))
+		}
+	}
+	return n
+}
@@ -0,0 +1,63 @@
+package pbparser
+
+import "sort"
+
+// FindMessageCycles returns the cycles of recursive message definitions within the
+// given proto file; be it a message referencing itself or a cycle of references
+// through several messages (howsoever deep they are nested). The references
+// considered are the ones made by fields, map values & oneof members.
+//
+// Each cycle is a list of the qualified names of the messages in it, in the order
+// of the references, and is reported just once; starting from the message with the
+// (lexicographically) smallest qualified name. The cycles are sorted likewise.
+func FindMessageCycles(pf *ProtoFile) [][]string {
+	g := newTypeGraph([]ProtoFile{*pf})
+
+	// the messages & their (distinct) references in a stable order...
+	services := make(map[string]bool)
+	for _, se := range pf.Services {
+		services[se.QualifiedName] = true
+	}
+	var msgs []string
+	adj := make(map[string][]string)
+	for qname, refs := range g.edges {
+		if services[qname] {
+			continue
+		}
+		msgs = append(msgs, qname)
+		seen := make(map[string]bool)
+		for _, ref := range refs {
+			// only messages have references of their own...
+			if _, isMsg := g.edges[ref]; isMsg && !seen[ref] {
+				seen[ref] = true
+				adj[qname] = append(adj[qname], ref)
+			}
+		}
+		sort.Strings(adj[qname])
+	}
+	sort.Strings(msgs)
+
+	// the cycles through each message which only go through messages sorting after
+	// it; so that each cycle is found just once, starting from its smallest message...
+	var cycles [][]string
+	for _, start := range msgs {
+		onPath := make(map[string]bool)
+		var path []string
+		var dfs func(qname string)
+		dfs = func(qname string) {
+			path = append(path, qname)
+			onPath[qname] = true
+			for _, next := range adj[qname] {
+				if next == start {
+					cycles = append(cycles, append([]string{}, path...))
+				} else if next > start && !onPath[next] {
+					dfs(next)
+				}
+			}
+			onPath[qname] = false
+			path = path[:len(path)-1]
+		}
+		dfs(start)
+	}
+	return cycles
+}
@@ -0,0 +1,68 @@
+package pbparser_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tallstoat/pbparser"
+)
+
+const cyclesProto = `
+syntax = "proto3";
+package tree;
+
+message Node {
+  string name = 1;
+  repeated Node children = 2;
+  Meta meta = 3;
+}
+
+message Meta {
+  map<string, Link> links = 1;
+  Kind kind = 2;
+}
+
+message Link {
+  oneof target {
+    Node node = 1;
+    string url = 2;
+  }
+}
+
+message Graph {
+  message Vertex {
+    repeated Graph.Edge edges = 1;
+  }
+  message Edge {
+    Graph.Vertex from = 1;
+    Graph.Vertex to = 2;
+  }
+  repeated Vertex vertices = 1;
+}
+
+message Flat {
+  string value = 1;
+  Kind kind = 2;
+}
+
+enum Kind {
+  KIND_UNKNOWN = 0;
+}
+
+service TreeService {
+  rpc Get (Node) returns (Node);
+}
+`
+
+func TestFindMessageCycles(t *testing.T) {
+	pf, err := pbparser.Parse(strings.NewReader(cyclesProto), nil)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+
+	expected := "[[tree.Graph.Edge tree.Graph.Vertex] [tree.Link tree.Node tree.Meta] [tree.Node]]"
+	if actual := fmt.Sprint(pbparser.FindMessageCycles(&pf)); actual != expected {
+		t.Errorf("Expected: %v, Actual: %v", expected, actual)
+	}
+}
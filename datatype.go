@@ -83,6 +83,144 @@ func (sdt ScalarDataType) Category() DataTypeCategory {
 	return ScalarDataTypeCategory
 }
 
+// String returns the name of the ScalarDataType.
+func (sdt ScalarDataType) String() string {
+	return sdt.name
+}
+
+// MarshalText returns the name of the ScalarDataType.
+func (sdt ScalarDataType) MarshalText() ([]byte, error) {
+	return []byte(sdt.name), nil
+}
+
+// UnmarshalText sets the ScalarDataType to the one with the given name. An Error
+// is returned if the name is not that of a scalar datatype.
+func (sdt *ScalarDataType) UnmarshalText(text []byte) error {
+	t, err := NewScalarDataType(string(text))
+	if err != nil {
+		return err
+	}
+	*sdt = t
+	return nil
+}
+
+// Type returns the ScalarType of the ScalarDataType.
+func (sdt ScalarDataType) Type() ScalarType {
+	return sdt.scalarType
+}
+
+// IsNumeric returns true if the ScalarDataType is an integer or a floating point type.
+func (sdt ScalarDataType) IsNumeric() bool {
+	return sdt.IsInteger() || sdt.IsFloatingPoint()
+}
+
+// IsInteger returns true if the ScalarDataType is one of the (signed or unsigned)
+// integer types.
+func (sdt ScalarDataType) IsInteger() bool {
+	switch sdt.scalarType {
+	case Int32Scalar, Int64Scalar, Uint32Scalar, Uint64Scalar, Sint32Scalar, Sint64Scalar,
+		Fixed32Scalar, Fixed64Scalar, Sfixed32Scalar, Sfixed64Scalar:
+		return true
+	}
+	return false
+}
+
+// IsUnsigned returns true if the ScalarDataType is one of the unsigned integer types.
+func (sdt ScalarDataType) IsUnsigned() bool {
+	switch sdt.scalarType {
+	case Uint32Scalar, Uint64Scalar, Fixed32Scalar, Fixed64Scalar:
+		return true
+	}
+	return false
+}
+
+// IsFloatingPoint returns true if the ScalarDataType is either float or double.
+func (sdt ScalarDataType) IsFloatingPoint() bool {
+	return sdt.scalarType == FloatScalar || sdt.scalarType == DoubleScalar
+}
+
+// IsPackable returns true if repeated fields of the ScalarDataType can use the packed
+// encoding i.e. if it is a numeric type or bool.
+func (sdt ScalarDataType) IsPackable() bool {
+	return sdt.IsNumeric() || sdt.scalarType == BoolScalar
+}
+
+// WireType is an enumeration which represents the wire types of the
+// protobuf binary encoding.
+type WireType int
+
+const (
+	VarintWireType          WireType = 0
+	Fixed64WireType         WireType = 1
+	LengthDelimitedWireType WireType = 2
+	Fixed32WireType         WireType = 5
+)
+
+// WireType returns the wire type with which values of the ScalarDataType are encoded.
+func (sdt ScalarDataType) WireType() WireType {
+	switch sdt.scalarType {
+	case Fixed64Scalar, Sfixed64Scalar, DoubleScalar:
+		return Fixed64WireType
+	case Fixed32Scalar, Sfixed32Scalar, FloatScalar:
+		return Fixed32WireType
+	case StringScalar, BytesScalar, AnyScalar:
+		return LengthDelimitedWireType
+	}
+	return VarintWireType
+}
+
+var goScalarTypes = map[ScalarType]string{
+	AnyScalar:      "interface{}",
+	BoolScalar:     "bool",
+	BytesScalar:    "[]byte",
+	DoubleScalar:   "float64",
+	FloatScalar:    "float32",
+	Fixed32Scalar:  "uint32",
+	Fixed64Scalar:  "uint64",
+	Int32Scalar:    "int32",
+	Int64Scalar:    "int64",
+	Sfixed32Scalar: "int32",
+	Sfixed64Scalar: "int64",
+	Sint32Scalar:   "int32",
+	Sint64Scalar:   "int64",
+	StringScalar:   "string",
+	Uint32Scalar:   "uint32",
+	Uint64Scalar:   "uint64",
+}
+
+// GoType returns the Go type which values of the ScalarDataType map to.
+func (sdt ScalarDataType) GoType() string {
+	return goScalarTypes[sdt.scalarType]
+}
+
+// JSONType returns the JSON schema type which values of the ScalarDataType map to as
+// per the proto3 JSON mapping; in which 64 bit integers are strings. An empty string
+// is returned for any since its representation depends on the message it holds.
+func (sdt ScalarDataType) JSONType() string {
+	switch {
+	case sdt.scalarType == BoolScalar:
+		return "boolean"
+	case sdt.scalarType == StringScalar || sdt.scalarType == BytesScalar:
+		return "string"
+	case sdt.IsFloatingPoint():
+		return "number"
+	case sdt.IsInteger() && sdt.is64Bit():
+		return "string"
+	case sdt.IsInteger():
+		return "integer"
+	}
+	return ""
+}
+
+// is64Bit returns true if the ScalarDataType is one of the 64 bit numeric types.
+func (sdt ScalarDataType) is64Bit() bool {
+	switch sdt.scalarType {
+	case Int64Scalar, Uint64Scalar, Sint64Scalar, Fixed64Scalar, Sfixed64Scalar, DoubleScalar:
+		return true
+	}
+	return false
+}
+
 // NewScalarDataType creates and returns a new ScalarDataType for the given string.
 // If a scalar data type mapping does not exist for the given string, an Error is returned.
 func NewScalarDataType(s string) (ScalarDataType, error) {
@@ -95,6 +233,78 @@ func NewScalarDataType(s string) (ScalarDataType, error) {
 	return ScalarDataType{name: key, scalarType: st}, nil
 }
 
+// scalarTypeAliases maps the names of the types of other languages, which are often
+// mistaken for protobuf scalar types, to the scalar type which was likely meant.
+var scalarTypeAliases = map[string]string{
+	"boolean": "bool",
+	"byte":    "bytes",
+	"float32": "float",
+	"float64": "double",
+	"int":     "int32",
+	"int8":    "int32",
+	"int16":   "int32",
+	"integer": "int32",
+	"long":    "int64",
+	"short":   "int32",
+	"str":     "string",
+	"uint":    "uint32",
+	"uint8":   "uint32",
+	"uint16":  "uint32",
+	"ulong":   "uint64",
+}
+
+// suggestScalarType returns the name of the scalar datatype which the given (unresolved)
+// datatype name was likely meant to be; or an empty string if it does not look like one.
+// Besides the known aliases, a name is taken to be a misspelling of a scalar datatype
+// if it starts with the same letter and is within an edit distance of a third of the
+// length of the latter.
+func suggestScalarType(name string) string {
+	key := strings.ToLower(name)
+	if s, found := scalarTypeAliases[key]; found {
+		return s
+	}
+	var suggestion string
+	best := -1
+	for s, st := range scalarLookupMap {
+		if st == AnyScalar || key == "" || key[0] != s[0] {
+			continue
+		}
+		d := editDistance(key, s)
+		if d > len(s)/3 || (best >= 0 && (d > best || (d == best && s > suggestion))) {
+			continue
+		}
+		suggestion, best = s, d
+	}
+	return suggestion
+}
+
+// editDistance returns the levenshtein distance between the given strings.
+func editDistance(a string, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = prev[j-1] + cost
+			if prev[j]+1 < curr[j] {
+				curr[j] = prev[j] + 1
+			}
+			if curr[j-1]+1 < curr[j] {
+				curr[j] = curr[j-1] + 1
+			}
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
 // MapDataType is a construct which represents a protobuf map datatype.
 type MapDataType struct {
 	keyType   DataType
@@ -111,6 +321,77 @@ func (mdt MapDataType) Category() DataTypeCategory {
 	return MapDataTypeCategory
 }
 
+// String returns the name of the MapDataType e.g. map<string, Foo>.
+func (mdt MapDataType) String() string {
+	return mdt.Name()
+}
+
+// MarshalText returns the name of the MapDataType.
+func (mdt MapDataType) MarshalText() ([]byte, error) {
+	if mdt.keyType == nil || mdt.valueType == nil {
+		return nil, errors.New("Map key and value datatypes must be specified")
+	}
+	return []byte(mdt.Name()), nil
+}
+
+// UnmarshalText sets the MapDataType to the one with the given name which is
+// of the form map<key, value>. An Error is returned if the name is malformed or
+// the key/value datatypes are not valid for a map.
+func (mdt *MapDataType) UnmarshalText(text []byte) error {
+	s := strings.TrimSpace(string(text))
+	if !strings.HasPrefix(s, "map<") || !strings.HasSuffix(s, ">") {
+		msg := fmt.Sprintf("'%v' is not a map datatype", s)
+		return errors.New(msg)
+	}
+	parts := strings.SplitN(s[len("map<"):len(s)-1], ",", 2)
+	if len(parts) != 2 {
+		msg := fmt.Sprintf("'%v' is not a map datatype", s)
+		return errors.New(msg)
+	}
+	keyType, err := NewScalarDataType(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return err
+	}
+	valueType, err := dataTypeOfName(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return err
+	}
+	t, err := NewMapDataType(keyType, valueType)
+	if err != nil {
+		return err
+	}
+	*mdt = t
+	return nil
+}
+
+// KeyType returns the datatype of the keys of the MapDataType.
+func (mdt MapDataType) KeyType() DataType {
+	return mdt.keyType
+}
+
+// ValueType returns the datatype of the values of the MapDataType.
+func (mdt MapDataType) ValueType() DataType {
+	return mdt.valueType
+}
+
+// NewMapDataType creates and returns a new MapDataType for the given key and value datatypes.
+// If the key datatype is not a scalar datatype (other than float, double & bytes) or the
+// value datatype is a map datatype, an Error is returned.
+func NewMapDataType(keyType DataType, valueType DataType) (MapDataType, error) {
+	if keyType == nil || valueType == nil {
+		return MapDataType{}, errors.New("Map key and value datatypes must be specified")
+	}
+	sdt, ok := keyType.(ScalarDataType)
+	if !ok || sdt.IsFloatingPoint() || sdt.scalarType == BytesScalar {
+		msg := fmt.Sprintf("'%v' is not a valid map key datatype", keyType.Name())
+		return MapDataType{}, errors.New(msg)
+	}
+	if valueType.Category() == MapDataTypeCategory {
+		return MapDataType{}, errors.New("Map value datatype cannot be another map")
+	}
+	return MapDataType{keyType: keyType, valueType: valueType}, nil
+}
+
 // NamedDataType is a construct which represents a message datatype as
 // a RPC request or response and a message/enum datatype as a field in
 // message, oneof or extend declarations.
@@ -129,13 +410,100 @@ func (ndt NamedDataType) Category() DataTypeCategory {
 	return NamedDataTypeCategory
 }
 
+// String returns the name of the NamedDataType; prefixed with "stream " if the
+// NamedDataType is preceded by a Stream keyword.
+func (ndt NamedDataType) String() string {
+	if ndt.supportsStreaming {
+		return "stream " + ndt.name
+	}
+	return ndt.name
+}
+
+// MarshalText returns the name of the NamedDataType as returned by String().
+func (ndt NamedDataType) MarshalText() ([]byte, error) {
+	if ndt.name == "" {
+		return nil, errors.New("Name of a NamedDataType cannot be empty")
+	}
+	return []byte(ndt.String()), nil
+}
+
+// UnmarshalText sets the NamedDataType to the one with the given name; which may
+// be prefixed with "stream ". An Error is returned if the name is not valid for a
+// NamedDataType.
+func (ndt *NamedDataType) UnmarshalText(text []byte) error {
+	s := strings.TrimLeft(string(text), " ")
+	streaming := false
+	if rest := strings.TrimPrefix(s, "stream "); rest != s {
+		s, streaming = rest, true
+	}
+	s = strings.TrimSpace(s)
+	t, err := NewNamedDataType(s)
+	if err != nil {
+		return err
+	}
+	t.stream(streaming)
+	*ndt = t
+	return nil
+}
+
+// NewNamedDataType creates and returns a new NamedDataType for the given message/enum name.
+// If the given name is empty or is the name of a scalar datatype (in any case; as per
+// NewScalarDataType), an Error is returned.
+func NewNamedDataType(name string) (NamedDataType, error) {
+	if name == "" {
+		return NamedDataType{}, errors.New("Name of a NamedDataType cannot be empty")
+	}
+	if scalarLookupMap[strings.ToLower(name)] != 0 {
+		msg := fmt.Sprintf("'%v' is the name of a ScalarDataType", name)
+		return NamedDataType{}, errors.New(msg)
+	}
+	return NamedDataType{name: name}, nil
+}
+
 // IsStream returns true if the NamedDataType is being used in a rpc
 // as a request or response and is preceded by a Stream keyword.
 func (ndt NamedDataType) IsStream() bool {
 	return ndt.supportsStreaming
 }
 
+// IsFullyQualified returns true if the name of the NamedDataType starts with a dot
+// e.g. .google.protobuf.Empty; in which case it is resolved from the root scope
+// rather than relative to the scope it is referenced in.
+func (ndt NamedDataType) IsFullyQualified() bool {
+	return strings.HasPrefix(ndt.name, ".")
+}
+
 // stream marks a NamedDataType as being preceded by a Stream keyword.
 func (ndt *NamedDataType) stream(flag bool) {
 	ndt.supportsStreaming = flag
 }
+
+// dataTypeOfName returns the scalar datatype with the given name (in any case) if
+// there is one; else a NamedDataType of the given name.
+func dataTypeOfName(name string) (DataType, error) {
+	if _, ok := scalarLookupMap[strings.ToLower(name)]; ok {
+		return NewScalarDataType(name)
+	}
+	return NewNamedDataType(name)
+}
+
+// resolveTypeName resolves the given (possibly relative) message/enum name
+// referenced within the given scope the way protoc does i.e. starting from the
+// innermost scope and moving outwards. The qualified name is returned if the
+// given function reports it as defined.
+func resolveTypeName(scope string, name string, defined func(string) bool) (string, bool) {
+	if strings.HasPrefix(name, ".") {
+		return name[1:], defined(name[1:])
+	}
+	for {
+		if defined(scope + "." + name) {
+			return scope + "." + name, true
+		}
+		i := strings.LastIndex(scope, ".")
+		if i < 0 {
+			break
+		}
+		scope = scope[:i]
+	}
+	return name, defined(name)
+}
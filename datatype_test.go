@@ -2,6 +2,7 @@ package pbparser
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -23,3 +24,159 @@ func TestScalarDataTypeCreationViaName(t *testing.T) {
 		}
 	}
 }
+
+func TestMapDataTypeComponents(t *testing.T) {
+	me, err := ParseMessage(strings.NewReader("message Bar { map<string, Foo> foos = 1; }"))
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	dt := me.Fields[0].Type
+	mdt, ok := dt.(MapDataType)
+	if !ok {
+		t.Fatalf("Expected a MapDataType, Actual: %T", dt)
+	}
+	if mdt.KeyType().Category() != ScalarDataTypeCategory || mdt.KeyType().Name() != "string" {
+		t.Errorf("Expected key type: string, Actual: %v", mdt.KeyType())
+	}
+	if mdt.ValueType().Category() != NamedDataTypeCategory || mdt.ValueType().Name() != "Foo" {
+		t.Errorf("Expected value type: Foo, Actual: %v", mdt.ValueType())
+	}
+	if mdt.Name() != "map<string, Foo>" {
+		t.Errorf("Expected name: map<string, Foo>, Actual: %v", mdt.Name())
+	}
+}
+
+func TestSuggestScalarType(t *testing.T) {
+	var tests = []struct {
+		name     string
+		expected string
+	}{
+		{name: "int", expected: "int32"},
+		{name: "float64", expected: "double"},
+		{name: "uint8", expected: "uint32"},
+		{name: "Boolean", expected: "bool"},
+		{name: "strng", expected: "string"},
+		{name: "unit64", expected: "uint64"},
+		{name: "Task", expected: ""},
+		{name: "Tool", expected: ""},
+		{name: "in", expected: ""},
+	}
+
+	for _, tt := range tests {
+		if actual := suggestScalarType(tt.name); actual != tt.expected {
+			t.Errorf("Expected suggestion for '%v': '%v', Actual: '%v'", tt.name, tt.expected, actual)
+		}
+	}
+}
+
+func TestScalarDataTypeClassification(t *testing.T) {
+	var tests = []struct {
+		name     string
+		numeric  bool
+		integer  bool
+		unsigned bool
+		floating bool
+		packable bool
+		wire     WireType
+		goType   string
+		jsonType string
+	}{
+		{name: "any", wire: LengthDelimitedWireType, goType: "interface{}"},
+		{name: "bool", packable: true, wire: VarintWireType, goType: "bool", jsonType: "boolean"},
+		{name: "bytes", wire: LengthDelimitedWireType, goType: "[]byte", jsonType: "string"},
+		{name: "double", numeric: true, floating: true, packable: true, wire: Fixed64WireType, goType: "float64", jsonType: "number"},
+		{name: "float", numeric: true, floating: true, packable: true, wire: Fixed32WireType, goType: "float32", jsonType: "number"},
+		{name: "fixed32", numeric: true, integer: true, unsigned: true, packable: true, wire: Fixed32WireType, goType: "uint32", jsonType: "integer"},
+		{name: "fixed64", numeric: true, integer: true, unsigned: true, packable: true, wire: Fixed64WireType, goType: "uint64", jsonType: "string"},
+		{name: "int32", numeric: true, integer: true, packable: true, wire: VarintWireType, goType: "int32", jsonType: "integer"},
+		{name: "int64", numeric: true, integer: true, packable: true, wire: VarintWireType, goType: "int64", jsonType: "string"},
+		{name: "sfixed32", numeric: true, integer: true, packable: true, wire: Fixed32WireType, goType: "int32", jsonType: "integer"},
+		{name: "sfixed64", numeric: true, integer: true, packable: true, wire: Fixed64WireType, goType: "int64", jsonType: "string"},
+		{name: "sint32", numeric: true, integer: true, packable: true, wire: VarintWireType, goType: "int32", jsonType: "integer"},
+		{name: "sint64", numeric: true, integer: true, packable: true, wire: VarintWireType, goType: "int64", jsonType: "string"},
+		{name: "string", wire: LengthDelimitedWireType, goType: "string", jsonType: "string"},
+		{name: "uint32", numeric: true, integer: true, unsigned: true, packable: true, wire: VarintWireType, goType: "uint32", jsonType: "integer"},
+		{name: "uint64", numeric: true, integer: true, unsigned: true, packable: true, wire: VarintWireType, goType: "uint64", jsonType: "string"},
+	}
+
+	if len(tests) != len(scalarLookupMap) {
+		t.Fatalf("Expected all %v scalars to be covered, Actual: %v", len(scalarLookupMap), len(tests))
+	}
+	for _, tt := range tests {
+		sdt, err := NewScalarDataType(tt.name)
+		if err != nil {
+			t.Fatalf("%v", err.Error())
+		}
+		if sdt.Type() != scalarLookupMap[tt.name] {
+			t.Errorf("Expected type of %v: %v, Actual: %v", tt.name, scalarLookupMap[tt.name], sdt.Type())
+		}
+		if sdt.IsNumeric() != tt.numeric || sdt.IsInteger() != tt.integer || sdt.IsUnsigned() != tt.unsigned ||
+			sdt.IsFloatingPoint() != tt.floating || sdt.IsPackable() != tt.packable {
+			t.Errorf("Unexpected classification of %v: numeric: %v, integer: %v, unsigned: %v, floating point: %v, packable: %v",
+				tt.name, sdt.IsNumeric(), sdt.IsInteger(), sdt.IsUnsigned(), sdt.IsFloatingPoint(), sdt.IsPackable())
+		}
+		if sdt.WireType() != tt.wire || sdt.GoType() != tt.goType || sdt.JSONType() != tt.jsonType {
+			t.Errorf("Expected mapping of %v: %v, '%v', '%v', Actual: %v, '%v', '%v'",
+				tt.name, tt.wire, tt.goType, tt.jsonType, sdt.WireType(), sdt.GoType(), sdt.JSONType())
+		}
+	}
+}
+
+func TestDataTypeText(t *testing.T) {
+	var tests = []struct {
+		text     string
+		dt       interface{ UnmarshalText([]byte) error }
+		expected string
+	}{
+		{text: "int64", dt: &ScalarDataType{}, expected: "int64"},
+		{text: "Foo", dt: &NamedDataType{}, expected: "Foo"},
+		{text: "stream pkg.Foo", dt: &NamedDataType{}, expected: "stream pkg.Foo"},
+		{text: "map<string, Foo>", dt: &MapDataType{}, expected: "map<string, Foo>"},
+		{text: "map<int32,bytes>", dt: &MapDataType{}, expected: "map<int32, bytes>"},
+	}
+
+	for _, tt := range tests {
+		if err := tt.dt.UnmarshalText([]byte(tt.text)); err != nil {
+			t.Errorf("%v", err.Error())
+			continue
+		}
+		if s := fmt.Sprintf("%v", tt.dt); s != "&"+tt.expected && s != tt.expected {
+			t.Errorf("Expected: '%v', Actual: '%v'", tt.expected, s)
+		}
+		text, err := tt.dt.(interface{ MarshalText() ([]byte, error) }).MarshalText()
+		if err != nil || string(text) != tt.expected {
+			t.Errorf("Expected text: '%v', Actual: '%v' (%v)", tt.expected, string(text), err)
+		}
+	}
+
+	var sdt ScalarDataType
+	var ndt NamedDataType
+	var mdt MapDataType
+	for _, err := range []error{
+		sdt.UnmarshalText([]byte("int")),
+		ndt.UnmarshalText([]byte("stream ")),
+		ndt.UnmarshalText([]byte("string")),
+		mdt.UnmarshalText([]byte("map<double, Foo>")),
+		mdt.UnmarshalText([]byte("map<string>")),
+		mdt.UnmarshalText([]byte("Foo")),
+	} {
+		if err == nil {
+			t.Errorf("Expected an error for malformed text")
+		}
+	}
+}
+
+func TestDataTypeConstructorsCase(t *testing.T) {
+	for _, name := range []string{"int32", "Int32", "STRING"} {
+		sdt, err := NewScalarDataType(name)
+		if err != nil || sdt.Name() != strings.ToLower(name) {
+			t.Errorf("Name: %v, Expected the scalar datatype %v, Actual: %v, %v", name, strings.ToLower(name), sdt, err)
+		}
+		if _, err := NewNamedDataType(name); err == nil {
+			t.Errorf("Name: %v, Expected error on creating a NamedDataType named after a scalar datatype", name)
+		}
+	}
+	if _, err := NewNamedDataType("Int32Value"); err != nil {
+		t.Errorf("%v", err.Error())
+	}
+}
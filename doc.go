@@ -12,14 +12,14 @@ API
 
 Clients should invoke the following apis :-
 
-	func Parse(r io.Reader, p ImportModuleProvider) (ProtoFile, error)
+	func Parse(r io.Reader, p ImportModuleProvider, opts ...ParseOption) (ProtoFile, error)
 
 The Parse() function expects the client code to provide a reader for the protobuf content
 and also a ImportModuleProvider which can be used to callback the client code for any
 imports in the protobuf content. If there are no imports, the client can choose to pass
 this as nil.
 
-	func ParseFile(file string) (ProtoFile, error)
+	func ParseFile(file string, opts ...ParseOption) (ProtoFile, error)
 
 The ParseFile() function is a utility function which expects the client code to provide only the path
 of the protobuf file. If there are any imports in the protobuf file, the parser will look for them
@@ -43,14 +43,17 @@ This datastructure represents parsed model of the given protobuf file. It includ
 
 	type ProtoFile struct {
 		PackageName        string               // name of the package
-		Syntax             string               // the protocol buffer syntax
+		Syntax             Syntax               // the protocol buffer syntax
 		Dependencies       []string             // names of any imports
 		PublicDependencies []string             // names of any public imports
+		WeakDependencies   []string             // names of any weak imports
 		Options            []OptionElement      // any package level options
 		Enums              []EnumElement        // any defined enums
 		Messages           []MessageElement     // any defined messages
 		Services           []ServiceElement     // any defined services
 		ExtendDeclarations []ExtendElement      // any extends directives
+		RawDeclarations    []RawDeclaration     // any unrecognized declarations (lenient mode only)
+		Warnings           []string             // any warnings raised during validation
 	}
 
 Each attribute in turn has a defined structure, which is explained in the godoc of the corresponding elements.
@@ -0,0 +1,242 @@
+package pbparser
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GraphOptions holds the knobs which can be tweaked while generating a graph
+// via the GenerateDOT() api.
+type GraphOptions struct {
+	// ClusterByPackage clusters the nodes by package instead of by file.
+	ClusterByPackage bool
+	// IncludeWellKnownTypes includes the types in the google.protobuf package.
+	IncludeWellKnownTypes bool
+	// CollapseNested represents nested messages & enums by their top-level message.
+	CollapseNested bool
+	// MaxDepth represents messages & enums nested deeper than the given depth by
+	// their ancestor at that depth; top-level ones being at depth 1. Zero means
+	// no limit.
+	MaxDepth int
+}
+
+// GenerateDOT generates a Graphviz (DOT) graph of the given proto files, keyed by
+// their paths. Messages, enums and services become nodes, clustered by file (or
+// package); field references and the request/response types of rpcs become edges
+// labelled by the name of the field/rpc. Every file also gets a node of its own
+// with edges to the files it imports. Types which are referenced but which are not
+// defined in any of the given files are drawn with dashed outlines. References among
+// types which are represented by the same node (on collapsing nested types) are
+// dropped; that of a message referring to itself is not.
+func GenerateDOT(pfs map[string]ProtoFile, opts GraphOptions) ([]byte, error) {
+	maxDepth := opts.MaxDepth
+	if opts.CollapseNested {
+		maxDepth = 1
+	}
+
+	g := dotGenerator{
+		opts:     opts,
+		maxDepth: maxDepth,
+		nodes:    make(map[string]string),
+		external: make(map[string]bool),
+	}
+
+	var files []string
+	for f := range pfs {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+
+	// first, figure out the nodes for all the types (so that references across files resolve)...
+	clusters := make(map[string][]string)
+	var clusterNames []string
+	for _, f := range files {
+		pf := pfs[f]
+		cluster := f
+		if opts.ClusterByPackage {
+			cluster = pf.PackageName
+		}
+		if _, found := clusters[cluster]; !found {
+			clusterNames = append(clusterNames, cluster)
+		}
+		decls := []string{fmt.Sprintf("%v [label=%v, shape=note];", dotID("file:"+f), strconv.Quote(f))}
+		decls = append(decls, g.addEnums(pf.Enums, "", 1)...)
+		decls = append(decls, g.addMessages(pf.Messages, "", 1)...)
+		for _, se := range pf.Services {
+			g.nodes[se.QualifiedName] = se.QualifiedName
+			decls = append(decls, fmt.Sprintf("%v [label=%v, shape=component];", dotID(se.QualifiedName), strconv.Quote(se.Name)))
+		}
+		clusters[cluster] = append(clusters[cluster], decls...)
+	}
+
+	// next, the edges...
+	for _, f := range files {
+		pf := pfs[f]
+		deps := pf.imports()
+		for i, dep := range deps {
+			label := "import"
+			if i >= len(pf.Dependencies)+len(pf.PublicDependencies) {
+				label = "import weak"
+			} else if i >= len(pf.Dependencies) {
+				label = "import public"
+			}
+			target := "file:" + dep
+			if df, found := findDependency(files, dep); found {
+				target = "file:" + df
+			} else if !g.external[target] {
+				g.external[target] = true
+				g.externalDecls = append(g.externalDecls, fmt.Sprintf("%v [label=%v, shape=note, style=dashed];", dotID(target), strconv.Quote(dep)))
+			}
+			g.addEdge("file:"+f, target, label)
+		}
+		for _, msg := range pf.Messages {
+			g.addFieldEdges(msg)
+		}
+		for _, se := range pf.Services {
+			for _, rpc := range se.RPCs {
+				g.addReference(se.QualifiedName, se.QualifiedName, rpc.RequestType.Name(), rpc.Name+" (request)")
+				g.addReference(se.QualifiedName, se.QualifiedName, rpc.ResponseType.Name(), rpc.Name+" (response)")
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "digraph protos {\n")
+	fmt.Fprintf(&buf, "  rankdir=LR;\n")
+	fmt.Fprintf(&buf, "  node [shape=box];\n")
+	for i, cluster := range clusterNames {
+		fmt.Fprintf(&buf, "  subgraph cluster_%v {\n", i)
+		fmt.Fprintf(&buf, "    label=%v;\n", strconv.Quote(cluster))
+		for _, decl := range clusters[cluster] {
+			fmt.Fprintf(&buf, "    %v\n", decl)
+		}
+		fmt.Fprintf(&buf, "  }\n")
+	}
+	for _, decl := range g.externalDecls {
+		fmt.Fprintf(&buf, "  %v\n", decl)
+	}
+	for _, edge := range g.edges {
+		fmt.Fprintf(&buf, "  %v\n", edge)
+	}
+	fmt.Fprintf(&buf, "}\n")
+	return buf.Bytes(), nil
+}
+
+type dotGenerator struct {
+	opts          GraphOptions
+	maxDepth      int
+	nodes         map[string]string // qualified name of a type -> qualified name of the node representing it
+	external      map[string]bool
+	externalDecls []string
+	edges         []string
+}
+
+func (g *dotGenerator) addEnums(enums []EnumElement, parent string, depth int) []string {
+	var decls []string
+	for _, ee := range enums {
+		if !g.opts.IncludeWellKnownTypes && strings.HasPrefix(ee.QualifiedName, wellKnownTypesPrefix) {
+			continue
+		}
+		if parent != "" && g.maxDepth > 0 && depth > g.maxDepth {
+			g.nodes[ee.QualifiedName] = g.nodes[parent]
+			continue
+		}
+		g.nodes[ee.QualifiedName] = ee.QualifiedName
+		decls = append(decls, fmt.Sprintf("%v [label=%v, shape=ellipse];", dotID(ee.QualifiedName), strconv.Quote(ee.Name)))
+	}
+	return decls
+}
+
+func (g *dotGenerator) addMessages(msgs []MessageElement, parent string, depth int) []string {
+	var decls []string
+	for _, me := range msgs {
+		if !g.opts.IncludeWellKnownTypes && strings.HasPrefix(me.QualifiedName, wellKnownTypesPrefix) {
+			continue
+		}
+		if parent != "" && g.maxDepth > 0 && depth > g.maxDepth {
+			g.nodes[me.QualifiedName] = g.nodes[parent]
+		} else {
+			g.nodes[me.QualifiedName] = me.QualifiedName
+			decls = append(decls, fmt.Sprintf("%v [label=%v];", dotID(me.QualifiedName), strconv.Quote(me.Name)))
+		}
+		decls = append(decls, g.addEnums(me.Enums, me.QualifiedName, depth+1)...)
+		decls = append(decls, g.addMessages(me.Messages, me.QualifiedName, depth+1)...)
+	}
+	return decls
+}
+
+// addFieldEdges adds the edges for the fields of the given message and of its
+// nested messages (howsoever deep).
+func (g *dotGenerator) addFieldEdges(me MessageElement) {
+	if _, found := g.nodes[me.QualifiedName]; !found {
+		return
+	}
+	for _, f := range me.allFields() {
+		for _, name := range namedTypes(f.Type) {
+			g.addReference(me.QualifiedName, me.QualifiedName, name, f.Name)
+		}
+	}
+	for _, nested := range me.Messages {
+		g.addFieldEdges(nested)
+	}
+}
+
+// addReference adds an edge from the node representing the given type to the
+// node representing the referenced type name; resolved within the given scope.
+func (g *dotGenerator) addReference(from string, scope string, name string, label string) {
+	target, found := resolveTypeName(scope, name, func(n string) bool {
+		_, found := g.nodes[n]
+		return found
+	})
+	if !found {
+		target = strings.TrimPrefix(name, ".")
+		if strings.HasPrefix(target, wellKnownTypesPrefix) && !g.opts.IncludeWellKnownTypes {
+			return
+		}
+		if !g.external[target] {
+			g.external[target] = true
+			g.externalDecls = append(g.externalDecls, fmt.Sprintf("%v [style=dashed];", dotID(target)))
+		}
+	} else {
+		source := g.nodes[from]
+		node := g.nodes[target]
+		// drop the self references which are artifacts of collapsing nested types...
+		if source == node && from != target {
+			return
+		}
+		target = node
+	}
+	g.addEdge(g.nodes[from], target, label)
+}
+
+func (g *dotGenerator) addEdge(from string, to string, label string) {
+	g.edges = append(g.edges, fmt.Sprintf("%v -> %v [label=%v];", dotID(from), dotID(to), strconv.Quote(label)))
+}
+
+// namedTypes returns the names of the messages/enums referred to by the given datatype.
+func namedTypes(dt DataType) []string {
+	switch t := dt.(type) {
+	case NamedDataType:
+		return []string{t.Name()}
+	case MapDataType:
+		return namedTypes(t.valueType)
+	}
+	return nil
+}
+
+// findDependency finds the file among the given files which the given import refers to.
+func findDependency(files []string, dep string) (string, bool) {
+	for _, f := range files {
+		if f == dep || strings.HasSuffix(f, "/"+dep) {
+			return f, true
+		}
+	}
+	return "", false
+}
+
+func dotID(s string) string {
+	return strconv.Quote(s)
+}
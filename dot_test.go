@@ -0,0 +1,101 @@
+package pbparser_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tallstoat/pbparser"
+)
+
+const dotProto = `
+syntax = "proto3";
+package tree;
+
+message Node {
+  repeated Node children = 1;
+  Meta meta = 2;
+  message Meta {
+    Node owner = 1;
+    map<string, Tag> tags = 2;
+    message Tag {
+      string value = 1;
+    }
+  }
+}
+`
+
+func TestGenerateDOT(t *testing.T) {
+	pfs := make(map[string]pbparser.ProtoFile)
+	for _, f := range []string{"service.proto", "internal/publicx.proto", "descriptor.proto"} {
+		pf, err := pbparser.ParseFile("./resources/" + f)
+		if err != nil {
+			t.Fatalf("%v", err.Error())
+		}
+		pfs[f] = pf
+	}
+	pf, err := pbparser.Parse(strings.NewReader(dotProto), nil)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	pfs["tree.proto"] = pf
+
+	var tests = []struct {
+		opts       pbparser.GraphOptions
+		contains   []string
+		notContain []string
+	}{
+		{
+			opts: pbparser.GraphOptions{},
+			contains: []string{
+				`"file:service.proto" -> "file:internal/publicx.proto" [label="import public"];`,
+				`"file:internal/ext/privatex.proto" [label="internal/ext/privatex.proto", shape=note, style=dashed];`,
+				`"logtask.LogTask" -> "publicx.Duh" [label="RouteChat (request)"];`,
+				`"logtask.LogTask" -> "privatex.Meh" [label="RouteChat (response)"];`,
+				`"privatex.Meh" [style=dashed];`,
+				`"tree.Node" -> "tree.Node" [label="children"];`,
+				`"tree.Node.Meta" -> "tree.Node" [label="owner"];`,
+				`"tree.Node.Meta" -> "tree.Node.Meta.Tag" [label="tags"];`,
+			},
+			notContain: []string{`"google.protobuf.`},
+		},
+		{
+			opts: pbparser.GraphOptions{CollapseNested: true},
+			contains: []string{
+				`"tree.Node" -> "tree.Node" [label="children"];`,
+			},
+			notContain: []string{`"tree.Node.Meta"`, `[label="meta"]`, `[label="owner"]`, `[label="tags"]`},
+		},
+		{
+			opts: pbparser.GraphOptions{MaxDepth: 2},
+			contains: []string{
+				`"tree.Node.Meta" -> "tree.Node" [label="owner"];`,
+				`"tree.Node" -> "tree.Node.Meta" [label="meta"];`,
+			},
+			notContain: []string{`"tree.Node.Meta.Tag"`, `[label="tags"]`},
+		},
+		{
+			opts: pbparser.GraphOptions{IncludeWellKnownTypes: true, ClusterByPackage: true},
+			contains: []string{
+				`label="google.protobuf";`,
+				`"google.protobuf.FileDescriptorSet" -> "google.protobuf.FileDescriptorProto" [label="file"];`,
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		out, err := pbparser.GenerateDOT(pfs, tt.opts)
+		if err != nil {
+			t.Fatalf("%v", err.Error())
+		}
+		for _, s := range tt.contains {
+			if !strings.Contains(string(out), s) {
+				t.Errorf("Test %v: Expected graph to contain %v, Actual:\n%v", i, s, string(out))
+			}
+		}
+		for _, s := range tt.notContain {
+			if strings.Contains(string(out), s) {
+				t.Errorf("Test %v: Expected graph to not contain %v", i, s)
+			}
+		}
+	}
+}
@@ -1,12 +1,64 @@
 package pbparser
 
+// Documentation is a datastructure which models
+// the comments associated with a construct in a protobuf file.
+//
+// Leading holds the comment block which immediately precedes
+// the construct, Trailing holds the comment which follows it
+// (on the same line or on the next line if separated from the
+// next construct by a blank line) and Detached holds any comment
+// blocks preceding the construct which are separated from it
+// by a blank line. These follow the same rules as protoc.
+type Documentation struct {
+	Leading  string
+	Trailing string
+	Detached []string
+}
+
+// Position is a datastructure which models the location
+// of a construct in a protobuf file. Both Line and Column are 1-based.
+type Position struct {
+	Line   int
+	Column int
+}
+
+// RawDeclaration is a datastructure which models a declaration
+// which the parser does not understand. Such declarations are captured
+// verbatim (only when lenient parsing is enabled) so that the rest of the
+// file can still be parsed.
+type RawDeclaration struct {
+	Documentation Documentation
+	Text          string
+	Position      Position
+}
+
 // OptionElement is a datastructure which models
 // the option construct in a protobuf file. Option constructs
 // exist at various levels/contexts like file, message etc.
+// List (e.g. [1, 2]) and aggregate (e.g. { a: 1 }) values are
+// held verbatim, including the enclosing brackets or braces.
+// For a custom option which sets a field of its (message typed)
+// extension e.g. (validate.rules).string.min_len, the Name holds
+// the name of the extension and the FieldPath the dotted path of
+// the field within it.
 type OptionElement struct {
 	Name            string
 	Value           string
 	IsParenthesized bool
+	FieldPath       string
+}
+
+// FullName returns the name of the option as declared in the
+// protobuf file e.g. (validate.rules).string.min_len
+func (oe OptionElement) FullName() string {
+	name := oe.Name
+	if oe.IsParenthesized {
+		name = "(" + name + ")"
+	}
+	if oe.FieldPath != "" {
+		name += "." + oe.FieldPath
+	}
+	return name
 }
 
 // EnumConstantElement is a datastructure which models
@@ -14,7 +66,7 @@ type OptionElement struct {
 // also have inline options specified.
 type EnumConstantElement struct {
 	Name          string
-	Documentation string
+	Documentation Documentation
 	Options       []OptionElement
 	Tag           int
 }
@@ -22,12 +74,17 @@ type EnumConstantElement struct {
 // EnumElement is a datastructure which models
 // the enum construct in a protobuf file. Enums are
 // defined standalone or as nested entities within messages.
+// The values & names reserved within the enum are held in
+// ReservedRanges and ReservedNames.
 type EnumElement struct {
-	Name          string
-	QualifiedName string
-	Documentation string
-	Options       []OptionElement
-	EnumConstants []EnumConstantElement
+	Name           string
+	QualifiedName  string
+	Documentation  Documentation
+	Options        []OptionElement
+	EnumConstants  []EnumConstantElement
+	ReservedRanges []ReservedRangeElement
+	ReservedNames  []string
+	Ordinal        int
 }
 
 // RPCElement is a datastructure which models
@@ -35,7 +92,7 @@ type EnumElement struct {
 // nested within ServiceElements.
 type RPCElement struct {
 	Name          string
-	Documentation string
+	Documentation Documentation
 	Options       []OptionElement
 	RequestType   NamedDataType
 	ResponseType  NamedDataType
@@ -47,21 +104,95 @@ type RPCElement struct {
 type ServiceElement struct {
 	Name          string
 	QualifiedName string
-	Documentation string
+	Documentation Documentation
 	Options       []OptionElement
 	RPCs          []RPCElement
 }
 
+// The labels which a field can be declared with. LabelNone denotes
+// a field which is declared without a label.
+const (
+	LabelNone     = ""
+	LabelOptional = "optional"
+	LabelRequired = "required"
+	LabelRepeated = "repeated"
+)
+
 // FieldElement is a datastructure which models
 // a field of a message, a field of a oneof element
 // or an entry in the extend declaration in a protobuf file.
+//
+// OneOf holds the name of the enclosing oneof for the fields of a
+// oneof element and Proto3Optional is set for the fields declared
+// with an explicit 'optional' label in the proto3 syntax (which
+// track presence like the proto2 optional fields do).
+//
+// Group is set for the fields declared as (proto2) groups. As
+// protoc does, the body of a group is modelled as a message named
+// after the group which is nested within the enclosing message (or
+// the scope of the extend declaration); the field being named after
+// the group in lower case and being of the type of that message.
 type FieldElement struct {
-	Name          string
-	Documentation string
-	Options       []OptionElement
-	Label         string /* optional, required, repeated, oneof */
-	Type          DataType
-	Tag           int
+	Name           string
+	Documentation  Documentation
+	Options        []OptionElement
+	Label          string /* one of the Label* constants */
+	Type           DataType
+	Tag            int
+	OneOf          string
+	Proto3Optional bool
+	Group          bool
+	Ordinal        int
+}
+
+// IsRepeated returns true if the field is declared with the repeated label.
+func (fe FieldElement) IsRepeated() bool {
+	return fe.Label == LabelRepeated
+}
+
+// IsRequired returns true if the field is declared with the required label.
+func (fe FieldElement) IsRequired() bool {
+	return fe.Label == LabelRequired
+}
+
+// IsMap returns true if the datatype of the field is a map datatype.
+func (fe FieldElement) IsMap() bool {
+	return fe.Type != nil && fe.Type.Category() == MapDataTypeCategory
+}
+
+// IsOneOfMember returns true if the field is declared within a oneof element.
+func (fe FieldElement) IsOneOfMember() bool {
+	return fe.OneOf != ""
+}
+
+// JSONName returns the name of the field as per the proto3 JSON mapping; which is
+// the value of the json_name option of the field if specified. If not, it is derived
+// from the name of the field the way protoc does.
+func (fe FieldElement) JSONName() string {
+	if v, found := findOption(fe.Options, "json_name"); found {
+		return v
+	}
+	return jsonName(fe.Name)
+}
+
+// jsonName derives the JSON name from the given field name the way protoc does i.e.
+// underscores are dropped and the characters following them are upper-cased.
+func jsonName(s string) string {
+	var b []byte
+	capitalizeNext := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '_' {
+			capitalizeNext = true
+			continue
+		}
+		if capitalizeNext && isLower(c) {
+			c -= 'a' - 'A'
+		}
+		capitalizeNext = false
+		b = append(b, c)
+	}
+	return string(b)
 }
 
 // OneOfElement is a datastructure which models
@@ -70,9 +201,10 @@ type FieldElement struct {
 // set at any time.
 type OneOfElement struct {
 	Name          string
-	Documentation string
+	Documentation Documentation
 	Options       []OptionElement
 	Fields        []FieldElement
+	Ordinal       int
 }
 
 // ExtensionsElement is a datastructure which models
@@ -82,7 +214,8 @@ type OneOfElement struct {
 // to the original message definition by defining field ranges which
 // can be used for extensions.
 type ExtensionsElement struct {
-	Documentation string
+	Documentation Documentation
+	Options       []OptionElement
 	Start         int
 	End           int
 }
@@ -90,17 +223,21 @@ type ExtensionsElement struct {
 // ReservedRangeElement is a datastructure which models
 // a reserved construct in a protobuf message.
 type ReservedRangeElement struct {
-	Documentation string
+	Documentation Documentation
 	Start         int
 	End           int
 }
 
 // MessageElement is a datastructure which models
 // the message construct in a protobuf file.
+//
+// The fields, oneofs, enums, nested messages & extend declarations of
+// a message are held in separate slices; their Ordinal records the
+// order in which they were declared within the message.
 type MessageElement struct {
 	Name               string
 	QualifiedName      string
-	Documentation      string
+	Documentation      Documentation
 	Options            []OptionElement
 	Fields             []FieldElement
 	Enums              []EnumElement
@@ -110,6 +247,8 @@ type MessageElement struct {
 	Extensions         []ExtensionsElement
 	ReservedRanges     []ReservedRangeElement
 	ReservedNames      []string
+	RawDeclarations    []RawDeclaration
+	Ordinal            int
 }
 
 // ExtendElement is a datastructure which models
@@ -118,8 +257,24 @@ type MessageElement struct {
 type ExtendElement struct {
 	Name          string
 	QualifiedName string
-	Documentation string
+	Documentation Documentation
 	Fields        []FieldElement
+	Ordinal       int
+}
+
+// Syntax is the protocol buffer syntax used by a protobuf file
+// as specified by its syntax statement.
+type Syntax string
+
+// The syntaxes supported by the parser.
+const (
+	SyntaxProto2 Syntax = "proto2"
+	SyntaxProto3 Syntax = "proto3"
+)
+
+// String returns the syntax as specified in the syntax statement.
+func (s Syntax) String() string {
+	return string(s)
 }
 
 // ProtoFile is a datastructure which represents the parsed model
@@ -133,12 +288,49 @@ type ExtendElement struct {
 // client code.
 type ProtoFile struct {
 	PackageName        string
-	Syntax             string
+	Syntax             Syntax
 	Dependencies       []string
 	PublicDependencies []string
+	WeakDependencies   []string
 	Options            []OptionElement
 	Enums              []EnumElement
 	Messages           []MessageElement
 	Services           []ServiceElement
 	ExtendDeclarations []ExtendElement
+	RawDeclarations    []RawDeclaration
+
+	// Warnings holds the issues found during verification which (unlike
+	// errors) do not fail the parse.
+	Warnings []string
+
+	// the imports declaring the messages/enums (keyed by their qualified
+	// names) of the imported files; populated during verification.
+	importedTypes map[string]string
+
+	// the packages declared by the imported files (keyed by the imports);
+	// populated during verification.
+	importedPackages map[string]string
+
+	// the lines spanned by the leading & trailing comments of the elements
+	// along with the qualified names of the elements; populated during parsing.
+	commentOwners []commentOwner
+}
+
+// IsProto3 returns true if the protobuf file uses the proto3 syntax.
+func (pf *ProtoFile) IsProto3() bool {
+	return pf.Syntax == SyntaxProto3
+}
+
+// IsProto2 returns true if the protobuf file uses the proto2 syntax; which is
+// also the syntax in effect if the file does not specify any.
+func (pf *ProtoFile) IsProto2() bool {
+	return pf.Syntax == SyntaxProto2 || pf.Syntax == ""
+}
+
+// imports returns all the imports of the protobuf file; the plain ones followed by
+// the public and the weak ones.
+func (pf *ProtoFile) imports() []string {
+	l := append([]string{}, pf.Dependencies...)
+	l = append(l, pf.PublicDependencies...)
+	return append(l, pf.WeakDependencies...)
 }
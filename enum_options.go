@@ -0,0 +1,112 @@
+package pbparser
+
+import "strings"
+
+// the well-known options which take an enum value along with the constants of
+// their enums; options not listed here are not checked unless they are custom
+// options of a (resolvable) enum type...
+var enumOptions = map[string][]string{
+	"optimize_for":      {"SPEED", "CODE_SIZE", "LITE_RUNTIME"},
+	"ctype":             {"STRING", "CORD", "STRING_PIECE"},
+	"jstype":            {"JS_NORMAL", "JS_STRING", "JS_NUMBER"},
+	"idempotency_level": {"IDEMPOTENCY_UNKNOWN", "NO_SIDE_EFFECTS", "IDEMPOTENT"},
+}
+
+// extensionField is a field of an extend declaration along with the qualified name
+// of the scope (package or message) within which the extend is declared.
+type extensionField struct {
+	scope string
+	field FieldElement
+}
+
+// validateEnumOptions validates that the options which take an enum value are set
+// to one of the constants of the enum; both the well-known ones and the custom ones
+// whose extension fields (declared in the proto file or its dependencies) are of an
+// enum type. The custom options which do not resolve to such fields are skipped.
+func validateEnumOptions(pf *ProtoFile, m map[string]protoFileOracle) error {
+	extensions := make(map[string]extensionField)
+	enums := make(map[string]EnumElement)
+	collectEnumOptionTypes(pf, extensions, enums)
+	for _, orcl := range m {
+		if orcl.pf != pf {
+			collectEnumOptionTypes(orcl.pf, extensions, enums)
+		}
+	}
+	isExtension := func(qname string) bool {
+		_, found := extensions[qname]
+		return found
+	}
+	isEnum := func(qname string) bool {
+		_, found := enums[qname]
+		return found
+	}
+
+	return walkOptions(pf, func(kind string, name string, options []OptionElement) error {
+		for _, op := range options {
+			var allowed []string
+			if op.FieldPath != "" {
+				// the value is of a field of the extension rather than of the extension itself...
+				continue
+			} else if !op.IsParenthesized {
+				allowed = enumOptions[op.Name]
+			} else if qname, found := resolveTypeName(name, op.Name, isExtension); found {
+				ext := extensions[qname]
+				if ndt, ok := ext.field.Type.(NamedDataType); ok {
+					if qname, found := resolveTypeName(ext.scope, ndt.Name(), isEnum); found {
+						for _, enc := range enums[qname].EnumConstants {
+							allowed = append(allowed, enc.Name)
+						}
+					}
+				}
+			}
+			if len(allowed) == 0 || containsString(allowed, op.Value) {
+				continue
+			}
+			return newValidationError(InvalidOptionValueCode, name, "Option %v in %v %v must be one of %v. Found: '%v'",
+				op.FullName(), kind, name, strings.Join(allowed, ", "), op.Value)
+		}
+		return nil
+	})
+}
+
+// collectEnumOptionTypes notes the extension fields & the enums (keyed by their
+// qualified names) of the given proto file.
+func collectEnumOptionTypes(pf *ProtoFile, extensions map[string]extensionField, enums map[string]EnumElement) {
+	addExtends := func(scope string, extends []ExtendElement) {
+		for _, ee := range extends {
+			for _, f := range ee.Fields {
+				qname := f.Name
+				if scope != "" {
+					qname = scope + "." + f.Name
+				}
+				extensions[qname] = extensionField{scope: scope, field: f}
+			}
+		}
+	}
+	addEnums := func(list []EnumElement) {
+		for _, en := range list {
+			enums[en.QualifiedName] = en
+		}
+	}
+
+	var walk func(msgs []MessageElement)
+	walk = func(msgs []MessageElement) {
+		for _, msg := range msgs {
+			addExtends(msg.QualifiedName, msg.ExtendDeclarations)
+			addEnums(msg.Enums)
+			walk(msg.Messages)
+		}
+	}
+	addExtends(pf.PackageName, pf.ExtendDeclarations)
+	addEnums(pf.Enums)
+	walk(pf.Messages)
+}
+
+func containsString(l []string, s string) bool {
+	for _, e := range l {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
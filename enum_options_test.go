@@ -0,0 +1,66 @@
+package pbparser_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tallstoat/pbparser"
+)
+
+func TestEnumOptionValues(t *testing.T) {
+	provider := mapImportModuleProvider{
+		"levels.proto": `syntax = "proto3";
+package my;
+import "google/protobuf/descriptor.proto";
+enum Level {
+  LEVEL_UNKNOWN = 0;
+  LOW = 1;
+  HIGH = 2;
+}
+extend google.protobuf.MessageOptions {
+  Level level = 50001;
+  string owner = 50002;
+}
+`,
+		"google/protobuf/descriptor.proto": "syntax = \"proto2\";\npackage google.protobuf;\nmessage MessageOptions {\n  extensions 1000 to max;\n}\nmessage FieldOptions {\n  extensions 1000 to max;\n}\n",
+	}
+
+	var tests = []struct {
+		options  string
+		expected string
+	}{
+		{options: `option (my.level) = HIGH; option (my.owner) = "x";`},
+		{options: `option (.my.level) = LOW;`},
+		{options: `option (my.unknown) = WHATEVER;`},
+		{options: `option (my.level) = HIHG;`, expected: "Option (my.level) in message opts.Task must be one of LEVEL_UNKNOWN, LOW, HIGH. Found: 'HIHG'"},
+	}
+
+	for _, tt := range tests {
+		proto := `syntax = "proto3";
+package opts;
+import "levels.proto";
+message Task {
+  ` + tt.options + `
+  string id = 1 [ctype = CORD, jstype = JS_STRING];
+}
+`
+		_, err := pbparser.Parse(strings.NewReader(proto), provider)
+		if tt.expected == "" && err != nil {
+			t.Errorf("Expected no error for %v, Actual: %v", tt.options, err.Error())
+		}
+		if tt.expected != "" && (err == nil || err.Error() != tt.expected) {
+			t.Errorf("Expected error: %v, Actual: %v", tt.expected, err)
+		}
+	}
+
+	_, err := pbparser.Parse(strings.NewReader(`syntax = "proto3";
+package opts;
+message Task {
+  string id = 1 [jstype = JS_STRNG];
+}
+`), nil)
+	expected := "Option jstype in field opts.Task.id must be one of JS_NORMAL, JS_STRING, JS_NUMBER. Found: 'JS_STRNG'"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected error: %v, Actual: %v", expected, err)
+	}
+}
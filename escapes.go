@@ -0,0 +1,133 @@
+package pbparser
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// the characters which stand for themselves or for a control character when
+// preceded by a backslash within a string literal...
+var simpleEscapes = map[rune]byte{
+	'a': '\a', 'b': '\b', 'f': '\f', 'n': '\n', 'r': '\r', 't': '\t', 'v': '\v',
+	'\\': '\\', '\'': '\'', '"': '"', '?': '?',
+}
+
+// unescape reads the rest of an escape sequence (the backslash having been read
+// already) using the given functions and writes the character it stands for into
+// buf. The escapes are the ones supported by protobuf string literals i.e. \n and
+// the like, hex (\x41), octal (\101) and unicode (\u0041 or \U00000041) ones. The
+// hex & octal escapes stand for bytes rather than characters. The read function
+// is expected to return eof at the end of the input.
+func unescape(read func() rune, unread func(), buf *bytes.Buffer) error {
+	c := read()
+	if b, found := simpleEscapes[c]; found {
+		return buf.WriteByte(b)
+	}
+
+	// readDigits reads up to max digits of the given base...
+	readDigits := func(max int, base int) string {
+		var digits []rune
+		for len(digits) < max {
+			d := read()
+			if d == eof {
+				break
+			}
+			if digitValue(d) >= base {
+				unread()
+				break
+			}
+			digits = append(digits, d)
+		}
+		return string(digits)
+	}
+
+	switch {
+	case c == 'x' || c == 'X':
+		digits := readDigits(2, 16)
+		if digits == "" {
+			return invalidEscapeErr(string(c))
+		}
+		return buf.WriteByte(byte(parseDigits(digits, 16)))
+	case c >= '0' && c <= '7':
+		unread()
+		digits := readDigits(3, 8)
+		v := parseDigits(digits, 8)
+		if v > 0xff {
+			return invalidEscapeErr(digits)
+		}
+		return buf.WriteByte(byte(v))
+	case c == 'u' || c == 'U':
+		n := 4
+		if c == 'U' {
+			n = 8
+		}
+		digits := readDigits(n, 16)
+		r := rune(parseDigits(digits, 16))
+		if len(digits) != n || !utf8.ValidRune(r) {
+			return invalidEscapeErr(string(c) + digits)
+		}
+		_, err := buf.WriteRune(r)
+		return err
+	}
+	if c == eof {
+		return invalidEscapeErr("")
+	}
+	return invalidEscapeErr(string(c))
+}
+
+// unescapeString returns the given content of a string literal (without the
+// enclosing quotes) with its escape sequences replaced by what they stand for.
+func unescapeString(s string) (string, error) {
+	if !strings.Contains(s, "\\") {
+		return s, nil
+	}
+	r := strings.NewReader(s)
+	read := func() rune {
+		c, _, err := r.ReadRune()
+		if err != nil {
+			return eof
+		}
+		return c
+	}
+	unread := func() {
+		_ = r.UnreadRune()
+	}
+
+	var buf bytes.Buffer
+	for c := read(); c != eof; c = read() {
+		if c != '\\' {
+			_, _ = buf.WriteRune(c)
+		} else if err := unescape(read, unread, &buf); err != nil {
+			return "", err
+		}
+	}
+	return buf.String(), nil
+}
+
+func invalidEscapeErr(seq string) error {
+	return fmt.Errorf("Invalid escape sequence '\\%v'", seq)
+}
+
+// digitValue returns the value of the given hex (or lower base) digit; 16 is
+// returned for any other character.
+func digitValue(c rune) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'a' && c <= 'f':
+		return int(c-'a') + 10
+	case c >= 'A' && c <= 'F':
+		return int(c-'A') + 10
+	}
+	return 16
+}
+
+func parseDigits(digits string, base int) int {
+	v := 0
+	for _, d := range digits {
+		v = v*base + digitValue(d)
+	}
+	return v
+}
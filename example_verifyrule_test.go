@@ -0,0 +1,49 @@
+package pbparser_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tallstoat/pbparser"
+)
+
+// OwnerRule is a custom verification rule which requires every top level
+// message to specify its owner via the (company.owner) option.
+type OwnerRule struct{}
+
+func (r OwnerRule) Check(pf *pbparser.ProtoFile, ctx *pbparser.VerifyContext) []error {
+	var errs []error
+	for _, me := range pf.Messages {
+		var found bool
+		for _, op := range me.Options {
+			if op.IsParenthesized && op.Name == "company.owner" {
+				found = true
+			}
+		}
+		if !found {
+			errs = append(errs, errors.New("Message "+me.QualifiedName+" does not specify its owner"))
+		}
+	}
+	return errs
+}
+
+// Example code for registering a custom verification rule
+func Example_verifyRules() {
+	r := strings.NewReader(`syntax = "proto3";
+package tasks;
+
+message Task {
+  option (company.owner) = "tasks-team";
+  string id = 1;
+}
+
+message Note {
+  string text = 1;
+}
+`)
+
+	_, err := pbparser.Parse(r, nil, pbparser.WithVerifyRules(OwnerRule{}))
+	fmt.Println(err)
+	// Output: Message tasks.Note does not specify its owner
+}
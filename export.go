@@ -0,0 +1,186 @@
+package pbparser
+
+import "strings"
+
+// Export returns a plain view of the parsed model which is designed to be fed
+// into text/template (or html/template). The view is made up only of maps keyed
+// by strings, slices, strings, ints and bools so templates can navigate it without
+// having to call any methods.
+//
+// The keys of the maps are part of the api of this library; new keys might be
+// added over time but the existing ones will not be renamed or removed. The keys
+// for a file are Package, Syntax, Imports, PublicImports, WeakImports, Options,
+// Enums, Messages, Services and Extends. Each element carries Name, DocLines (the
+// leading comment split into lines), TrailingDoc and Options (a map of option name
+// to value where the names of custom options are enclosed in parenthesis). In
+// addition :-
+//
+//   - a message carries QualifiedName, Fields, OneOfs, Enums, Messages, Extends,
+//     ReservedRanges (maps with Start & End) and ReservedNames.
+//   - a field carries Type (the datatype as declared; empty if not set), Tag,
+//     Label, JSONName, IsRepeated, IsRequired, IsOptional, IsMap and for map
+//     fields, KeyType & ValueType.
+//   - a oneof carries Fields.
+//   - an enum carries QualifiedName, Constants (maps with Name, Tag etc),
+//     ReservedRanges and ReservedNames.
+//   - a service carries QualifiedName and RPCs; each of which carries RequestType,
+//     ResponseType, IsClientStreaming and IsServerStreaming.
+//   - an extend carries QualifiedName and Fields.
+//
+// Note that the consecutive // lines of a leading comment are joined into a single
+// line (separated by spaces) as they are in Documentation.Leading; it is only the
+// lines of a /* */ comment which end up as separate DocLines.
+func (pf *ProtoFile) Export() map[string]interface{} {
+	var services []map[string]interface{}
+	for _, se := range pf.Services {
+		services = append(services, exportService(se))
+	}
+	return map[string]interface{}{
+		"Package":       pf.PackageName,
+		"Syntax":        pf.Syntax.String(),
+		"Imports":       append([]string{}, pf.Dependencies...),
+		"PublicImports": append([]string{}, pf.PublicDependencies...),
+		"WeakImports":   append([]string{}, pf.WeakDependencies...),
+		"Options":       exportOptions(pf.Options),
+		"Enums":         exportEnums(pf.Enums),
+		"Messages":      exportMessages(pf.Messages),
+		"Services":      services,
+		"Extends":       exportExtends(pf.ExtendDeclarations),
+	}
+}
+
+func exportMessages(msgs []MessageElement) []map[string]interface{} {
+	var l []map[string]interface{}
+	for _, me := range msgs {
+		var oneofs []map[string]interface{}
+		for _, oo := range me.OneOfs {
+			m := exportElement(oo.Name, oo.Documentation, oo.Options)
+			m["Fields"] = exportFields(oo.Fields)
+			oneofs = append(oneofs, m)
+		}
+		m := exportElement(me.Name, me.Documentation, me.Options)
+		m["QualifiedName"] = me.QualifiedName
+		m["Fields"] = exportFields(me.Fields)
+		m["OneOfs"] = oneofs
+		m["Enums"] = exportEnums(me.Enums)
+		m["Messages"] = exportMessages(me.Messages)
+		m["Extends"] = exportExtends(me.ExtendDeclarations)
+		m["ReservedRanges"] = exportReservedRanges(me.ReservedRanges)
+		m["ReservedNames"] = append([]string{}, me.ReservedNames...)
+		l = append(l, m)
+	}
+	return l
+}
+
+func exportFields(fields []FieldElement) []map[string]interface{} {
+	var l []map[string]interface{}
+	for _, f := range fields {
+		m := exportElement(f.Name, f.Documentation, f.Options)
+		m["Type"] = ""
+		if f.Type != nil {
+			m["Type"] = f.Type.Name()
+		}
+		m["Tag"] = f.Tag
+		m["Label"] = f.Label
+		m["JSONName"] = f.JSONName()
+		m["IsRepeated"] = f.IsRepeated()
+		m["IsRequired"] = f.IsRequired()
+		m["IsOptional"] = f.Label == LabelOptional
+		m["IsMap"] = f.IsMap()
+		if mdt, ok := f.Type.(MapDataType); ok {
+			m["KeyType"] = mdt.KeyType().Name()
+			m["ValueType"] = mdt.ValueType().Name()
+		}
+		l = append(l, m)
+	}
+	return l
+}
+
+func exportEnums(enums []EnumElement) []map[string]interface{} {
+	var l []map[string]interface{}
+	for _, ee := range enums {
+		var constants []map[string]interface{}
+		for _, ec := range ee.EnumConstants {
+			c := exportElement(ec.Name, ec.Documentation, ec.Options)
+			c["Tag"] = ec.Tag
+			constants = append(constants, c)
+		}
+		m := exportElement(ee.Name, ee.Documentation, ee.Options)
+		m["QualifiedName"] = ee.QualifiedName
+		m["Constants"] = constants
+		m["ReservedRanges"] = exportReservedRanges(ee.ReservedRanges)
+		m["ReservedNames"] = append([]string{}, ee.ReservedNames...)
+		l = append(l, m)
+	}
+	return l
+}
+
+func exportReservedRanges(ranges []ReservedRangeElement) []map[string]interface{} {
+	var l []map[string]interface{}
+	for _, rr := range ranges {
+		l = append(l, map[string]interface{}{"Start": rr.Start, "End": rr.End})
+	}
+	return l
+}
+
+func exportService(se ServiceElement) map[string]interface{} {
+	var rpcs []map[string]interface{}
+	for _, rpc := range se.RPCs {
+		r := exportElement(rpc.Name, rpc.Documentation, rpc.Options)
+		r["RequestType"] = rpc.RequestType.Name()
+		r["ResponseType"] = rpc.ResponseType.Name()
+		r["IsClientStreaming"] = rpc.RequestType.IsStream()
+		r["IsServerStreaming"] = rpc.ResponseType.IsStream()
+		rpcs = append(rpcs, r)
+	}
+	m := exportElement(se.Name, se.Documentation, se.Options)
+	m["QualifiedName"] = se.QualifiedName
+	m["RPCs"] = rpcs
+	return m
+}
+
+func exportExtends(extends []ExtendElement) []map[string]interface{} {
+	var l []map[string]interface{}
+	for _, ee := range extends {
+		m := exportElement(ee.Name, ee.Documentation, nil)
+		m["QualifiedName"] = ee.QualifiedName
+		m["Fields"] = exportFields(ee.Fields)
+		l = append(l, m)
+	}
+	return l
+}
+
+// exportElement returns the map with the keys which are common to all elements.
+func exportElement(name string, doc Documentation, options []OptionElement) map[string]interface{} {
+	return map[string]interface{}{
+		"Name":        name,
+		"DocLines":    docLines(doc.Leading),
+		"TrailingDoc": strings.TrimSpace(doc.Trailing),
+		"Options":     exportOptions(options),
+	}
+}
+
+func exportOptions(options []OptionElement) map[string]string {
+	m := make(map[string]string)
+	for _, op := range options {
+		m[op.FullName()] = op.Value
+	}
+	return m
+}
+
+// docLines splits the given comment into trimmed lines; dropping any blank
+// lines at the start and the end. Only the comments read from /* */ blocks have
+// more than one line; the // lines being joined by the parser.
+func docLines(s string) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		lines = append(lines, strings.TrimSpace(line))
+	}
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
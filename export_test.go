@@ -0,0 +1,103 @@
+package pbparser_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/tallstoat/pbparser"
+)
+
+const summaryTemplate = `# {{.Package}} ({{.Syntax}})
+{{range .Services}}
+## Service {{.Name}}
+{{range .DocLines}}{{.}}
+{{end}}{{range .RPCs}}
+* {{.Name}}({{if .IsClientStreaming}}stream {{end}}{{.RequestType}}) -> {{if .IsServerStreaming}}stream {{end}}{{.ResponseType}}{{end}}
+{{end}}{{range .Messages}}
+## Message {{.QualifiedName}}
+{{range .DocLines}}{{.}}
+{{end}}
+| Field | Type | Tag |
+|---|---|---|
+{{range .Fields}}| {{.Name}}{{if index .Options "deprecated"}} (deprecated){{end}} | {{if .IsRepeated}}repeated {{end}}{{if .IsMap}}map of {{.KeyType}} to {{.ValueType}}{{else}}{{.Type}}{{end}} | {{.Tag}} |
+{{end}}{{range .OneOfs}}{{$oneof := .Name}}{{range .Fields}}| {{.Name}} (oneof {{$oneof}}) | {{.Type}} | {{.Tag}} |
+{{end}}{{end}}{{end}}`
+
+func TestExport(t *testing.T) {
+	pf, err := pbparser.ParseFile("./resources/service.proto")
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+
+	tmpl := template.Must(template.New("summary").Parse(summaryTemplate))
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, pf.Export()); err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	out := buf.String()
+
+	var expected = []string{
+		"# logtask (proto2)\n",
+		"## Service LogTask\nLogTask is a service which handles operations on tasks defined via a custom DSL\n",
+		"* AddTask(Task) -> TaskId\n",
+		"* RouteChat(stream publicx.Duh) -> stream privatex.Meh\n",
+		"## Message logtask.Task\nTask object...\n",
+		"| priority (deprecated) | string | 4 |\n",
+		"| tags | repeated string | 10 |\n",
+		"| buzz (oneof fizzbuzz) | int32 | 13 |\n",
+		"| statusmap | map of string to ReturnStatus | 2 |\n",
+	}
+	for _, s := range expected {
+		if !strings.Contains(out, s) {
+			t.Errorf("Expected output to contain %q, Actual output:\n%v", s, out)
+		}
+	}
+}
+
+func TestExportFieldWithoutType(t *testing.T) {
+	pf := pbparser.ProtoFile{PackageName: "built", Syntax: "proto3"}
+	me := pbparser.MessageElement{Name: "Item"}
+	me.Fields = append(me.Fields, pbparser.FieldElement{Name: "id", Tag: 1})
+	if err := pf.AddMessage(me); err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+
+	fields := pf.Export()["Messages"].([]map[string]interface{})[0]["Fields"].([]map[string]interface{})
+	if len(fields) != 1 || fields[0]["Type"] != "" || fields[0]["IsMap"] != false {
+		t.Errorf("Expected the field to be exported with an empty Type, Actual: %v", fields)
+	}
+}
+
+func TestExportDocLines(t *testing.T) {
+	pf, err := pbparser.Parse(strings.NewReader(`syntax = "proto3";
+package docs;
+// Line comments are
+// joined into one line.
+message Lines {}
+/*
+ * Block comments keep
+ * their lines.
+ */
+message Block {}
+`), nil)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+
+	msgs := pf.Export()["Messages"].([]map[string]interface{})
+	var tests = []struct {
+		actual   interface{}
+		expected []string
+	}{
+		{actual: msgs[0]["DocLines"], expected: []string{"Line comments are joined into one line."}},
+		{actual: msgs[1]["DocLines"], expected: []string{"Block comments keep", "their lines."}},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.actual, tt.expected) {
+			t.Errorf("Expected: %q, Actual: %q", tt.expected, tt.actual)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package pbparser
+
+import "strings"
+
+// GoPackage returns the value of the go_package option of the proto file and
+// whether the option is specified.
+func (pf *ProtoFile) GoPackage() (string, bool) {
+	return findOption(pf.Options, "go_package")
+}
+
+// GoImportPath splits the value of the go_package option of the proto file into
+// the import path and the name of the go package around the ';' (if any). If the
+// name is not given explicitly, it is the last element of the import path as is
+// the case with protoc-gen-go. The last value returned is false if the option is
+// not specified or if either of the parts is empty.
+func (pf *ProtoFile) GoImportPath() (string, string, bool) {
+	v, found := pf.GoPackage()
+	if !found {
+		return "", "", false
+	}
+	importPath, name := v, v[strings.LastIndex(v, "/")+1:]
+	if i := strings.Index(v, ";"); i >= 0 {
+		importPath, name = v[:i], v[i+1:]
+	}
+	if importPath == "" || name == "" {
+		return "", "", false
+	}
+	return importPath, name, true
+}
+
+// JavaPackage returns the value of the java_package option of the proto file and
+// whether the option is specified.
+func (pf *ProtoFile) JavaPackage() (string, bool) {
+	return findOption(pf.Options, "java_package")
+}
+
+// JavaMultipleFiles returns the value of the java_multiple_files option of the
+// proto file. The second value returned is false if the option is not specified
+// or if its value is not a boolean.
+func (pf *ProtoFile) JavaMultipleFiles() (bool, bool) {
+	v, found := findOption(pf.Options, "java_multiple_files")
+	if !found || (v != "true" && v != "false") {
+		return false, false
+	}
+	return v == "true", true
+}
+
+// OptimizeFor returns the value of the optimize_for option of the proto file i.e.
+// one of SPEED, CODE_SIZE or LITE_RUNTIME. The second value returned is false if
+// the option is not specified or if its value is not one of these.
+func (pf *ProtoFile) OptimizeFor() (string, bool) {
+	v, found := findOption(pf.Options, "optimize_for")
+	if !found || (v != "SPEED" && v != "CODE_SIZE" && v != "LITE_RUNTIME") {
+		return "", false
+	}
+	return v, true
+}
+
+// CsharpNamespace returns the value of the csharp_namespace option of the proto
+// file and whether the option is specified.
+func (pf *ProtoFile) CsharpNamespace() (string, bool) {
+	return findOption(pf.Options, "csharp_namespace")
+}
+
+// ObjcClassPrefix returns the value of the objc_class_prefix option of the proto
+// file and whether the option is specified.
+func (pf *ProtoFile) ObjcClassPrefix() (string, bool) {
+	return findOption(pf.Options, "objc_class_prefix")
+}
+
+// findOption returns the value of the (non custom) option with the given name
+// amongst the given options and whether it is found. If the option is specified
+// more than once, the last value is returned.
+func findOption(options []OptionElement, name string) (string, bool) {
+	var value string
+	var found bool
+	for _, op := range options {
+		if op.Name == name && !op.IsParenthesized {
+			value, found = op.Value, true
+		}
+	}
+	return value, found
+}
@@ -0,0 +1,106 @@
+package pbparser_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tallstoat/pbparser"
+)
+
+func TestFileOptions(t *testing.T) {
+	pf, err := pbparser.Parse(strings.NewReader(`syntax = "proto3";
+package opts;
+option go_package = "example.com/shop/v1;shopv1";
+option java_package = "com.example.shop.v1";
+option java_multiple_files = true;
+option optimize_for = CODE_SIZE;
+option csharp_namespace = "Example.Shop.V1";
+option objc_class_prefix = "ESV";
+`), nil)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	var empty pbparser.ProtoFile
+
+	var tests = []struct {
+		name     string
+		pf       *pbparser.ProtoFile
+		accessor func(pf *pbparser.ProtoFile) (string, bool)
+		value    string
+		found    bool
+	}{
+		{name: "go_package", pf: &pf, accessor: (*pbparser.ProtoFile).GoPackage, value: "example.com/shop/v1;shopv1", found: true},
+		{name: "go_package", pf: &empty, accessor: (*pbparser.ProtoFile).GoPackage},
+		{name: "java_package", pf: &pf, accessor: (*pbparser.ProtoFile).JavaPackage, value: "com.example.shop.v1", found: true},
+		{name: "java_package", pf: &empty, accessor: (*pbparser.ProtoFile).JavaPackage},
+		{name: "optimize_for", pf: &pf, accessor: (*pbparser.ProtoFile).OptimizeFor, value: "CODE_SIZE", found: true},
+		{name: "optimize_for", pf: &empty, accessor: (*pbparser.ProtoFile).OptimizeFor},
+		{name: "csharp_namespace", pf: &pf, accessor: (*pbparser.ProtoFile).CsharpNamespace, value: "Example.Shop.V1", found: true},
+		{name: "csharp_namespace", pf: &empty, accessor: (*pbparser.ProtoFile).CsharpNamespace},
+		{name: "objc_class_prefix", pf: &pf, accessor: (*pbparser.ProtoFile).ObjcClassPrefix, value: "ESV", found: true},
+		{name: "objc_class_prefix", pf: &empty, accessor: (*pbparser.ProtoFile).ObjcClassPrefix},
+	}
+
+	for _, tt := range tests {
+		value, found := tt.accessor(tt.pf)
+		if value != tt.value || found != tt.found {
+			t.Errorf("Expected %v: '%v' (%v), Actual: '%v' (%v)", tt.name, tt.value, tt.found, value, found)
+		}
+	}
+
+	if v, found := pf.JavaMultipleFiles(); !v || !found {
+		t.Errorf("Expected java_multiple_files: true (true), Actual: %v (%v)", v, found)
+	}
+	if v, found := empty.JavaMultipleFiles(); v || found {
+		t.Errorf("Expected java_multiple_files: false (false), Actual: %v (%v)", v, found)
+	}
+}
+
+func TestFileOptionsMalformed(t *testing.T) {
+	malformed := pbparser.ProtoFile{Options: []pbparser.OptionElement{
+		{Name: "java_multiple_files", Value: "yes"},
+		{Name: "optimize_for", Value: "FAST"},
+	}}
+	if v, found := malformed.JavaMultipleFiles(); v || found {
+		t.Errorf("Expected malformed java_multiple_files to be not found, Actual: %v (%v)", v, found)
+	}
+	if v, found := malformed.OptimizeFor(); v != "" || found {
+		t.Errorf("Expected malformed optimize_for to be not found, Actual: '%v' (%v)", v, found)
+	}
+
+	// custom options of the same name must not be mistaken for the well-known ones...
+	custom := pbparser.ProtoFile{Options: []pbparser.OptionElement{{Name: "go_package", Value: "x", IsParenthesized: true}}}
+	if v, found := custom.GoPackage(); v != "" || found {
+		t.Errorf("Expected the custom option (go_package) to be ignored, Actual: '%v' (%v)", v, found)
+	}
+}
+
+func TestGoImportPath(t *testing.T) {
+	var tests = []struct {
+		goPackage  string
+		importPath string
+		name       string
+		found      bool
+	}{
+		{goPackage: "example.com/shop/v1;shopv1", importPath: "example.com/shop/v1", name: "shopv1", found: true},
+		{goPackage: "example.com/shop/v1", importPath: "example.com/shop/v1", name: "v1", found: true},
+		{goPackage: "shop", importPath: "shop", name: "shop", found: true},
+		{goPackage: "example.com/shop/v1;"},
+		{goPackage: ";shopv1"},
+		{goPackage: "example.com/shop/"},
+	}
+
+	for _, tt := range tests {
+		pf := pbparser.ProtoFile{Options: []pbparser.OptionElement{{Name: "go_package", Value: tt.goPackage}}}
+		importPath, name, found := pf.GoImportPath()
+		if importPath != tt.importPath || name != tt.name || found != tt.found {
+			t.Errorf("Expected for go_package '%v': '%v', '%v' (%v), Actual: '%v', '%v' (%v)",
+				tt.goPackage, tt.importPath, tt.name, tt.found, importPath, name, found)
+		}
+	}
+
+	var empty pbparser.ProtoFile
+	if _, _, found := empty.GoImportPath(); found {
+		t.Errorf("Expected no go_package to be found")
+	}
+}
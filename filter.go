@@ -0,0 +1,132 @@
+package pbparser
+
+// OptionScope is an enumeration which represents the kinds of elements
+// options can be specified for.
+type OptionScope int
+
+const (
+	FileOptionScope OptionScope = iota
+	MessageOptionScope
+	FieldOptionScope
+	OneOfOptionScope
+	EnumOptionScope
+	EnumValueOptionScope
+	ExtensionRangeOptionScope
+	ServiceOptionScope
+	RPCOptionScope
+)
+
+// FilterOptions drops the options of the given proto file (howsoever deeply nested)
+// which the given function does not want to keep; the function being passed the
+// scope of each option along with the option. The options of all the elements are
+// visited i.e. the ones of the file, messages, fields (including those of oneofs &
+// extend declarations), oneofs, enums, enum values, extension ranges, services and
+// rpcs.
+//
+// The imports of the packages which defined the dropped custom options are dropped
+// as well if nothing else from the packages is in use anymore. This relies on the
+// proto file having been produced by the Parse() or the ParseFile() api.
+func FilterOptions(pf *ProtoFile, keep func(scope OptionScope, o OptionElement) bool) {
+	f := optionFilter{keep: keep, dropped: make(map[string]bool)}
+	pf.Options = f.filter(FileOptionScope, pf.Options)
+	f.filterEnums(pf.Enums)
+	f.filterMessages(pf.Messages)
+	f.filterExtends(pf.ExtendDeclarations)
+	for i := range pf.Services {
+		se := &pf.Services[i]
+		se.Options = f.filter(ServiceOptionScope, se.Options)
+		for j := range se.RPCs {
+			se.RPCs[j].Options = f.filter(RPCOptionScope, se.RPCs[j].Options)
+		}
+	}
+	if len(f.dropped) == 0 {
+		return
+	}
+
+	// find the packages (of the imports) which are no longer in use...
+	var deps []string
+	for _, pkg := range pf.importedPackages {
+		if pkg != "" && pkg != pf.PackageName {
+			deps = append(deps, pkg)
+		}
+	}
+	packages := newPackageSet(pf.PackageName, deps)
+	used := usedPackages(pf, packages)
+	unused := make(map[string]bool)
+	for name := range f.dropped {
+		if pkg := packages.packageOf(name); pkg != "" && pkg != pf.PackageName && !used[pkg] {
+			unused[pkg] = true
+		}
+	}
+	prune := func(imports []string) []string {
+		var kept []string
+		for _, d := range imports {
+			if !unused[pf.importedPackages[d]] {
+				kept = append(kept, d)
+			}
+		}
+		return kept
+	}
+	pf.Dependencies = prune(pf.Dependencies)
+	pf.PublicDependencies = prune(pf.PublicDependencies)
+	pf.WeakDependencies = prune(pf.WeakDependencies)
+}
+
+// optionFilter drops the options which are not to be kept; noting the names of
+// the custom options dropped.
+type optionFilter struct {
+	keep    func(scope OptionScope, o OptionElement) bool
+	dropped map[string]bool
+}
+
+func (f optionFilter) filter(scope OptionScope, options []OptionElement) []OptionElement {
+	var kept []OptionElement
+	for _, op := range options {
+		if f.keep(scope, op) {
+			kept = append(kept, op)
+		} else if op.IsParenthesized {
+			f.dropped[op.Name] = true
+		}
+	}
+	return kept
+}
+
+func (f optionFilter) filterMessages(msgs []MessageElement) {
+	for i := range msgs {
+		me := &msgs[i]
+		me.Options = f.filter(MessageOptionScope, me.Options)
+		f.filterFields(me.Fields)
+		for j := range me.OneOfs {
+			me.OneOfs[j].Options = f.filter(OneOfOptionScope, me.OneOfs[j].Options)
+			f.filterFields(me.OneOfs[j].Fields)
+		}
+		for j := range me.Extensions {
+			me.Extensions[j].Options = f.filter(ExtensionRangeOptionScope, me.Extensions[j].Options)
+		}
+		f.filterEnums(me.Enums)
+		f.filterExtends(me.ExtendDeclarations)
+		f.filterMessages(me.Messages)
+	}
+}
+
+func (f optionFilter) filterFields(fields []FieldElement) {
+	for i := range fields {
+		fields[i].Options = f.filter(FieldOptionScope, fields[i].Options)
+	}
+}
+
+func (f optionFilter) filterEnums(enums []EnumElement) {
+	for i := range enums {
+		ee := &enums[i]
+		ee.Options = f.filter(EnumOptionScope, ee.Options)
+		for j := range ee.EnumConstants {
+			ee.EnumConstants[j].Options = f.filter(EnumValueOptionScope, ee.EnumConstants[j].Options)
+		}
+	}
+}
+
+func (f optionFilter) filterExtends(extends []ExtendElement) {
+	for i := range extends {
+		f.filterFields(extends[i].Fields)
+	}
+}
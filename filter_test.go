@@ -0,0 +1,68 @@
+package pbparser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tallstoat/pbparser"
+)
+
+func TestFilterOptions(t *testing.T) {
+	pf, err := pbparser.ParseFile("./resources/filter/annotated.proto")
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+
+	var scopes []pbparser.OptionScope
+	pbparser.FilterOptions(&pf, func(scope pbparser.OptionScope, o pbparser.OptionElement) bool {
+		if o.IsParenthesized && strings.HasPrefix(o.Name, "gogoproto.") {
+			scopes = append(scopes, scope)
+			return false
+		}
+		return true
+	})
+
+	expectedScopes := []pbparser.OptionScope{
+		pbparser.FileOptionScope,
+		pbparser.EnumOptionScope,
+		pbparser.EnumValueOptionScope,
+		pbparser.MessageOptionScope,
+		pbparser.FieldOptionScope,
+		pbparser.OneOfOptionScope,
+		pbparser.FieldOptionScope,
+		pbparser.RPCOptionScope,
+	}
+	if !reflect.DeepEqual(expectedScopes, scopes) {
+		t.Errorf("Expected the options dropped in scopes: %v, Actual: %v", expectedScopes, scopes)
+	}
+
+	// the result must be the same as that of a file written without the gogoproto
+	// options & import in the first place...
+	expected, err := pbparser.ParseFile("./resources/filter/stripped.proto")
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	if !reflect.DeepEqual(expected.Export(), pf.Export()) {
+		t.Errorf("Expected: %v, Actual: %v", expected.Export(), pf.Export())
+	}
+}
+
+func TestFilterOptionsKeepsImportsInUse(t *testing.T) {
+	pf, err := pbparser.ParseFile("./resources/filter/annotated.proto")
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+
+	// the types of acme.internal are still in use; so its import must be retained...
+	pbparser.FilterOptions(&pf, func(scope pbparser.OptionScope, o pbparser.OptionElement) bool {
+		return !o.IsParenthesized || !strings.HasPrefix(o.Name, "acme.internal.")
+	})
+	expected := []string{"gogo.proto", "internal.proto"}
+	if !reflect.DeepEqual(expected, pf.Dependencies) {
+		t.Errorf("Expected imports: %v, Actual: %v", expected, pf.Dependencies)
+	}
+	if len(pf.Services[0].Options) != 0 {
+		t.Errorf("Expected no options for service Shop, Actual: %v", pf.Services[0].Options)
+	}
+}
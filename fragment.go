@@ -0,0 +1,90 @@
+package pbparser
+
+import (
+	"errors"
+	"io"
+)
+
+// ParseMessage parses a fragment of protobuf content holding a single message
+// declaration (along with its documentation) e.g. one pasted from documentation.
+// The fragment needs no syntax or package declaration and is not verified; so the
+// types it references need not be defined and the qualified names of the message
+// (and of the types nested within it) are not prefixed by any package name. Any
+// ParseOption(s) passed in tweak the default behavior of the parser.
+//
+// If the fragment is not a single message declaration or if the parsing fails, an
+// Error is returned; with the location in it being relative to the fragment.
+func ParseMessage(r io.Reader, opts ...ParseOption) (MessageElement, error) {
+	pf, err := parseFragment(r, "message", opts)
+	if err != nil {
+		return MessageElement{}, err
+	}
+	return pf.Messages[0], nil
+}
+
+// ParseEnum parses a fragment of protobuf content holding a single enum declaration.
+// It works the same way as the ParseMessage() api.
+func ParseEnum(r io.Reader, opts ...ParseOption) (EnumElement, error) {
+	pf, err := parseFragment(r, "enum", opts)
+	if err != nil {
+		return EnumElement{}, err
+	}
+	return pf.Enums[0], nil
+}
+
+// ParseService parses a fragment of protobuf content holding a single service
+// declaration. It works the same way as the ParseMessage() api.
+func ParseService(r io.Reader, opts ...ParseOption) (ServiceElement, error) {
+	pf, err := parseFragment(r, "service", opts)
+	if err != nil {
+		return ServiceElement{}, err
+	}
+	return pf.Services[0], nil
+}
+
+// parseFragment parses a single declaration of the given kind into a ProtoFile.
+func parseFragment(r io.Reader, kind string, opts []ParseOption) (ProtoFile, error) {
+	if r == nil {
+		return ProtoFile{}, errors.New("Reader for protobuf content is mandatory")
+	}
+
+	pf := ProtoFile{}
+	o := newParseOptions(opts)
+	if err := o.checkFileOptions(); err != nil {
+		return pf, err
+	}
+	p := newParser(r, o)
+	defer p.release()
+
+	documentation, err := p.readDocumentationIfFound()
+	if err != nil {
+		return pf, err
+	}
+	p.skipWhitespace()
+	if label := p.readWord(); label != kind {
+		return pf, p.errline("Expected '%v', but found: '%v'", kind, label)
+	}
+
+	ctx := parseCtx{ctxType: fileCtx}
+	switch kind {
+	case "message":
+		err = p.readMessage(&pf, documentation, ctx)
+	case "enum":
+		err = p.readEnum(&pf, documentation, ctx)
+	case "service":
+		err = p.readService(&pf, documentation)
+	}
+	if err != nil {
+		return pf, err
+	}
+
+	// nothing but comments should follow the declaration...
+	if _, err := p.readDocumentationIfFound(); err != nil {
+		return pf, err
+	}
+	p.skipWhitespace()
+	if !p.eofReached {
+		return pf, p.errline("Unexpected content after the %v declaration", kind)
+	}
+	return pf, nil
+}
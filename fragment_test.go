@@ -0,0 +1,83 @@
+package pbparser_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tallstoat/pbparser"
+)
+
+func TestParseFragment(t *testing.T) {
+	me, err := pbparser.ParseMessage(strings.NewReader(`
+// A search request.
+message SearchRequest {
+  string query = 1;
+  optional int32 page = 2;
+  map<string, Filter> filters = 3;
+  message Filter {
+    repeated string values = 1;
+  }
+}
+// trailing junk comment
+`))
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	if me.QualifiedName != "SearchRequest" || me.Messages[0].QualifiedName != "SearchRequest.Filter" {
+		t.Errorf("Expected unprefixed qualified names, Actual: %v, %v", me.QualifiedName, me.Messages[0].QualifiedName)
+	}
+	if me.Documentation.Leading != "A search request." || len(me.Fields) != 3 {
+		t.Errorf("Unexpected message: %v", me)
+	}
+
+	ee, err := pbparser.ParseEnum(strings.NewReader("enum Color { RED = 0; GREEN = 1; }"))
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	if ee.QualifiedName != "Color" || len(ee.EnumConstants) != 2 {
+		t.Errorf("Unexpected enum: %v", ee)
+	}
+
+	se, err := pbparser.ParseService(strings.NewReader(`service Search {
+  rpc Find (SearchRequest) returns (stream SearchResponse);
+}`))
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	if se.QualifiedName != "Search" || se.RPCs[0].ResponseType.Name() != "SearchResponse" {
+		t.Errorf("Unexpected service: %v", se)
+	}
+
+	var errTests = []struct {
+		fragment string
+		parse    func(string) error
+		errorstr string
+	}{
+		{
+			fragment: "enum Color { RED = 0; }",
+			parse:    func(s string) error { _, err := pbparser.ParseMessage(strings.NewReader(s)); return err },
+			errorstr: "Expected 'message', but found: 'enum' on line: 1",
+		},
+		{
+			fragment: "message A {}\nmessage B {}",
+			parse:    func(s string) error { _, err := pbparser.ParseMessage(strings.NewReader(s)); return err },
+			errorstr: "Unexpected content after the message declaration on line: 2",
+		},
+		{
+			fragment: "\n\nenum Color {\n  RED = zero;\n}",
+			parse:    func(s string) error { _, err := pbparser.ParseEnum(strings.NewReader(s)); return err },
+			errorstr: "on line: 4",
+		},
+		{
+			fragment: "service S {\n  rpc Find (A) returns B;\n}",
+			parse:    func(s string) error { _, err := pbparser.ParseService(strings.NewReader(s)); return err },
+			errorstr: "on line: 2",
+		},
+	}
+	for _, tt := range errTests {
+		err := tt.parse(tt.fragment)
+		if err == nil || !strings.Contains(err.Error(), tt.errorstr) {
+			t.Errorf("Fragment: %q, Expected error containing %q, Actual: %v", tt.fragment, tt.errorstr, err)
+		}
+	}
+}
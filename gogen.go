@@ -0,0 +1,271 @@
+package pbparser
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"strings"
+)
+
+// GoGenOptions holds the knobs which can be tweaked while generating Go types
+// via the GenerateGoTypes() api.
+type GoGenOptions struct {
+	// PackageName is the name of the Go package of the generated source. If not
+	// specified, it is derived from the go_package option of the proto file and
+	// failing that, from the package of the proto file.
+	PackageName string
+}
+
+// GenerateGoTypes generates gofmt-ed Go source with plain Go types mirroring the
+// messages and enums in the given proto file. Messages become structs (nested
+// messages become structs named after their parents e.g. Outer_Inner), enums
+// become int32 based types with a const per enum constant, repeated fields become
+// slices and map fields become maps. Fields within oneofs become regular fields of
+// the enclosing struct. Fields referring to types which are not defined in the
+// given proto file (e.g. imported ones) are typed as interface{}.
+//
+// This is not a replacement for protoc-gen-go; the generated types carry no wire
+// encoding whatsoever and are only meant to serve as typed stubs.
+func GenerateGoTypes(pf *ProtoFile, opts GoGenOptions) ([]byte, error) {
+	g := goGenerator{
+		types: make(map[string]goType),
+		used:  make(map[string]bool),
+	}
+	g.registerEnums(pf.Enums, "")
+	g.registerMessages(pf.Messages, "")
+
+	pkg := opts.PackageName
+	if pkg == "" {
+		pkg = goPackageName(pf)
+	}
+	if !isGoIdentifier(pkg) || token.Lookup(pkg).IsKeyword() {
+		return nil, fmt.Errorf("'%v' is not a valid Go package name", pkg)
+	}
+
+	fmt.Fprintf(&g.buf, "// Code generated by pbparser. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&g.buf, "package %v\n", pkg)
+	g.writeEnums(pf.Enums, "")
+	g.writeMessages(pf.Messages)
+
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("Unable to format generated Go source. Reason:: %v", err)
+	}
+	return src, nil
+}
+
+// goType holds the Go name of a message or enum defined in the proto file.
+type goType struct {
+	name   string
+	isEnum bool
+}
+
+type goGenerator struct {
+	buf   bytes.Buffer
+	types map[string]goType
+	used  map[string]bool
+}
+
+// registerMessages assigns Go names to the given messages, their nested messages
+// and enums (howsoever deep), keyed by their qualified names.
+func (g *goGenerator) registerMessages(msgs []MessageElement, parent string) {
+	for _, me := range msgs {
+		name := g.uniqueName(goNestedName(parent, me.Name))
+		g.types[me.QualifiedName] = goType{name: name}
+		g.registerEnums(me.Enums, name)
+		g.registerMessages(me.Messages, name)
+	}
+}
+
+func (g *goGenerator) registerEnums(enums []EnumElement, parent string) {
+	for _, ee := range enums {
+		name := g.uniqueName(goNestedName(parent, ee.Name))
+		g.types[ee.QualifiedName] = goType{name: name, isEnum: true}
+	}
+}
+
+// uniqueName returns the given name, suffixed with underscores if it is already
+// used by some other top-level Go identifier.
+func (g *goGenerator) uniqueName(name string) string {
+	for g.used[name] {
+		name += "_"
+	}
+	g.used[name] = true
+	return name
+}
+
+func (g *goGenerator) writeEnums(enums []EnumElement, parent string) {
+	for _, ee := range enums {
+		name := g.types[ee.QualifiedName].name
+		// like protoc-gen-go, constants of nested enums are prefixed with the
+		// name of the enclosing message rather than the name of the enum...
+		prefix := parent
+		if prefix == "" {
+			prefix = name
+		}
+
+		g.writeDoc(ee.Documentation, "")
+		fmt.Fprintf(&g.buf, "type %v int32\n\n", name)
+		if len(ee.EnumConstants) == 0 {
+			continue
+		}
+		fmt.Fprintf(&g.buf, "const (\n")
+		for _, ec := range ee.EnumConstants {
+			g.writeDoc(ec.Documentation, "\t")
+			fmt.Fprintf(&g.buf, "\t%v %v = %v\n", g.uniqueName(prefix+"_"+ec.Name), name, ec.Tag)
+		}
+		fmt.Fprintf(&g.buf, ")\n\n")
+	}
+}
+
+func (g *goGenerator) writeMessages(msgs []MessageElement) {
+	for _, me := range msgs {
+		name := g.types[me.QualifiedName].name
+
+		g.writeDoc(me.Documentation, "")
+		fmt.Fprintf(&g.buf, "type %v struct {\n", name)
+		fields := make(map[string]bool)
+		for _, f := range me.Fields {
+			g.writeField(me, f, fields, "")
+		}
+		for _, oo := range me.OneOfs {
+			for _, f := range oo.Fields {
+				g.writeField(me, f, fields, oo.Name)
+			}
+		}
+		fmt.Fprintf(&g.buf, "}\n\n")
+
+		g.writeEnums(me.Enums, name)
+		g.writeMessages(me.Messages)
+	}
+}
+
+func (g *goGenerator) writeField(me MessageElement, f FieldElement, used map[string]bool, oneof string) {
+	name := goCamelCase(f.Name)
+	for used[name] {
+		name += "_"
+	}
+	used[name] = true
+
+	typ := g.goFieldType(me, f.Type)
+	if f.IsRepeated() {
+		typ = "[]" + typ
+	}
+
+	g.writeDoc(f.Documentation, "\t")
+	fmt.Fprintf(&g.buf, "\t%v %v", name, typ)
+	if oneof != "" {
+		fmt.Fprintf(&g.buf, " // oneof %v", oneof)
+	}
+	fmt.Fprintf(&g.buf, "\n")
+}
+
+// goFieldType returns the Go type for the given field datatype; resolving any
+// named datatypes relative to the given message.
+func (g *goGenerator) goFieldType(me MessageElement, dt DataType) string {
+	switch t := dt.(type) {
+	case ScalarDataType:
+		return t.GoType()
+	case MapDataType:
+		return "map[" + g.goFieldType(me, t.keyType) + "]" + g.goFieldType(me, t.valueType)
+	}
+	qname, found := resolveTypeName(me.QualifiedName, dt.Name(), func(n string) bool {
+		_, found := g.types[n]
+		return found
+	})
+	if !found {
+		return "interface{}"
+	}
+	gt := g.types[qname]
+	if gt.isEnum {
+		return gt.name
+	}
+	return "*" + gt.name
+}
+
+func (g *goGenerator) writeDoc(doc Documentation, indent string) {
+	for _, line := range docLines(doc.Leading) {
+		fmt.Fprintf(&g.buf, "%v// %v\n", indent, line)
+	}
+}
+
+// goPackageName derives the name of the Go package from the go_package option
+// of the proto file or else, from the package of the proto file.
+func goPackageName(pf *ProtoFile) string {
+	name := strings.Replace(pf.PackageName, ".", "_", -1)
+	for _, op := range pf.Options {
+		if op.Name == "go_package" && !op.IsParenthesized {
+			name = op.Value
+			if i := strings.LastIndex(name, ";"); i >= 0 {
+				name = name[i+1:]
+			} else if i := strings.LastIndex(name, "/"); i >= 0 {
+				name = name[i+1:]
+			}
+		}
+	}
+
+	var b []byte
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if !isLetter(rune(c)) && !isDigit(rune(c)) {
+			c = '_'
+		}
+		b = append(b, c)
+	}
+	name = string(b)
+	if name == "" {
+		return "pb"
+	}
+	if isDigit(rune(name[0])) {
+		name = "_" + name
+	}
+	if token.Lookup(name).IsKeyword() {
+		name += "_"
+	}
+	return name
+}
+
+// goNestedName returns the Go name for a type nested within the given parent.
+func goNestedName(parent string, name string) string {
+	if parent == "" {
+		return goCamelCase(name)
+	}
+	return parent + "_" + goCamelCase(name)
+}
+
+// goCamelCase converts the given proto name to an exported Go name the same way
+// protoc-gen-go does e.g. foo_bar becomes FooBar & _foo becomes XFoo.
+func goCamelCase(s string) string {
+	var b []byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '_' && i == 0:
+			b = append(b, 'X')
+		case c == '_' && i+1 < len(s) && isLower(s[i+1]):
+			// skip the underscore; the next letter is capitalized...
+		case isLower(c) && (i == 0 || s[i-1] == '_'):
+			b = append(b, c-('a'-'A'))
+		default:
+			b = append(b, c)
+		}
+	}
+	return string(b)
+}
+
+func isGoIdentifier(s string) bool {
+	if s == "" || isDigit(rune(s[0])) {
+		return false
+	}
+	for _, c := range s {
+		if !isLetter(c) && !isDigit(c) && c != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+func isLower(c byte) bool {
+	return c >= 'a' && c <= 'z'
+}
@@ -0,0 +1,115 @@
+package pbparser_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tallstoat/pbparser"
+)
+
+const goGenProto = `
+syntax = "proto3";
+package go.gen;
+
+option go_package = "example.com/stubs;stubs";
+
+message Outer_Inner {
+  string type = 1;
+}
+
+message Outer {
+  message Inner {
+    string func = 1;
+    string _hidden = 2;
+  }
+  Inner inner = 1;
+  Outer_Inner flat = 2;
+  repeated Kind kinds = 4;
+  map<int64, Inner> byid = 5;
+  bytes payload = 6;
+  string foo_bar = 7;
+  string fooBar = 8;
+}
+
+enum Kind {
+  KIND_UNKNOWN = 0;
+}
+`
+
+func TestGenerateGoTypes(t *testing.T) {
+	pf, err := pbparser.Parse(strings.NewReader(goGenProto), nil)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	src, err := pbparser.GenerateGoTypes(&pf, pbparser.GoGenOptions{})
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+
+	var expected = []string{
+		"package stubs\n",
+		"type Outer_Inner struct {\n\tType string\n}\n",
+		"type Outer_Inner_ struct {\n\tFunc    string\n\tXHidden string\n}\n",
+		"\tInner   *Outer_Inner_\n",
+		"\tFlat    *Outer_Inner\n",
+		"\tKinds   []Kind\n",
+		"\tByid    map[int64]*Outer_Inner_\n",
+		"\tPayload []byte\n",
+		"\tFooBar  string\n\tFooBar_ string\n",
+		"\tKind_KIND_UNKNOWN Kind = 0\n",
+	}
+	for _, s := range expected {
+		if !strings.Contains(string(src), s) {
+			t.Errorf("Expected generated source to contain %q, Actual:\n%v", s, string(src))
+		}
+	}
+
+	// make sure that the generated source (for a couple of fixtures too) compiles...
+	sources := map[string][]byte{"gen.go": src}
+	for _, file := range []string{"service.proto", "enum.proto"} {
+		pf, err := pbparser.ParseFile("./resources/" + file)
+		if err != nil {
+			t.Fatalf("%v", err.Error())
+		}
+		src, err := pbparser.GenerateGoTypes(&pf, pbparser.GoGenOptions{PackageName: "stubs"})
+		if err != nil {
+			t.Fatalf("%v", err.Error())
+		}
+		sources[strings.TrimSuffix(file, ".proto")+".go"] = src
+	}
+	for name, src := range sources {
+		compileGoSource(t, name, src)
+	}
+}
+
+func compileGoSource(t *testing.T, name string, src []byte) {
+	gobin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not available; skipping compilation of generated source")
+	}
+	dir, err := ioutil.TempDir("", "pbparser-gogen")
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string][]byte{
+		"go.mod": []byte("module example.com/stubs\n\ngo 1.12\n"),
+		name:     src,
+	}
+	for f, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, f), content, 0644); err != nil {
+			t.Fatalf("%v", err.Error())
+		}
+	}
+
+	cmd := exec.Command(gobin, "build", "./...")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("Generated source %v does not compile: %v\n%s\n%s", name, err, out, src)
+	}
+}
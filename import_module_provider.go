@@ -1,10 +1,15 @@
 package pbparser
 
 import (
+	"bytes"
+	"context"
+	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 // ImportModuleProvider is the interface which given a protobuf import module returns a reader for it.
@@ -37,6 +42,72 @@ func (pi *defaultImportModuleProviderImpl) Provide(module string) (io.Reader, er
 		return nil, err
 	}
 
-	r := strings.NewReader(string(raw[:]))
-	return r, nil
+	return bytes.NewReader(raw), nil
+}
+
+// HTTPImportModuleProvider is an implementation of the ImportModuleProvider interface
+// which fetches the import modules over HTTP(S). A module is fetched by a GET request
+// to the URL formed by joining the BaseURL and the module string.
+//
+// The body of the response is read in full (and closed) before returning, so the
+// returned reader needs no closing.
+type HTTPImportModuleProvider struct {
+	// BaseURL is the URL relative to which the import modules are fetched.
+	BaseURL string
+	// Client is the http.Client used for the requests; http.DefaultClient if nil.
+	Client *http.Client
+	// Header holds the headers (e.g. for authorization) to be sent with every request.
+	Header http.Header
+	// Timeout is the time limit for fetching a module; no limit if zero.
+	Timeout time.Duration
+	// MaxSize is the maximum size (in bytes) of a module; no limit if zero.
+	MaxSize int64
+}
+
+// Provide fetches the given module. An Error naming the URL is returned if the
+// request fails, if the response status is not 200 or if the module exceeds the
+// MaxSize.
+func (pi *HTTPImportModuleProvider) Provide(module string) (io.Reader, error) {
+	url := strings.TrimSuffix(pi.BaseURL, "/") + "/" + strings.TrimPrefix(module, "/")
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to fetch %v. Reason:: %v", url, err.Error())
+	}
+	for k, v := range pi.Header {
+		req.Header[k] = v
+	}
+	if pi.Timeout > 0 {
+		ctx, cancel := context.WithTimeout(req.Context(), pi.Timeout)
+		defer cancel()
+		req = req.WithContext(ctx)
+	}
+
+	client := pi.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to fetch %v. Reason:: %v", url, err.Error())
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Unable to fetch %v. Reason:: Unexpected status %v", url, resp.Status)
+	}
+
+	// read one byte more than the limit to be able to tell if it was exceeded...
+	var body io.Reader = resp.Body
+	if pi.MaxSize > 0 {
+		body = io.LimitReader(resp.Body, pi.MaxSize+1)
+	}
+	raw, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to fetch %v. Reason:: %v", url, err.Error())
+	}
+	if pi.MaxSize > 0 && int64(len(raw)) > pi.MaxSize {
+		return nil, fmt.Errorf("Unable to fetch %v. Reason:: Module exceeds the size limit of %v bytes", url, pi.MaxSize)
+	}
+	return bytes.NewReader(raw), nil
 }
@@ -0,0 +1,89 @@
+package pbparser_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tallstoat/pbparser"
+)
+
+func TestHTTPImportModuleProvider(t *testing.T) {
+	modules := map[string]string{
+		"/protos/common/types.proto": `
+syntax = "proto3";
+package common;
+
+message Money {
+  string currency = 1;
+  int64 units = 2;
+}
+`,
+		"/protos/big.proto": strings.Repeat("// padding\n", 100),
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/protos/slow.proto" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		content, found := modules[r.URL.Path]
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(content))
+	}))
+	defer srv.Close()
+
+	p := &pbparser.HTTPImportModuleProvider{
+		BaseURL: srv.URL + "/protos/",
+		Client:  srv.Client(),
+		Header:  http.Header{"Authorization": []string{"Bearer secret"}},
+		Timeout: 100 * time.Millisecond,
+		MaxSize: 512,
+	}
+
+	entry := `
+syntax = "proto3";
+package shop;
+
+import "common/types.proto";
+
+message Price {
+  common.Money amount = 1;
+}
+`
+	pf, err := pbparser.Parse(strings.NewReader(entry), p)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	if pf.Messages[0].Fields[0].Type.Name() != "common.Money" {
+		t.Errorf("Expected the imported type to be resolved, Actual: %v", pf.Messages[0].Fields[0].Type.Name())
+	}
+
+	var tests = []struct {
+		module   string
+		provider *pbparser.HTTPImportModuleProvider
+		errorstr string
+	}{
+		{module: "missing.proto", provider: p, errorstr: srv.URL + "/protos/missing.proto. Reason:: Unexpected status 404"},
+		{module: "big.proto", provider: p, errorstr: "exceeds the size limit of 512 bytes"},
+		{module: "slow.proto", provider: p, errorstr: srv.URL + "/protos/slow.proto"},
+		{
+			module:   "common/types.proto",
+			provider: &pbparser.HTTPImportModuleProvider{BaseURL: srv.URL + "/protos", Client: srv.Client()},
+			errorstr: "Unexpected status 401",
+		},
+	}
+	for _, tt := range tests {
+		_, err := tt.provider.Provide(tt.module)
+		if err == nil || !strings.Contains(err.Error(), tt.errorstr) {
+			t.Errorf("Module: %v, Expected error containing %q, Actual: %v", tt.module, tt.errorstr, err)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package pbparser
+
+import (
+	"sort"
+	"strings"
+)
+
+// PruneImports removes the imports of the proto file which are not required and
+// returns the ones removed. An import is required if it provides any of the types
+// referenced by the fields, rpcs & extend declarations of the proto file or if its
+// package defines any of the custom options used; the same analysis the verifier
+// uses to reject unused imports. Public imports are never removed since they are
+// re-exported to the importers of the proto file.
+//
+// What each import provides is known for a proto file produced by the Parse() or
+// the ParseFile() api. For the rest of the imports, the given ImportModuleProvider
+// is used to parse them; if it is nil, those imports are retained. An Error is
+// returned if an import cannot be parsed.
+func (pf *ProtoFile) PruneImports(p ImportModuleProvider) ([]string, error) {
+	// the package of each import & the import providing each type...
+	pkgs := make(map[string]string)
+	types := make(map[string]string)
+	for d, pkg := range pf.importedPackages {
+		pkgs[d] = pkg
+	}
+	for k, d := range pf.importedTypes {
+		types[k] = d
+	}
+	for _, d := range pf.Dependencies {
+		if _, found := pkgs[d]; found || p == nil {
+			continue
+		}
+		dpf, err := provideDependency(p, d, parseOptions{})
+		if err != nil {
+			return nil, err
+		}
+		pkgs[d] = dpf.PackageName
+		for k := range typeNames(&dpf) {
+			if _, found := types[k]; !found {
+				types[k] = d
+			}
+		}
+	}
+
+	var deps []string
+	for _, pkg := range pkgs {
+		if pkg != "" && pkg != pf.PackageName {
+			deps = append(deps, pkg)
+		}
+	}
+	used := usedPackages(pf, newPackageSet(pf.PackageName, deps))
+
+	// note the imports providing the types referenced...
+	names := typeNames(pf)
+	defined := func(qname string) bool {
+		_, found := types[qname]
+		return names.has(qname) || found
+	}
+	required := make(map[string]bool)
+	walkFileRefs(pf, func(ref typeRef) {
+		if qname, found := resolveTypeName(ref.scope, ref.name, defined); found {
+			required[types[qname]] = true
+		}
+	})
+
+	var kept, removed []string
+	for _, d := range pf.Dependencies {
+		pkg, known := pkgs[d]
+		if !known || required[d] || (pkg != "" && pkg != pf.PackageName && used[pkg]) {
+			kept = append(kept, d)
+		} else {
+			removed = append(removed, d)
+		}
+	}
+	pf.Dependencies = kept
+	return removed, nil
+}
+
+// MissingImports returns the packages which the proto file refers to (by the types
+// it references or the custom options it uses) but which none of its imports
+// provides. The packages are told apart from the names of the types by the naming
+// conventions i.e. the leading lower case components of a name make up the package.
+// This relies on the proto file having been produced by the Parse() or the ParseFile()
+// api for the types and packages provided by the imports to be known.
+func (pf *ProtoFile) MissingImports() []string {
+	var deps []string
+	for _, pkg := range pf.importedPackages {
+		if pkg != "" && pkg != pf.PackageName {
+			deps = append(deps, pkg)
+		}
+	}
+	packages := newPackageSet(pf.PackageName, deps)
+
+	missing := make(map[string]bool)
+	note := func(pkg string) {
+		if pkg == "" || packages.set[pkg] {
+			return
+		}
+		// (a part of) the package of the proto file itself is not missing either...
+		if strings.Contains("."+pf.PackageName+".", "."+pkg+".") {
+			return
+		}
+		missing[pkg] = true
+	}
+
+	defined := pf.definesType()
+	walkFileRefs(pf, func(ref typeRef) {
+		if _, found := resolveTypeName(ref.scope, ref.name, defined); !found {
+			note(packageOfName(strings.TrimPrefix(ref.name, ".")))
+		}
+	})
+	_ = walkOptions(pf, func(kind string, name string, options []OptionElement) error {
+		for _, op := range options {
+			if op.IsParenthesized {
+				note(packageOfName(op.Name))
+			}
+		}
+		return nil
+	})
+
+	var l []string
+	for pkg := range missing {
+		l = append(l, pkg)
+	}
+	sort.Strings(l)
+	return l
+}
+
+// packageOfName returns the package qualifying the given type name as per the naming
+// conventions i.e. the leading components of the name which start in lower case.
+func packageOfName(name string) string {
+	parts := strings.Split(name, ".")
+	n := 0
+	for n < len(parts)-1 && parts[n] != "" && isLower(parts[n][0]) {
+		n++
+	}
+	return strings.Join(parts[:n], ".")
+}
@@ -0,0 +1,142 @@
+package pbparser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tallstoat/pbparser"
+)
+
+var importsProvider = mapImportModuleProvider{
+	"money.proto":  "syntax = \"proto3\";\npackage money;\nmessage Amount {\n  int64 cents = 1;\n}\n",
+	"audit.proto":  "syntax = \"proto3\";\npackage audit;\nmessage Trail {\n  string by = 1;\n}\n",
+	"common.proto": "syntax = \"proto3\";\nmessage Tag {\n  string name = 1;\n}\n",
+	"extras.proto": "syntax = \"proto3\";\npackage shop;\nmessage Extra {\n  string name = 1;\n}\n",
+	"tags.proto":   "syntax = \"proto3\";\npackage tags;\nmessage Stub {\n  string id = 1;\n}\n",
+	"public.proto": "syntax = \"proto3\";\npackage pub;\nmessage Stub {\n  string id = 1;\n}\n",
+}
+
+const importsProto = `syntax = "proto3";
+package shop;
+
+import "money.proto";
+import "audit.proto";
+import "common.proto";
+import "extras.proto";
+import "tags.proto";
+import public "public.proto";
+
+message Item {
+  string id = 1 [(tags.label) = "id"];
+  money.Amount price = 2;
+  map<string, audit.Trail> trails = 3;
+  Tag tag = 4;
+  Extra extra = 5;
+  pub.Stub stub = 6;
+}
+`
+
+func TestPruneImports(t *testing.T) {
+	pf, err := pbparser.Parse(strings.NewReader(importsProto), importsProvider)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+
+	// everything is in use to begin with...
+	removed, err := pf.PruneImports(nil)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	if len(removed) != 0 {
+		t.Errorf("Expected no imports to be removed, Actual: %v", removed)
+	}
+
+	for _, name := range []string{"price", "tag", "extra", "stub"} {
+		if err = pf.Messages[0].RemoveField(name); err != nil {
+			t.Fatalf("%v", err.Error())
+		}
+	}
+	pbparser.FilterOptions(&pf, func(scope pbparser.OptionScope, o pbparser.OptionElement) bool { return false })
+
+	removed, err = pf.PruneImports(nil)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	expected := []string{"money.proto", "common.proto", "extras.proto"}
+	if !reflect.DeepEqual(expected, removed) {
+		t.Errorf("Expected removed imports: %v, Actual: %v", expected, removed)
+	}
+	// FilterOptions has already dropped the import of tags.proto...
+	if !reflect.DeepEqual([]string{"audit.proto"}, pf.Dependencies) {
+		t.Errorf("Expected imports: [audit.proto], Actual: %v", pf.Dependencies)
+	}
+	if !reflect.DeepEqual([]string{"public.proto"}, pf.PublicDependencies) {
+		t.Errorf("Expected public imports: [public.proto], Actual: %v", pf.PublicDependencies)
+	}
+}
+
+func TestPruneImportsWithProvider(t *testing.T) {
+	// a model which is not produced by the parser knows nothing of its imports...
+	pf := pbparser.ProtoFile{
+		PackageName:  "shop",
+		Syntax:       pbparser.SyntaxProto3,
+		Dependencies: []string{"money.proto", "audit.proto"},
+	}
+	amount, _ := pbparser.NewNamedDataType("money.Amount")
+	if err := pf.AddMessage(pbparser.MessageElement{Name: "Item"}); err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	if err := pf.Messages[0].AddField(pbparser.FieldElement{Name: "price", Type: amount, Tag: 1}); err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+
+	removed, err := pf.PruneImports(nil)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	if len(removed) != 0 {
+		t.Errorf("Expected no imports to be removed without a provider, Actual: %v", removed)
+	}
+
+	removed, err = pf.PruneImports(importsProvider)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	if !reflect.DeepEqual([]string{"audit.proto"}, removed) || !reflect.DeepEqual([]string{"money.proto"}, pf.Dependencies) {
+		t.Errorf("Expected audit.proto to be removed, Actual: removed %v, retained %v", removed, pf.Dependencies)
+	}
+
+	pf.Dependencies = append(pf.Dependencies, "missing.proto")
+	if _, err = pf.PruneImports(importsProvider); err == nil {
+		t.Errorf("Expected an error for the import which cannot be provided")
+	}
+}
+
+func TestMissingImports(t *testing.T) {
+	pf, err := pbparser.Parse(strings.NewReader(importsProto), importsProvider)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	if missing := pf.MissingImports(); len(missing) != 0 {
+		t.Errorf("Expected no missing imports, Actual: %v", missing)
+	}
+
+	ts, _ := pbparser.NewNamedDataType("google.protobuf.Timestamp")
+	nested, _ := pbparser.NewNamedDataType("Item.Missing")
+	item := &pf.Messages[0]
+	fields := []pbparser.FieldElement{
+		{Name: "created", Type: ts, Tag: 10, Options: []pbparser.OptionElement{{Name: "acme.audit.level", Value: "1", IsParenthesized: true}}},
+		{Name: "nested", Type: nested, Tag: 11, Options: []pbparser.OptionElement{{Name: "shop.internal", Value: "true", IsParenthesized: true}}},
+	}
+	for _, f := range fields {
+		if err = item.AddField(f); err != nil {
+			t.Fatalf("%v", err.Error())
+		}
+	}
+
+	expected := []string{"acme.audit", "google.protobuf"}
+	if missing := pf.MissingImports(); !reflect.DeepEqual(expected, missing) {
+		t.Errorf("Expected missing imports: %v, Actual: %v", expected, missing)
+	}
+}
@@ -0,0 +1,107 @@
+package pbparser
+
+import "sync"
+
+// Interner is a table of strings which lets the models produced by a batch of
+// Parse() or ParseFile() calls share the storage of identical strings i.e. the
+// names of the elements & their types, the labels, the names & values of the
+// options, the imports and the qualified names; as well as the datatypes of the
+// fields. It is meant for the applications which hold on to the models of a large
+// number of files. An Interner is safe for concurrent use; so the same one can be
+// shared by parses running in parallel.
+type Interner struct {
+	mu      sync.RWMutex
+	strings map[string]string
+	types   map[string]DataType
+}
+
+// NewInterner returns a new (empty) Interner.
+func NewInterner() *Interner {
+	return &Interner{strings: make(map[string]string), types: make(map[string]DataType)}
+}
+
+// Intern returns the string held in the table which is equal to the given string;
+// adding the given string to the table if there is none.
+func (in *Interner) Intern(s string) string {
+	in.mu.RLock()
+	t, found := in.strings[s]
+	in.mu.RUnlock()
+	if found {
+		return t
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if t, found := in.strings[s]; found {
+		return t
+	}
+	in.strings[s] = s
+	return s
+}
+
+// internBytes is the same as Intern() except that the string is only allocated
+// if it is not in the table already.
+func (in *Interner) internBytes(b []byte) string {
+	in.mu.RLock()
+	t, found := in.strings[string(b)]
+	in.mu.RUnlock()
+	if found {
+		return t
+	}
+	return in.Intern(string(b))
+}
+
+// internType returns the datatype held in the table which has the same name as the
+// given one; adding the given datatype to the table if there is none. The datatypes
+// being immutable values, the fields of the same type can share one (boxed) value.
+func (in *Interner) internType(dt DataType) DataType {
+	name := dt.Name()
+	in.mu.RLock()
+	t, found := in.types[name]
+	in.mu.RUnlock()
+	if found {
+		return t
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if t, found := in.types[name]; found {
+		return t
+	}
+	in.types[name] = dt
+	return dt
+}
+
+// Len returns the number of strings held in the table.
+func (in *Interner) Len() int {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	return len(in.strings)
+}
+
+// intern returns the interned copy of the given string if interning is enabled;
+// else the string itself.
+func (p *parser) intern(s string) string {
+	if p.opts.interner == nil {
+		return s
+	}
+	return p.opts.interner.Intern(s)
+}
+
+// internType returns the interned copy of the given datatype if interning is
+// enabled; else the datatype itself.
+func (p *parser) internType(dt DataType) DataType {
+	if p.opts.interner == nil {
+		return dt
+	}
+	return p.opts.interner.internType(dt)
+}
+
+// internBytes returns the interned copy of the given bytes if interning is enabled;
+// else a string of the bytes.
+func (p *parser) internBytes(b []byte) string {
+	if p.opts.interner == nil {
+		return string(b)
+	}
+	return p.opts.interner.internBytes(b)
+}
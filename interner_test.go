@@ -0,0 +1,148 @@
+package pbparser_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"runtime"
+	"sync"
+	"testing"
+	"unsafe"
+
+	"github.com/tallstoat/pbparser"
+)
+
+// corpus returns the contents of the files which make up the corpus parsed by the
+// interning tests & benchmark.
+func corpus(tb testing.TB) [][]byte {
+	var l [][]byte
+	for _, file := range []string{"./resources/descriptor.proto", "./resources/enum.proto", "./resources/integer-message.proto"} {
+		raw, err := ioutil.ReadFile(file)
+		if err != nil {
+			tb.Fatalf("%v", err.Error())
+		}
+		l = append(l, raw)
+	}
+
+	// along with a generated one which is typical of the schemas of services...
+	var buf bytes.Buffer
+	buf.WriteString("syntax = \"proto3\";\npackage acme.inventory.v1;\n")
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&buf, "message Item%v {\n", i)
+		fmt.Fprintf(&buf, "  string id = 1 [deprecated = true];\n  string name = 2;\n  repeated string tags = 3;\n")
+		fmt.Fprintf(&buf, "  int64 created_at = 4;\n  int64 updated_at = 5;\n  map<string, string> labels = 6;\n}\n")
+	}
+	return append(l, buf.Bytes())
+}
+
+func stringData(s string) uintptr {
+	return (*reflect.StringHeader)(unsafe.Pointer(&s)).Data
+}
+
+func TestParseWithInterning(t *testing.T) {
+	in := pbparser.NewInterner()
+	for _, raw := range corpus(t) {
+		expected, err := pbparser.Parse(bytes.NewReader(raw), nil)
+		if err != nil {
+			t.Fatalf("%v", err.Error())
+		}
+		actual, err := pbparser.Parse(bytes.NewReader(raw), nil, pbparser.WithInterning(in))
+		if err != nil {
+			t.Fatalf("%v", err.Error())
+		}
+		if !reflect.DeepEqual(expected, actual) {
+			t.Errorf("Expected the model parsed with interning to be the same as the one parsed without")
+		}
+	}
+	if in.Len() == 0 {
+		t.Fatalf("Expected the strings of the models to be interned")
+	}
+
+	// the strings of models parsed with the same table must share their storage...
+	raw := corpus(t)[0]
+	pf1, err := pbparser.Parse(bytes.NewReader(raw), nil, pbparser.WithInterning(in))
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	pf2, err := pbparser.Parse(bytes.NewReader(raw), nil, pbparser.WithInterning(in))
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	m1, m2 := pf1.Messages[0], pf2.Messages[0]
+	var tests = []struct {
+		s1 string
+		s2 string
+	}{
+		{s1: pf1.PackageName, s2: pf2.PackageName},
+		{s1: m1.QualifiedName, s2: m2.QualifiedName},
+		{s1: m1.Fields[0].Name, s2: m2.Fields[0].Name},
+		{s1: m1.Fields[0].Label, s2: m2.Fields[0].Label},
+		{s1: m1.Fields[0].Type.Name(), s2: m2.Fields[0].Type.Name()},
+		{s1: pf1.Options[0].Name, s2: pf2.Options[0].Name},
+		{s1: pf1.Options[0].Value, s2: pf2.Options[0].Value},
+	}
+	for _, tt := range tests {
+		if stringData(tt.s1) != stringData(tt.s2) {
+			t.Errorf("Expected the storage of '%v' to be shared", tt.s1)
+		}
+	}
+}
+
+func TestParseWithInterningConcurrently(t *testing.T) {
+	in := pbparser.NewInterner()
+	files := corpus(t)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(raw []byte) {
+			defer wg.Done()
+			if _, err := pbparser.Parse(bytes.NewReader(raw), nil, pbparser.WithInterning(in)); err != nil {
+				t.Errorf("%v", err.Error())
+			}
+		}(files[i%len(files)])
+	}
+	wg.Wait()
+}
+
+// BenchmarkParseCorpusInterning benchmarks the memory held by the models of a corpus
+// of files parsed with & without interning; the retained-B/op metric reporting the
+// heap in use after the models have been parsed.
+func BenchmarkParseCorpusInterning(b *testing.B) {
+	files := corpus(b)
+	for _, interning := range []bool{false, true} {
+		name := "plain"
+		if interning {
+			name = "interned"
+		}
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			var retained uint64
+			for i := 0; i < b.N; i++ {
+				var opts []pbparser.ParseOption
+				if interning {
+					opts = append(opts, pbparser.WithInterning(pbparser.NewInterner()))
+				}
+				var before, after runtime.MemStats
+				runtime.GC()
+				runtime.ReadMemStats(&before)
+				models := make([]pbparser.ProtoFile, 0, 100*len(files))
+				for j := 0; j < 100; j++ {
+					for _, raw := range files {
+						pf, err := pbparser.Parse(bytes.NewReader(raw), nil, opts...)
+						if err != nil {
+							b.Fatalf("%v", err.Error())
+						}
+						models = append(models, pf)
+					}
+				}
+				opts = nil
+				runtime.GC()
+				runtime.ReadMemStats(&after)
+				retained += after.HeapAlloc - before.HeapAlloc
+				runtime.KeepAlive(models)
+			}
+			b.ReportMetric(float64(retained)/float64(b.N), "retained-B/op")
+		})
+	}
+}
@@ -0,0 +1,325 @@
+package pbparser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// LintFinding is a datastructure which describes a violation of a lint rule.
+// The rules (along with their IDs & messages) mirror the ones of the buf tool
+// so that either of them can be used to enforce the same policy.
+type LintFinding struct {
+	// RuleID is the ID of the violated rule e.g. FIELD_LOWER_SNAKE_CASE.
+	RuleID string
+	// Element is the qualified name of the element violating the rule.
+	Element string
+	// Message describes the violation.
+	Message string
+}
+
+// LintOptions holds the knobs which can be tweaked while linting a proto file
+// via the Lint() api.
+type LintOptions struct {
+	// Enable lists the IDs of the rules to be applied; all the rules listed by
+	// LintRules() are applied if empty.
+	Enable []string
+	// Disable lists the IDs of the rules not to be applied.
+	Disable []string
+}
+
+type lintRule struct {
+	id    string
+	check func(l *linter)
+}
+
+// the rules in the order they are applied in...
+var lintRules = []lintRule{
+	{"PACKAGE_DEFINED", checkPackageDefined},
+	{"PACKAGE_LOWER_SNAKE_CASE", checkPackageLowerSnakeCase},
+	{"PACKAGE_VERSION_SUFFIX", checkPackageVersionSuffix},
+	{"ENUM_PASCAL_CASE", checkEnumPascalCase},
+	{"ENUM_VALUE_UPPER_SNAKE_CASE", checkEnumValueUpperSnakeCase},
+	{"ENUM_VALUE_PREFIX", checkEnumValuePrefix},
+	{"ENUM_ZERO_VALUE_SUFFIX", checkEnumZeroValueSuffix},
+	{"MESSAGE_PASCAL_CASE", checkMessagePascalCase},
+	{"FIELD_LOWER_SNAKE_CASE", checkFieldLowerSnakeCase},
+	{"ONEOF_LOWER_SNAKE_CASE", checkOneofLowerSnakeCase},
+	{"SERVICE_PASCAL_CASE", checkServicePascalCase},
+	{"SERVICE_SUFFIX", checkServiceSuffix},
+	{"RPC_PASCAL_CASE", checkRPCPascalCase},
+	{"RPC_REQUEST_RESPONSE_UNIQUE", checkRPCRequestResponseUnique},
+	{"RPC_REQUEST_STANDARD_NAME", checkRPCRequestStandardName},
+	{"RPC_RESPONSE_STANDARD_NAME", checkRPCResponseStandardName},
+}
+
+// LintRules returns the IDs of all the lint rules; which make up the default rule set.
+func LintRules() []string {
+	var ids []string
+	for _, r := range lintRules {
+		ids = append(ids, r.id)
+	}
+	return ids
+}
+
+// Lint applies the lint rules chosen via the given LintOptions to the given proto
+// file and returns the findings; ordered by rule (as listed by LintRules()) and
+// then by the order of the elements in the proto file.
+//
+// An Error is returned if any of the given rule IDs is unknown.
+func Lint(pf *ProtoFile, opts LintOptions) ([]LintFinding, error) {
+	known := make(map[string]bool)
+	for _, r := range lintRules {
+		known[r.id] = true
+	}
+	enabled := make(map[string]bool)
+	for _, id := range opts.Enable {
+		if !known[id] {
+			return nil, fmt.Errorf("Unknown lint rule %v", id)
+		}
+		enabled[id] = true
+	}
+	if len(opts.Enable) == 0 {
+		enabled = known
+	}
+	for _, id := range opts.Disable {
+		if !known[id] {
+			return nil, fmt.Errorf("Unknown lint rule %v", id)
+		}
+		delete(enabled, id)
+	}
+
+	l := linter{pf: pf}
+	for _, r := range lintRules {
+		if enabled[r.id] {
+			l.rule = r.id
+			r.check(&l)
+		}
+	}
+	return l.findings, nil
+}
+
+type linter struct {
+	pf       *ProtoFile
+	rule     string
+	findings []LintFinding
+}
+
+func (l *linter) report(element string, format string, args ...interface{}) {
+	l.findings = append(l.findings, LintFinding{RuleID: l.rule, Element: element, Message: fmt.Sprintf(format, args...)})
+}
+
+// messages returns all the messages in the proto file (howsoever deep they are nested).
+func (l *linter) messages() []MessageElement {
+	var msgs []MessageElement
+	var add func(nested []MessageElement)
+	add = func(nested []MessageElement) {
+		for _, me := range nested {
+			msgs = append(msgs, me)
+			add(me.Messages)
+		}
+	}
+	add(l.pf.Messages)
+	return msgs
+}
+
+// enums returns all the enums in the proto file (howsoever deep they are nested).
+func (l *linter) enums() []EnumElement {
+	enums := append([]EnumElement{}, l.pf.Enums...)
+	for _, me := range l.messages() {
+		enums = append(enums, me.Enums...)
+	}
+	return enums
+}
+
+var (
+	lowerSnakeCaseRegex    = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+	upperSnakeCaseRegex    = regexp.MustCompile(`^[A-Z][A-Z0-9]*(_[A-Z0-9]+)*$`)
+	packageVersionRegex    = regexp.MustCompile(`^v[1-9][0-9]*(p[1-9][0-9]*)?((alpha|beta)[1-9][0-9]*)?(test[a-z0-9]*)?$`)
+	packageLowerSnakeRegex = regexp.MustCompile(`^[a-z][a-z0-9_]*(\.[a-z][a-z0-9_]*)*$`)
+)
+
+func isPascalCase(s string) bool {
+	return s != "" && s[0] >= 'A' && s[0] <= 'Z' && !strings.Contains(s, "_")
+}
+
+func toLowerSnakeCase(s string) string {
+	return strings.ToLower(toUpperSnakeCase(s))
+}
+
+func checkPackageDefined(l *linter) {
+	if l.pf.PackageName == "" {
+		l.report("", "Files must have a package defined.")
+	}
+}
+
+func checkPackageLowerSnakeCase(l *linter) {
+	if pkg := l.pf.PackageName; pkg != "" && !packageLowerSnakeRegex.MatchString(pkg) {
+		var parts []string
+		for _, part := range strings.Split(pkg, ".") {
+			parts = append(parts, toLowerSnakeCase(part))
+		}
+		l.report(pkg, "Package name %q should be lower_snake.case, such as %q.", pkg, strings.Join(parts, "."))
+	}
+}
+
+func checkPackageVersionSuffix(l *linter) {
+	pkg := l.pf.PackageName
+	if pkg == "" {
+		return
+	}
+	parts := strings.Split(pkg, ".")
+	if len(parts) < 2 || !packageVersionRegex.MatchString(parts[len(parts)-1]) {
+		l.report(pkg, "Package name %q should be suffixed with a correctly formed version, such as %q.", pkg, pkg+".v1")
+	}
+}
+
+func checkEnumPascalCase(l *linter) {
+	for _, ee := range l.enums() {
+		if !isPascalCase(ee.Name) {
+			l.report(ee.QualifiedName, "Enum name %q should be PascalCase, such as %q.", ee.Name, goCamelCase(ee.Name))
+		}
+	}
+}
+
+func checkEnumValueUpperSnakeCase(l *linter) {
+	for _, ee := range l.enums() {
+		for _, ec := range ee.EnumConstants {
+			if !upperSnakeCaseRegex.MatchString(ec.Name) {
+				l.report(ee.QualifiedName+"."+ec.Name, "Enum value name %q should be UPPER_SNAKE_CASE, such as %q.",
+					ec.Name, toUpperSnakeCase(ec.Name))
+			}
+		}
+	}
+}
+
+func checkEnumValuePrefix(l *linter) {
+	for _, ee := range l.enums() {
+		prefix := toUpperSnakeCase(ee.Name) + "_"
+		for _, ec := range ee.EnumConstants {
+			if !strings.HasPrefix(ec.Name, prefix) {
+				l.report(ee.QualifiedName+"."+ec.Name, "Enum value name %q should be prefixed with %q.", ec.Name, prefix)
+			}
+		}
+	}
+}
+
+func checkEnumZeroValueSuffix(l *linter) {
+	for _, ee := range l.enums() {
+		for _, ec := range ee.EnumConstants {
+			if ec.Tag == 0 && !strings.HasSuffix(ec.Name, "_UNSPECIFIED") {
+				l.report(ee.QualifiedName+"."+ec.Name, "Enum zero value name %q should be suffixed with %q.", ec.Name, "_UNSPECIFIED")
+			}
+		}
+	}
+}
+
+func checkMessagePascalCase(l *linter) {
+	for _, me := range l.messages() {
+		if !isPascalCase(me.Name) {
+			l.report(me.QualifiedName, "Message name %q should be PascalCase, such as %q.", me.Name, goCamelCase(me.Name))
+		}
+	}
+}
+
+func checkFieldLowerSnakeCase(l *linter) {
+	for _, me := range l.messages() {
+		for _, f := range me.allFields() {
+			if !lowerSnakeCaseRegex.MatchString(f.Name) {
+				l.report(me.QualifiedName+"."+f.Name, "Field name %q should be lower_snake_case, such as %q.",
+					f.Name, toLowerSnakeCase(f.Name))
+			}
+		}
+	}
+}
+
+func checkOneofLowerSnakeCase(l *linter) {
+	for _, me := range l.messages() {
+		for _, oo := range me.OneOfs {
+			if !lowerSnakeCaseRegex.MatchString(oo.Name) {
+				l.report(me.QualifiedName+"."+oo.Name, "Oneof name %q should be lower_snake_case, such as %q.",
+					oo.Name, toLowerSnakeCase(oo.Name))
+			}
+		}
+	}
+}
+
+func checkServicePascalCase(l *linter) {
+	for _, se := range l.pf.Services {
+		if !isPascalCase(se.Name) {
+			l.report(se.QualifiedName, "Service name %q should be PascalCase, such as %q.", se.Name, goCamelCase(se.Name))
+		}
+	}
+}
+
+func checkServiceSuffix(l *linter) {
+	for _, se := range l.pf.Services {
+		if !strings.HasSuffix(se.Name, "Service") {
+			l.report(se.QualifiedName, "Service name %q should be suffixed with %q.", se.Name, "Service")
+		}
+	}
+}
+
+func checkRPCPascalCase(l *linter) {
+	for _, se := range l.pf.Services {
+		for _, rpc := range se.RPCs {
+			if !isPascalCase(rpc.Name) {
+				l.report(se.QualifiedName+"."+rpc.Name, "RPC name %q should be PascalCase, such as %q.", rpc.Name, goCamelCase(rpc.Name))
+			}
+		}
+	}
+}
+
+func checkRPCRequestResponseUnique(l *linter) {
+	defined := typeNames(l.pf)
+	resolve := func(scope string, name string) string {
+		if qname, found := resolveTypeName(scope, name, defined.has); found {
+			return qname
+		}
+		return strings.TrimPrefix(name, ".")
+	}
+
+	uses := make(map[string]int)
+	var order []string
+	for _, se := range l.pf.Services {
+		for _, rpc := range se.RPCs {
+			req := resolve(se.QualifiedName, rpc.RequestType.Name())
+			resp := resolve(se.QualifiedName, rpc.ResponseType.Name())
+			if req == resp {
+				l.report(se.QualifiedName+"."+rpc.Name, "RPC %q has the same type %q for the request and response.", rpc.Name, req)
+			}
+			for _, t := range []string{req, resp} {
+				if uses[t] == 0 {
+					order = append(order, t)
+				}
+				uses[t]++
+			}
+		}
+	}
+	for _, t := range order {
+		if uses[t] > 1 {
+			l.report(t, "%q is used as the request or response type for multiple RPCs.", t)
+		}
+	}
+}
+
+func checkRPCRequestStandardName(l *linter) {
+	checkRPCStandardName(l, "request", "Request", func(rpc RPCElement) string { return rpc.RequestType.Name() })
+}
+
+func checkRPCResponseStandardName(l *linter) {
+	checkRPCStandardName(l, "response", "Response", func(rpc RPCElement) string { return rpc.ResponseType.Name() })
+}
+
+func checkRPCStandardName(l *linter, kind string, suffix string, typeName func(RPCElement) string) {
+	for _, se := range l.pf.Services {
+		for _, rpc := range se.RPCs {
+			name := typeName(rpc)
+			name = name[strings.LastIndex(name, ".")+1:]
+			if name != rpc.Name+suffix && name != se.Name+rpc.Name+suffix {
+				l.report(se.QualifiedName+"."+rpc.Name, "RPC %v type %q should be named %q or %q.",
+					kind, name, rpc.Name+suffix, se.Name+rpc.Name+suffix)
+			}
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package pbparser_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tallstoat/pbparser"
+)
+
+func TestLint(t *testing.T) {
+	pf, err := pbparser.ParseFile("./resources/lint/bad.proto")
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+
+	// the findings buf reports for the same file...
+	expected := []pbparser.LintFinding{
+		{"PACKAGE_LOWER_SNAKE_CASE", "Acme.shop", `Package name "Acme.shop" should be lower_snake.case, such as "acme.shop".`},
+		{"PACKAGE_VERSION_SUFFIX", "Acme.shop", `Package name "Acme.shop" should be suffixed with a correctly formed version, such as "Acme.shop.v1".`},
+		{"ENUM_PASCAL_CASE", "Acme.shop.order_state", `Enum name "order_state" should be PascalCase, such as "OrderState".`},
+		{"ENUM_VALUE_UPPER_SNAKE_CASE", "Acme.shop.order_state.ORDER_STATE_open", `Enum value name "ORDER_STATE_open" should be UPPER_SNAKE_CASE, such as "ORDER_STATE_OPEN".`},
+		{"ENUM_VALUE_PREFIX", "Acme.shop.order_state.UNKNOWN", `Enum value name "UNKNOWN" should be prefixed with "ORDER_STATE_".`},
+		{"ENUM_ZERO_VALUE_SUFFIX", "Acme.shop.order_state.UNKNOWN", `Enum zero value name "UNKNOWN" should be suffixed with "_UNSPECIFIED".`},
+		{"ENUM_ZERO_VALUE_SUFFIX", "Acme.shop.Order.Status.STATUS_NONE", `Enum zero value name "STATUS_NONE" should be suffixed with "_UNSPECIFIED".`},
+		{"MESSAGE_PASCAL_CASE", "Acme.shop.Order.line_item", `Message name "line_item" should be PascalCase, such as "LineItem".`},
+		{"FIELD_LOWER_SNAKE_CASE", "Acme.shop.Order.orderId", `Field name "orderId" should be lower_snake_case, such as "order_id".`},
+		{"ONEOF_LOWER_SNAKE_CASE", "Acme.shop.Order.Payment", `Oneof name "Payment" should be lower_snake_case, such as "payment".`},
+		{"SERVICE_SUFFIX", "Acme.shop.Orders", `Service name "Orders" should be suffixed with "Service".`},
+		{"RPC_PASCAL_CASE", "Acme.shop.Orders.watch_order", `RPC name "watch_order" should be PascalCase, such as "WatchOrder".`},
+		{"RPC_REQUEST_RESPONSE_UNIQUE", "Acme.shop.Orders.Echo", `RPC "Echo" has the same type "Acme.shop.Order" for the request and response.`},
+		{"RPC_REQUEST_RESPONSE_UNIQUE", "Acme.shop.GetOrderRequest", `"Acme.shop.GetOrderRequest" is used as the request or response type for multiple RPCs.`},
+		{"RPC_REQUEST_RESPONSE_UNIQUE", "Acme.shop.Order", `"Acme.shop.Order" is used as the request or response type for multiple RPCs.`},
+		{"RPC_REQUEST_STANDARD_NAME", "Acme.shop.Orders.watch_order", `RPC request type "GetOrderRequest" should be named "watch_orderRequest" or "Orderswatch_orderRequest".`},
+		{"RPC_REQUEST_STANDARD_NAME", "Acme.shop.Orders.Echo", `RPC request type "Order" should be named "EchoRequest" or "OrdersEchoRequest".`},
+		{"RPC_RESPONSE_STANDARD_NAME", "Acme.shop.Orders.GetOrder", `RPC response type "Order" should be named "GetOrderResponse" or "OrdersGetOrderResponse".`},
+		{"RPC_RESPONSE_STANDARD_NAME", "Acme.shop.Orders.watch_order", `RPC response type "Order" should be named "watch_orderResponse" or "Orderswatch_orderResponse".`},
+		{"RPC_RESPONSE_STANDARD_NAME", "Acme.shop.Orders.Echo", `RPC response type "Order" should be named "EchoResponse" or "OrdersEchoResponse".`},
+	}
+	findings, err := pbparser.Lint(&pf, pbparser.LintOptions{})
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	if len(findings) != len(expected) {
+		t.Fatalf("Expected %v findings, Actual: %v", len(expected), findings)
+	}
+	for i := range expected {
+		if findings[i] != expected[i] {
+			t.Errorf("Expected: %v, Actual: %v", expected[i], findings[i])
+		}
+	}
+
+	// rules can be enabled & disabled individually...
+	var optionTests = []struct {
+		opts     pbparser.LintOptions
+		rules    string
+		errorstr string
+	}{
+		{opts: pbparser.LintOptions{Enable: []string{"SERVICE_SUFFIX", "ENUM_VALUE_PREFIX"}}, rules: "ENUM_VALUE_PREFIX SERVICE_SUFFIX"},
+		{
+			opts:  pbparser.LintOptions{Enable: []string{"SERVICE_SUFFIX", "ENUM_VALUE_PREFIX"}, Disable: []string{"SERVICE_SUFFIX"}},
+			rules: "ENUM_VALUE_PREFIX",
+		},
+		{
+			opts:  pbparser.LintOptions{Disable: []string{"RPC_REQUEST_STANDARD_NAME", "RPC_RESPONSE_STANDARD_NAME", "RPC_REQUEST_RESPONSE_UNIQUE"}},
+			rules: "PACKAGE_LOWER_SNAKE_CASE PACKAGE_VERSION_SUFFIX ENUM_PASCAL_CASE ENUM_VALUE_UPPER_SNAKE_CASE ENUM_VALUE_PREFIX ENUM_ZERO_VALUE_SUFFIX MESSAGE_PASCAL_CASE FIELD_LOWER_SNAKE_CASE ONEOF_LOWER_SNAKE_CASE SERVICE_SUFFIX RPC_PASCAL_CASE",
+		},
+		{opts: pbparser.LintOptions{Disable: []string{"FIELD_CASE"}}, errorstr: "Unknown lint rule FIELD_CASE"},
+	}
+	for _, tt := range optionTests {
+		findings, err := pbparser.Lint(&pf, tt.opts)
+		if tt.errorstr != "" {
+			if err == nil || err.Error() != tt.errorstr {
+				t.Errorf("Options: %v, Expected error: %v, Actual: %v", tt.opts, tt.errorstr, err)
+			}
+			continue
+		}
+		var rules []string
+		for _, f := range findings {
+			if len(rules) == 0 || rules[len(rules)-1] != f.RuleID {
+				rules = append(rules, f.RuleID)
+			}
+		}
+		if actual := strings.Join(rules, " "); actual != tt.rules {
+			t.Errorf("Options: %v, Expected rules: %v, Actual: %v", tt.opts, tt.rules, actual)
+		}
+	}
+
+	// a file following the conventions has no findings...
+	pf, err = pbparser.ParseFile("./resources/lint/good.proto")
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	if findings, _ := pbparser.Lint(&pf, pbparser.LintOptions{}); len(findings) != 0 {
+		t.Errorf("Expected no findings, Actual: %v", findings)
+	}
+}
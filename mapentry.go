@@ -0,0 +1,127 @@
+package pbparser
+
+import (
+	"errors"
+	"fmt"
+)
+
+// SynthesizeMapEntries rewrites every map field of the given proto file (howsoever
+// deeply nested) the way protoc represents it in descriptors i.e. as a repeated field
+// of a nested message which is synthesized for it. The message is named after the
+// field (foo_bar becomes FooBarEntry), carries the fields key = 1 & value = 2 and
+// the option map_entry = true; which is what RestoreMapEntries() relies on to reverse
+// the transformation. An Error is returned if the name of a synthesized message is
+// already used by another nested message or enum.
+func SynthesizeMapEntries(pf *ProtoFile) error {
+	for i := range pf.Messages {
+		if err := synthesizeMapEntries(&pf.Messages[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func synthesizeMapEntries(me *MessageElement) error {
+	for i := range me.Messages {
+		if err := synthesizeMapEntries(&me.Messages[i]); err != nil {
+			return err
+		}
+	}
+	// maps are not allowed within oneofs; so only the fields of the message itself...
+	for i := range me.Fields {
+		f := &me.Fields[i]
+		mdt, ok := f.Type.(MapDataType)
+		if !ok {
+			continue
+		}
+		entry := MessageElement{
+			Name:    mapEntryName(f.Name),
+			Options: []OptionElement{{Name: "map_entry", Value: "true"}},
+			Fields: []FieldElement{
+				{Name: "key", Label: LabelOptional, Type: mdt.KeyType(), Tag: 1, Ordinal: 1},
+				{Name: "value", Label: LabelOptional, Type: mdt.ValueType(), Tag: 2, Ordinal: 2},
+			},
+		}
+		if err := me.AddMessage(entry); err != nil {
+			msg := fmt.Sprintf("Unable to synthesize the entry message of map field '%v' in message %v. Reason:: %v", f.Name, me.QualifiedName, err.Error())
+			return errors.New(msg)
+		}
+		f.Label = LabelRepeated
+		f.Type = NamedDataType{name: entry.Name}
+	}
+	return nil
+}
+
+// RestoreMapEntries reverses SynthesizeMapEntries() on the given proto file i.e. the
+// repeated fields of the nested messages with the option map_entry = true are turned
+// back into map fields and the nested messages are removed. An Error is returned if a
+// map entry message does not have the fields key = 1 & value = 2.
+func RestoreMapEntries(pf *ProtoFile) error {
+	for i := range pf.Messages {
+		if err := restoreMapEntries(&pf.Messages[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func restoreMapEntries(me *MessageElement) error {
+	var nested []MessageElement
+	for _, nm := range me.Messages {
+		if !isMapEntry(nm) {
+			if err := restoreMapEntries(&nm); err != nil {
+				return err
+			}
+			nested = append(nested, nm)
+			continue
+		}
+		if len(nm.Fields) != 2 || nm.Fields[0].Tag != 1 || nm.Fields[1].Tag != 2 {
+			msg := fmt.Sprintf("Map entry message %v must have the fields key = 1 and value = 2", nm.QualifiedName)
+			return errors.New(msg)
+		}
+		mdt, err := NewMapDataType(nm.Fields[0].Type, nm.Fields[1].Type)
+		if err != nil {
+			return err
+		}
+		for i := range me.Fields {
+			f := &me.Fields[i]
+			if f.Label == LabelRepeated && f.Type != nil && f.Type.Category() == NamedDataTypeCategory &&
+				(f.Type.Name() == nm.Name || f.Type.Name() == nm.QualifiedName) {
+				f.Label = LabelNone
+				f.Type = mdt
+			}
+		}
+	}
+	me.Messages = nested
+	return nil
+}
+
+// isMapEntry returns true if the given message has the option map_entry = true.
+func isMapEntry(me MessageElement) bool {
+	for _, op := range me.Options {
+		if op.Name == "map_entry" && !op.IsParenthesized && op.Value == "true" {
+			return true
+		}
+	}
+	return false
+}
+
+// mapEntryName returns the name of the entry message of the given map field the
+// way protoc does i.e. the name is camel cased and suffixed with Entry.
+func mapEntryName(fieldName string) string {
+	var b []byte
+	capitalizeNext := true
+	for i := 0; i < len(fieldName); i++ {
+		c := fieldName[i]
+		if c == '_' {
+			capitalizeNext = true
+			continue
+		}
+		if capitalizeNext && isLower(c) {
+			c -= 'a' - 'A'
+		}
+		capitalizeNext = false
+		b = append(b, c)
+	}
+	return string(b) + "Entry"
+}
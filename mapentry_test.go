@@ -0,0 +1,97 @@
+package pbparser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const mapEntryProto = `
+syntax = "proto3";
+package inventory;
+
+message Store {
+  string id = 1;
+  map<string, Item> items_by_sku = 2;
+  message Shelf {
+    map<int32, string> labels = 1;
+  }
+  repeated Shelf shelves = 3;
+}
+
+message Item {
+  string sku = 1;
+}
+`
+
+func TestSynthesizeMapEntries(t *testing.T) {
+	pf, err := Parse(strings.NewReader(mapEntryProto), nil)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	original, _ := Parse(strings.NewReader(mapEntryProto), nil)
+
+	if err = SynthesizeMapEntries(&pf); err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+
+	store := pf.Messages[0]
+	f := store.Fields[1]
+	if f.Label != LabelRepeated || f.Type.Category() != NamedDataTypeCategory || f.Type.Name() != "ItemsBySkuEntry" {
+		t.Errorf("Expected field items_by_sku to be a repeated ItemsBySkuEntry, Actual: %v %v", f.Label, f.Type.Name())
+	}
+	entry := store.Messages[1]
+	if entry.QualifiedName != "inventory.Store.ItemsBySkuEntry" || !isMapEntry(entry) {
+		t.Errorf("Expected the map entry message inventory.Store.ItemsBySkuEntry, Actual: %v", entry)
+	}
+	if len(entry.Fields) != 2 || entry.Fields[0].Name != "key" || entry.Fields[0].Tag != 1 || entry.Fields[0].Type.Name() != "string" ||
+		entry.Fields[1].Name != "value" || entry.Fields[1].Tag != 2 || entry.Fields[1].Type.Name() != "Item" {
+		t.Errorf("Expected the fields key = 1 and value = 2 in %v, Actual: %v", entry.Name, entry.Fields)
+	}
+	shelf := store.Messages[0]
+	if len(shelf.Messages) != 1 || shelf.Messages[0].QualifiedName != "inventory.Store.Shelf.LabelsEntry" {
+		t.Errorf("Expected the map entry message inventory.Store.Shelf.LabelsEntry, Actual: %v", shelf.Messages)
+	}
+
+	if err = RestoreMapEntries(&pf); err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	if !reflect.DeepEqual(original, pf) {
+		t.Errorf("Expected the restored proto file to be the same as the original one")
+	}
+}
+
+func TestSynthesizeMapEntriesNameClash(t *testing.T) {
+	pf, err := Parse(strings.NewReader(`syntax = "proto3";
+message Store {
+  map<string, string> tags = 1;
+  message TagsEntry {
+    string key = 1;
+  }
+}`), nil)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	err = SynthesizeMapEntries(&pf)
+	if err == nil || !strings.Contains(err.Error(), "Unable to synthesize the entry message of map field 'tags' in message Store") {
+		t.Errorf("Expected an error for the name clash, Actual: %v", err)
+	}
+}
+
+func TestMapEntryName(t *testing.T) {
+	var tests = []struct {
+		field    string
+		expected string
+	}{
+		{field: "tags", expected: "TagsEntry"},
+		{field: "items_by_sku", expected: "ItemsBySkuEntry"},
+		{field: "_internal", expected: "InternalEntry"},
+		{field: "byId", expected: "ByIdEntry"},
+		{field: "field_1", expected: "Field1Entry"},
+	}
+	for _, tt := range tests {
+		if actual := mapEntryName(tt.field); actual != tt.expected {
+			t.Errorf("Expected entry name of %v: %v, Actual: %v", tt.field, tt.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package pbparser
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// MarkdownOptions holds the knobs which can be tweaked while generating
+// documentation via the GenerateMarkdown() api.
+type MarkdownOptions struct {
+	// Title is the title of the document. Defaults to the package of the proto file.
+	Title string
+}
+
+// GenerateMarkdown generates markdown documentation for the given proto file
+// with sections for its messages (a table of fields each), enums (a table of
+// values each) and services (a table of rpcs each). Descriptions come from the
+// leading comments of the elements. Nested messages and enums get headings a
+// level deeper than their parents. References to messages and enums defined in
+// the proto file link to their sections and elements having the deprecated
+// option set are marked as deprecated.
+func GenerateMarkdown(pf *ProtoFile, opts MarkdownOptions) ([]byte, error) {
+	g := markdownGenerator{defined: make(map[string]bool)}
+	g.index(pf.Messages, pf.Enums)
+
+	title := opts.Title
+	if title == "" {
+		title = pf.PackageName
+	}
+	fmt.Fprintf(&g.buf, "# %v\n", title)
+	if pf.PackageName != "" {
+		fmt.Fprintf(&g.buf, "\nPackage: `%v`\n", pf.PackageName)
+	}
+
+	if len(pf.Messages) > 0 {
+		fmt.Fprintf(&g.buf, "\n## Messages\n")
+		g.writeMessages(pf.Messages, 3)
+	}
+	if len(pf.Enums) > 0 {
+		fmt.Fprintf(&g.buf, "\n## Enums\n")
+		g.writeEnums(pf.Enums, 3)
+	}
+	if len(pf.Services) > 0 {
+		fmt.Fprintf(&g.buf, "\n## Services\n")
+		for _, se := range pf.Services {
+			g.writeService(se)
+		}
+	}
+	return g.buf.Bytes(), nil
+}
+
+type markdownGenerator struct {
+	buf     bytes.Buffer
+	defined map[string]bool
+}
+
+// index records the qualified names of the given messages and enums (howsoever deep).
+func (g *markdownGenerator) index(msgs []MessageElement, enums []EnumElement) {
+	for _, ee := range enums {
+		g.defined[ee.QualifiedName] = true
+	}
+	for _, me := range msgs {
+		g.defined[me.QualifiedName] = true
+		g.index(me.Messages, me.Enums)
+	}
+}
+
+func (g *markdownGenerator) writeMessages(msgs []MessageElement, level int) {
+	for _, me := range msgs {
+		g.writeHeading(level, me.QualifiedName, me.Name, me.Documentation, me.Options)
+		fields := me.allFields()
+		if len(fields) > 0 {
+			fmt.Fprintf(&g.buf, "\n| Field | Type | Label | Tag | Description |\n")
+			fmt.Fprintf(&g.buf, "| ----- | ---- | ----- | --- | ----------- |\n")
+			for _, f := range me.Fields {
+				g.writeField(me, f, f.Label)
+			}
+			for _, oo := range me.OneOfs {
+				for _, f := range oo.Fields {
+					g.writeField(me, f, "oneof "+oo.Name)
+				}
+			}
+		}
+		g.writeEnums(me.Enums, level+1)
+		g.writeMessages(me.Messages, level+1)
+	}
+}
+
+func (g *markdownGenerator) writeField(me MessageElement, f FieldElement, label string) {
+	fmt.Fprintf(&g.buf, "| %v | %v | %v | %v | %v |\n",
+		f.Name, g.typeRef(me.QualifiedName, f.Type), label, f.Tag, description(f.Documentation, f.Options))
+}
+
+func (g *markdownGenerator) writeEnums(enums []EnumElement, level int) {
+	for _, ee := range enums {
+		g.writeHeading(level, ee.QualifiedName, ee.Name, ee.Documentation, ee.Options)
+		if len(ee.EnumConstants) == 0 {
+			continue
+		}
+		fmt.Fprintf(&g.buf, "\n| Name | Number | Description |\n")
+		fmt.Fprintf(&g.buf, "| ---- | ------ | ----------- |\n")
+		for _, ec := range ee.EnumConstants {
+			fmt.Fprintf(&g.buf, "| %v | %v | %v |\n", ec.Name, ec.Tag, description(ec.Documentation, ec.Options))
+		}
+	}
+}
+
+func (g *markdownGenerator) writeService(se ServiceElement) {
+	g.writeHeading(3, se.QualifiedName, se.Name, se.Documentation, se.Options)
+	if len(se.RPCs) == 0 {
+		return
+	}
+	fmt.Fprintf(&g.buf, "\n| Method | Request | Response | Description |\n")
+	fmt.Fprintf(&g.buf, "| ------ | ------- | -------- | ----------- |\n")
+	for _, rpc := range se.RPCs {
+		fmt.Fprintf(&g.buf, "| %v | %v | %v | %v |\n", rpc.Name,
+			g.rpcTypeRef(se.QualifiedName, rpc.RequestType), g.rpcTypeRef(se.QualifiedName, rpc.ResponseType),
+			description(rpc.Documentation, rpc.Options))
+	}
+}
+
+// writeHeading writes the heading (along with an anchor for linking to it) and
+// the description of an element.
+func (g *markdownGenerator) writeHeading(level int, anchor string, name string, doc Documentation, options []OptionElement) {
+	if level > 6 {
+		level = 6
+	}
+	fmt.Fprintf(&g.buf, "\n<a name=\"%v\"></a>\n", anchor)
+	fmt.Fprintf(&g.buf, "%v %v\n", strings.Repeat("#", level), name)
+	if isDeprecated(options) {
+		fmt.Fprintf(&g.buf, "\n**Deprecated**\n")
+	}
+	if lines := docLines(doc.Leading); len(lines) > 0 {
+		fmt.Fprintf(&g.buf, "\n%v\n", strings.Join(lines, "\n"))
+	}
+}
+
+func (g *markdownGenerator) rpcTypeRef(scope string, ndt NamedDataType) string {
+	ref := g.typeRef(scope, ndt)
+	if ndt.IsStream() {
+		return "stream " + ref
+	}
+	return ref
+}
+
+// typeRef returns the given datatype; linked to the section of the message or
+// enum it refers to if defined in the proto file.
+func (g *markdownGenerator) typeRef(scope string, dt DataType) string {
+	switch t := dt.(type) {
+	case ScalarDataType:
+		return t.Name()
+	case MapDataType:
+		return "map&lt;" + g.typeRef(scope, t.keyType) + ", " + g.typeRef(scope, t.valueType) + "&gt;"
+	}
+	qname, found := resolveTypeName(scope, dt.Name(), func(n string) bool {
+		return g.defined[n]
+	})
+	if !found {
+		return dt.Name()
+	}
+	return fmt.Sprintf("[%v](#%v)", dt.Name(), qname)
+}
+
+// description returns the leading comment of an element made fit for a table
+// cell; prefixed with a deprecation marker if the element is deprecated.
+func description(doc Documentation, options []OptionElement) string {
+	s := strings.Join(docLines(doc.Leading), "<br>")
+	s = strings.Replace(s, "|", "\\|", -1)
+	if isDeprecated(options) {
+		s = strings.TrimSpace("**Deprecated** " + s)
+	}
+	return s
+}
+
+func isDeprecated(options []OptionElement) bool {
+	for _, op := range options {
+		if op.Name == "deprecated" && !op.IsParenthesized && op.Value == "true" {
+			return true
+		}
+	}
+	return false
+}
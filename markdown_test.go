@@ -0,0 +1,31 @@
+package pbparser_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tallstoat/pbparser"
+	"github.com/tallstoat/pbparser/pbparsertest"
+)
+
+func TestGenerateMarkdown(t *testing.T) {
+	var files = []string{
+		"service.proto",
+		"enum.proto",
+		"comments.proto",
+		"extension-declarations.proto",
+	}
+
+	for _, file := range files {
+		pf, err := pbparser.ParseFile("./resources/" + file)
+		if err != nil {
+			t.Fatalf("%v", err.Error())
+		}
+		out, err := pbparser.GenerateMarkdown(&pf, pbparser.MarkdownOptions{})
+		if err != nil {
+			t.Fatalf("%v", err.Error())
+		}
+
+		pbparsertest.AssertGolden(t, "./resources/markdown/"+strings.TrimSuffix(file, ".proto")+".md", out)
+	}
+}
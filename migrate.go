@@ -0,0 +1,234 @@
+package pbparser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MigrationNote is a datastructure which describes a change made (or not made)
+// while migrating a proto file which needs the attention of the user.
+type MigrationNote struct {
+	// Element is the qualified name of the element the note is about.
+	Element string
+	// Note describes the change and its implications.
+	Note string
+}
+
+// MigrateToProto3 returns a copy of the given proto2 file transformed into a
+// proto3 file along with notes on everything which needs human attention. The
+// given proto file is left untouched.
+//
+// The transformation drops the optional & required labels of the message typed
+// fields (which track presence in proto3 as well), turns those of the other fields
+// into proto3 optional labels (so that their presence is still tracked), drops the
+// default options of fields, drops extension ranges and extend declarations
+// (other than those of custom options) and ensures that the first value of every
+// enum is zero; either by moving the constant with value zero to the front or by
+// inserting a <ENUM>_UNSPECIFIED constant. An Error is returned if the latter is
+// not possible because the name of the constant is already taken. Groups are
+// rewritten as fields of the type of the messages modelling their bodies.
+func MigrateToProto3(pf *ProtoFile) (*ProtoFile, []MigrationNote, error) {
+	if pf.IsProto3() {
+		return nil, nil, errors.New("Proto file is already using the proto3 syntax")
+	}
+
+	m := migrator{defined: pf.definesType(), messages: make(map[string]bool)}
+	var addMessages func(msgs []MessageElement)
+	addMessages = func(msgs []MessageElement) {
+		for _, me := range msgs {
+			m.messages[me.QualifiedName] = true
+			addMessages(me.Messages)
+		}
+	}
+	addMessages(pf.Messages)
+	npf := *pf
+	npf.Syntax = SyntaxProto3
+
+	var err error
+	if npf.Enums, err = m.migrateEnums(pf.Enums); err != nil {
+		return nil, nil, err
+	}
+	if npf.Messages, err = m.migrateMessages(pf.Messages); err != nil {
+		return nil, nil, err
+	}
+	npf.ExtendDeclarations = m.migrateExtends(pf.ExtendDeclarations)
+	return &npf, m.notes, nil
+}
+
+type migrator struct {
+	notes    []MigrationNote
+	defined  func(string) bool // reports whether a message/enum of the given qualified name is known
+	messages map[string]bool   // the qualified names of the messages of the proto file
+}
+
+// isMessageType returns true if the given datatype, referenced within the given
+// scope, is that of a message of the proto file. The types of the imports are not
+// known to be messages.
+func (m *migrator) isMessageType(scope string, dt DataType) bool {
+	if dt.Category() != NamedDataTypeCategory {
+		return false
+	}
+	qname, found := resolveTypeName(scope, dt.Name(), m.defined)
+	return found && m.messages[qname]
+}
+
+func (m *migrator) note(element string, format string, args ...interface{}) {
+	m.notes = append(m.notes, MigrationNote{Element: element, Note: fmt.Sprintf(format, args...)})
+}
+
+func (m *migrator) migrateMessages(msgs []MessageElement) ([]MessageElement, error) {
+	var l []MessageElement
+	for _, me := range msgs {
+		var err error
+		me.Fields = m.migrateFields(me.QualifiedName, me.Fields)
+		oneofs := make([]OneOfElement, len(me.OneOfs))
+		for i, oo := range me.OneOfs {
+			oo.Fields = m.migrateFields(me.QualifiedName, oo.Fields)
+			oneofs[i] = oo
+		}
+		me.OneOfs = oneofs
+		for _, ext := range me.Extensions {
+			m.note(me.QualifiedName, "Extension range %v to %v dropped; extension ranges are not allowed in proto3. Consider using google.protobuf.Any instead", ext.Start, ext.End)
+		}
+		me.Extensions = nil
+		me.ExtendDeclarations = m.migrateExtends(me.ExtendDeclarations)
+		if me.Enums, err = m.migrateEnums(me.Enums); err != nil {
+			return nil, err
+		}
+		if me.Messages, err = m.migrateMessages(me.Messages); err != nil {
+			return nil, err
+		}
+		l = append(l, me)
+	}
+	return l, nil
+}
+
+func (m *migrator) migrateFields(parent string, fields []FieldElement) []FieldElement {
+	var l []FieldElement
+	for _, f := range fields {
+		element := parent + "." + f.Name
+		switch f.Label {
+		case required:
+			if m.isMessageType(parent, f.Type) {
+				m.note(element, "Required label dropped; proto3 does not enforce the presence of fields")
+				f.Label = ""
+			} else {
+				m.note(element, "Required label replaced by a proto3 optional label; the presence of the field is still tracked but no longer enforced")
+				f.Label = optional
+				f.Proto3Optional = true
+			}
+		case optional:
+			if m.isMessageType(parent, f.Type) {
+				f.Label = ""
+			} else {
+				m.note(element, "Optional label kept as a proto3 optional label; the presence of the field is still tracked")
+				f.Proto3Optional = true
+			}
+		}
+
+		if f.Group {
+			m.note(element, "Group rewritten as a field of type %v; the encoding of the field on the wire changes", f.Type.Name())
+			f.Group = false
+		}
+
+		var options []OptionElement
+		for _, op := range f.Options {
+			if op.Name == "default" && !op.IsParenthesized {
+				m.note(element, "Default value '%v' dropped; proto3 fields default to the zero value of their type", op.Value)
+				continue
+			}
+			options = append(options, op)
+		}
+		f.Options = options
+		l = append(l, f)
+	}
+	return l
+}
+
+// migrateExtends drops the extend declarations other than the ones for custom
+// options (which are the only ones allowed in proto3).
+func (m *migrator) migrateExtends(extends []ExtendElement) []ExtendElement {
+	var l []ExtendElement
+	for _, ee := range extends {
+		name := strings.TrimPrefix(ee.Name, ".")
+		if strings.HasPrefix(name, wellKnownTypesPrefix) && strings.HasSuffix(name, "Options") {
+			l = append(l, ee)
+			continue
+		}
+		var names []string
+		for _, f := range ee.Fields {
+			names = append(names, f.Name)
+		}
+		m.note(ee.QualifiedName, "Extend declaration dropped along with its fields: %v; proto3 only allows extending options", strings.Join(names, ", "))
+	}
+	return l
+}
+
+func (m *migrator) migrateEnums(enums []EnumElement) ([]EnumElement, error) {
+	var l []EnumElement
+	for _, ee := range enums {
+		if len(ee.EnumConstants) == 0 || ee.EnumConstants[0].Tag == 0 {
+			l = append(l, ee)
+			continue
+		}
+
+		constants := []EnumConstantElement{}
+		zero := -1
+		for i, ec := range ee.EnumConstants {
+			if ec.Tag == 0 {
+				zero = i
+				break
+			}
+		}
+		if zero >= 0 {
+			constants = append(constants, ee.EnumConstants[zero])
+			constants = append(constants, ee.EnumConstants[:zero]...)
+			constants = append(constants, ee.EnumConstants[zero+1:]...)
+			m.note(ee.QualifiedName, "Enum constant %v moved to the front; the first enum value must be zero in proto3", ee.EnumConstants[zero].Name)
+		} else {
+			name := toUpperSnakeCase(ee.Name) + "_UNSPECIFIED"
+			if isEnumConstantNameTaken(enums, name) {
+				msg := fmt.Sprintf("Unable to add enum constant %v with value zero to enum %v as the name is already taken", name, ee.QualifiedName)
+				return nil, errors.New(msg)
+			}
+			constants = append(constants, EnumConstantElement{Name: name, Tag: 0})
+			constants = append(constants, ee.EnumConstants...)
+			m.note(ee.QualifiedName, "Enum constant %v = 0 added; the first enum value must be zero in proto3. "+
+				"This is the value unset fields will now have", name)
+		}
+		ee.EnumConstants = constants
+		l = append(l, ee)
+	}
+	return l, nil
+}
+
+// isEnumConstantNameTaken checks if the given name is taken by any constant of the
+// given enums. Since enum constants are siblings of their enum (and not children),
+// this needs to be checked across the enums declared in the same scope.
+func isEnumConstantNameTaken(enums []EnumElement, name string) bool {
+	for _, ee := range enums {
+		for _, ec := range ee.EnumConstants {
+			if ec.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// toUpperSnakeCase converts the given CamelCase name to UPPER_SNAKE_CASE.
+func toUpperSnakeCase(s string) string {
+	var b []byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'A' && c <= 'Z' && i > 0 && (isLower(s[i-1]) || (i+1 < len(s) && isLower(s[i+1]) && s[i-1] != '_')) {
+			b = append(b, '_')
+		}
+		if isLower(c) {
+			c -= 'a' - 'A'
+		}
+		b = append(b, c)
+	}
+	return string(b)
+}
@@ -0,0 +1,109 @@
+package pbparser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const migrateProto = `
+syntax = "proto2";
+package migrate;
+
+enum Color {
+  RED = 1;
+  GREEN = 2;
+}
+
+message Item {
+  required string id = 1;
+  optional int32 count = 2 [default = 10, deprecated = true];
+  optional Item parent = 3;
+  repeated string tags = 4;
+  enum State {
+    ACTIVE = 1;
+    DELETED = 0;
+  }
+  oneof choice {
+    string name = 5;
+  }
+  repeated group Part = 6 {
+    optional string url = 1;
+  }
+  optional Color color = 7;
+  required Item root = 8;
+  extensions 100 to 199;
+}
+
+extend Item {
+  optional string note = 100;
+}
+`
+
+func TestMigrateToProto3(t *testing.T) {
+	pf, err := Parse(strings.NewReader(migrateProto), nil)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	original, _ := Parse(strings.NewReader(migrateProto), nil)
+
+	npf, notes, err := MigrateToProto3(&pf)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	if !reflect.DeepEqual(pf, original) {
+		t.Errorf("Expected the given proto file to be left untouched")
+	}
+	if err := verify(npf, nil, parseOptions{}); err != nil {
+		t.Errorf("Expected migrated proto file to pass verification, Actual: %v", err.Error())
+	}
+
+	var expected = []MigrationNote{
+		{Element: "migrate.Color", Note: "Enum constant COLOR_UNSPECIFIED = 0 added"},
+		{Element: "migrate.Item.id", Note: "Required label replaced by a proto3 optional label"},
+		{Element: "migrate.Item.count", Note: "Optional label kept as a proto3 optional label"},
+		{Element: "migrate.Item.count", Note: "Default value '10' dropped"},
+		{Element: "migrate.Item.part", Note: "Group rewritten as a field of type Part"},
+		{Element: "migrate.Item.color", Note: "Optional label kept as a proto3 optional label"},
+		{Element: "migrate.Item.root", Note: "Required label dropped"},
+		{Element: "migrate.Item", Note: "Extension range 100 to 199 dropped"},
+		{Element: "migrate.Item.State", Note: "Enum constant DELETED moved to the front"},
+		{Element: "migrate.Item.Part.url", Note: "Optional label kept as a proto3 optional label"},
+		{Element: "migrate.Item", Note: "Extend declaration dropped along with its fields: note"},
+	}
+	if len(notes) != len(expected) {
+		t.Errorf("Expected %v notes, Actual: %v", len(expected), notes)
+	}
+	for i := 0; i < len(notes) && i < len(expected); i++ {
+		if notes[i].Element != expected[i].Element || !strings.HasPrefix(notes[i].Note, expected[i].Note) {
+			t.Errorf("Expected note: %v, Actual: %v", expected[i], notes[i])
+		}
+	}
+
+	item := npf.Messages[0]
+	if len(item.Fields[1].Options) != 1 || item.Fields[3].Label != "repeated" {
+		t.Errorf("Expected default options to be dropped, Actual: %v", item.Fields)
+	}
+	for _, i := range []int{0, 1, 5} {
+		if f := item.Fields[i]; f.Label != "optional" || !f.Proto3Optional {
+			t.Errorf("Expected field %v to be a proto3 optional field, Actual: %v", f.Name, f)
+		}
+	}
+	for _, i := range []int{2, 6} {
+		if f := item.Fields[i]; f.Label != "" || f.Proto3Optional {
+			t.Errorf("Expected the label of field %v to be dropped, Actual: %v", f.Name, f)
+		}
+	}
+	if item.Fields[4].Group || item.Fields[4].Type.Name() != "Part" {
+		t.Errorf("Expected the group to be rewritten as a field, Actual: %v", item.Fields[4])
+	}
+	if actual := npf.Enums[0].EnumConstants[0]; actual.Name != "COLOR_UNSPECIFIED" || actual.Tag != 0 {
+		t.Errorf("Expected COLOR_UNSPECIFIED = 0, Actual: %v", actual)
+	}
+
+	// the name of the zero value to be inserted is taken...
+	pf.Enums[0].EnumConstants = append(pf.Enums[0].EnumConstants, EnumConstantElement{Name: "COLOR_UNSPECIFIED", Tag: 3})
+	if _, _, err := MigrateToProto3(&pf); err == nil || !strings.Contains(err.Error(), "name is already taken") {
+		t.Errorf("Expected error on the name of the zero value being taken, Actual: %v", err)
+	}
+}
@@ -0,0 +1,215 @@
+package pbparser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// AddMessage adds the given message to the proto file. The qualified names of
+// the message and of everything nested within it are (re)computed based on the
+// package of the proto file. An Error is returned if the name of the message
+// is already used by another message or enum in the proto file.
+func (pf *ProtoFile) AddMessage(me MessageElement) error {
+	if err := checkTypeName(me.Name, pf.Messages, pf.Enums); err != nil {
+		return err
+	}
+	qualifyMessage(&me, pf.PackageName)
+	pf.Messages = append(pf.Messages, me)
+	return nil
+}
+
+// AddEnum adds the given enum to the proto file. The qualified name of the enum
+// is (re)computed based on the package of the proto file. An Error is returned
+// if the name of the enum is already used by another message or enum in the
+// proto file.
+func (pf *ProtoFile) AddEnum(ee EnumElement) error {
+	if err := checkTypeName(ee.Name, pf.Messages, pf.Enums); err != nil {
+		return err
+	}
+	ee.QualifiedName = qualify(pf.PackageName, ee.Name)
+	pf.Enums = append(pf.Enums, ee)
+	return nil
+}
+
+// AddMessage adds the given message as a nested message within this message.
+// The qualified names of the nested message and of everything nested within it
+// are (re)computed. An Error is returned if the name of the nested message is
+// already used by another nested message or enum, or by a field or oneof.
+func (me *MessageElement) AddMessage(nested MessageElement) error {
+	if err := checkTypeName(nested.Name, me.Messages, me.Enums); err != nil {
+		return err
+	}
+	if me.memberKind(nested.Name) != "" {
+		return errors.New("Duplicate name " + nested.Name)
+	}
+	qualifyMessage(&nested, me.QualifiedName)
+	nested.Ordinal = me.nextOrdinal()
+	me.Messages = append(me.Messages, nested)
+	return nil
+}
+
+// AddEnum adds the given enum as a nested enum within this message. The qualified
+// name of the nested enum is (re)computed. An Error is returned if the name of
+// the nested enum is already used by another nested message or enum, or by a
+// field or oneof.
+func (me *MessageElement) AddEnum(ee EnumElement) error {
+	if err := checkTypeName(ee.Name, me.Messages, me.Enums); err != nil {
+		return err
+	}
+	if me.memberKind(ee.Name) != "" {
+		return errors.New("Duplicate name " + ee.Name)
+	}
+	ee.QualifiedName = qualify(me.QualifiedName, ee.Name)
+	ee.Ordinal = me.nextOrdinal()
+	me.Enums = append(me.Enums, ee)
+	return nil
+}
+
+// AddField adds the given field to the message. An Error is returned if the
+// field has no datatype, if its name is reserved or already used by another
+// field (including fields within oneofs), a oneof, a nested message or a nested
+// enum or if its tag is not available.
+func (me *MessageElement) AddField(f FieldElement) error {
+	if f.Name == "" || f.Type == nil {
+		return errors.New("Field must have a name and a datatype")
+	}
+	for _, name := range me.ReservedNames {
+		if name == f.Name {
+			msg := fmt.Sprintf("Field name '%v' is reserved in message %v", f.Name, me.QualifiedName)
+			return errors.New(msg)
+		}
+	}
+	if kind := me.memberKind(f.Name); kind == "field" {
+		msg := fmt.Sprintf("Duplicate name '%v' for a field in message %v", f.Name, me.QualifiedName)
+		return errors.New(msg)
+	} else if kind != "" {
+		msg := fmt.Sprintf("Duplicate name '%v' for a field in message %v; already used by the %v %v", f.Name, me.QualifiedName, kind, f.Name)
+		return errors.New(msg)
+	}
+	if available, reason := me.IsTagAvailable(f.Tag); !available {
+		msg := fmt.Sprintf("Unable to add field '%v' to message %v. Reason:: %v", f.Name, me.QualifiedName, reason)
+		return errors.New(msg)
+	}
+	f.Ordinal = me.nextOrdinal()
+	me.Fields = append(me.Fields, f)
+	return nil
+}
+
+// RemoveField removes the field with the given name from the message; looking
+// for it within the oneofs too. An Error is returned if there is no such field.
+func (me *MessageElement) RemoveField(name string) error {
+	for i, f := range me.Fields {
+		if f.Name == name {
+			me.Fields = append(me.Fields[:i], me.Fields[i+1:]...)
+			return nil
+		}
+	}
+	for i := range me.OneOfs {
+		oo := &me.OneOfs[i]
+		for j, f := range oo.Fields {
+			if f.Name == name {
+				oo.Fields = append(oo.Fields[:j], oo.Fields[j+1:]...)
+				return nil
+			}
+		}
+	}
+	msg := fmt.Sprintf("Field '%v' not found in message %v", name, me.QualifiedName)
+	return errors.New(msg)
+}
+
+// AddConstant adds the given enum constant to the enum. An Error is returned if
+// the name or the value of the enum constant is reserved in the enum, if the name
+// is already used by another enum constant or if the value is already used and
+// the enum does not allow aliases.
+func (ee *EnumElement) AddConstant(ec EnumConstantElement) error {
+	if isTagReserved(ee.ReservedRanges, ec.Tag) {
+		msg := fmt.Sprintf("Enum constant %v in enum %v uses the reserved value %v", ec.Name, ee.QualifiedName, ec.Tag)
+		return errors.New(msg)
+	}
+	if containsString(ee.ReservedNames, ec.Name) {
+		msg := fmt.Sprintf("Enum constant %v in enum %v uses a reserved name", ec.Name, ee.QualifiedName)
+		return errors.New(msg)
+	}
+	for _, other := range ee.EnumConstants {
+		if other.Name == ec.Name {
+			msg := fmt.Sprintf("Duplicate name '%v' for an enum constant in enum %v", ec.Name, ee.QualifiedName)
+			return errors.New(msg)
+		}
+		if other.Tag == ec.Tag && !isAllowAlias(ee) {
+			return errors.New(ec.Name + " is reusing an enum value. If this is intended, set 'option allow_alias = true;' in the enum")
+		}
+	}
+	ee.EnumConstants = append(ee.EnumConstants, ec)
+	return nil
+}
+
+// memberKind returns the kind (field, oneof, message or enum) of the member of the
+// message which uses the given name; or an empty string if the name is not used.
+// The members of a message all share the same namespace.
+func (me *MessageElement) memberKind(name string) string {
+	for _, f := range me.allFields() {
+		if f.Name == name {
+			return "field"
+		}
+	}
+	for _, oo := range me.OneOfs {
+		if oo.Name == name {
+			return "oneof"
+		}
+	}
+	for _, nested := range me.Messages {
+		if nested.Name == name {
+			return "message"
+		}
+	}
+	for _, ee := range me.Enums {
+		if ee.Name == name {
+			return "enum"
+		}
+	}
+	return ""
+}
+
+// checkTypeName checks that the given name is not already used by any of
+// the given messages or enums which are declared in the same scope.
+func checkTypeName(name string, msgs []MessageElement, enums []EnumElement) error {
+	if name == "" {
+		return errors.New("Message or enum must have a name")
+	}
+	for _, msg := range msgs {
+		if msg.Name == name {
+			return errors.New("Duplicate name " + name)
+		}
+	}
+	for _, en := range enums {
+		if en.Name == name {
+			return errors.New("Duplicate name " + name)
+		}
+	}
+	return nil
+}
+
+// qualifyMessage computes the qualified name of the given message & everything
+// nested within it (howsoever deep) as being declared within the given scope (a
+// package or a qualified message name; possibly empty). The nested elements are
+// copied first so as not to modify the ones shared with the caller.
+func qualifyMessage(me *MessageElement, scope string) {
+	me.QualifiedName = qualify(scope, me.Name)
+	me.Enums = append([]EnumElement(nil), me.Enums...)
+	for i := range me.Enums {
+		me.Enums[i].QualifiedName = qualify(me.QualifiedName, me.Enums[i].Name)
+	}
+	me.ExtendDeclarations = append([]ExtendElement(nil), me.ExtendDeclarations...)
+	for i := range me.ExtendDeclarations {
+		// the name of an extend declaration refers to the extended message; it is
+		// qualified here only if it is a relative one (as the parser does)...
+		if ee := &me.ExtendDeclarations[i]; !strings.Contains(ee.Name, ".") {
+			ee.QualifiedName = qualify(me.QualifiedName, ee.Name)
+		}
+	}
+	me.Messages = append([]MessageElement(nil), me.Messages...)
+	for i := range me.Messages {
+		qualifyMessage(&me.Messages[i], me.QualifiedName)
+	}
+}
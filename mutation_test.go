@@ -0,0 +1,301 @@
+package pbparser_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tallstoat/pbparser"
+	"github.com/tallstoat/pbparser/pbparsertest"
+)
+
+func TestBuildProtoFile(t *testing.T) {
+	pf := pbparser.ProtoFile{PackageName: "built", Syntax: "proto3"}
+
+	status := pbparser.EnumElement{Name: "Status"}
+	for i, name := range []string{"UNKNOWN", "ACTIVE"} {
+		if err := status.AddConstant(pbparser.EnumConstantElement{Name: name, Tag: i}); err != nil {
+			t.Fatalf("%v", err.Error())
+		}
+	}
+	if err := pf.AddEnum(status); err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+
+	strType, _ := pbparser.NewScalarDataType("string")
+	statusType, _ := pbparser.NewNamedDataType("Status")
+	mapType, err := pbparser.NewMapDataType(strType, strType)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+
+	user := pbparser.MessageElement{Name: "User", ReservedNames: []string{"email"}}
+	user.ReservedRanges = []pbparser.ReservedRangeElement{{Start: 4, End: 4}}
+	fields := []pbparser.FieldElement{
+		{Name: "name", Type: strType, Tag: 1},
+		{Name: "status", Type: statusType, Tag: 2},
+		{Name: "labels", Type: mapType, Tag: 3},
+	}
+	for _, f := range fields {
+		if err := user.AddField(f); err != nil {
+			t.Fatalf("%v", err.Error())
+		}
+	}
+	if err := user.AddMessage(pbparser.MessageElement{Name: "Address"}); err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	if err := pf.AddMessage(user); err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+
+	if actual := pf.Enums[0].QualifiedName; actual != "built.Status" {
+		t.Errorf("Expected: built.Status, Actual: %v", actual)
+	}
+	if actual := pf.Messages[0].Messages[0].QualifiedName; actual != "built.User.Address" {
+		t.Errorf("Expected: built.User.Address, Actual: %v", actual)
+	}
+
+	me := &pf.Messages[0]
+	var tests = []struct {
+		f        pbparser.FieldElement
+		errorstr string
+	}{
+		{f: pbparser.FieldElement{Name: "name", Type: strType, Tag: 5}, errorstr: "Duplicate name 'name'"},
+		{f: pbparser.FieldElement{Name: "email", Type: strType, Tag: 5}, errorstr: "Field name 'email' is reserved"},
+		{f: pbparser.FieldElement{Name: "alias", Type: strType, Tag: 1}, errorstr: "already used by field 'name'"},
+		{f: pbparser.FieldElement{Name: "alias", Type: strType, Tag: 4}, errorstr: "within the reserved range 4 to 4"},
+		{f: pbparser.FieldElement{Name: "alias", Tag: 5}, errorstr: "must have a name and a datatype"},
+	}
+	for _, tt := range tests {
+		err := me.AddField(tt.f)
+		if err == nil || !strings.Contains(err.Error(), tt.errorstr) {
+			t.Errorf("Field: %v, Expected error containing %q, Actual: %v", tt.f.Name, tt.errorstr, err)
+		}
+	}
+	if len(me.Fields) != 3 {
+		t.Errorf("Expected 3 fields, Actual: %v", len(me.Fields))
+	}
+
+	if err := me.RemoveField("status"); err != nil {
+		t.Errorf("%v", err.Error())
+	}
+	if err := me.RemoveField("status"); err == nil {
+		t.Errorf("Expected error on removing a missing field")
+	}
+	if err := me.AddField(pbparser.FieldElement{Name: "state", Type: statusType, Tag: 2}); err != nil {
+		t.Errorf("%v", err.Error())
+	}
+
+	if err := pf.AddMessage(pbparser.MessageElement{Name: "Status"}); err == nil {
+		t.Errorf("Expected error on adding a message with a duplicate name")
+	}
+	if err := pf.Enums[0].AddConstant(pbparser.EnumConstantElement{Name: "STARTED", Tag: 1}); err == nil {
+		t.Errorf("Expected error on reusing an enum value")
+	}
+	if _, err := pbparser.NewMapDataType(statusType, strType); err == nil {
+		t.Errorf("Expected error on a map with a non-scalar key")
+	}
+}
+
+// formatProto renders the parts of the given ProtoFile which the mutation helpers
+// build as the content of a .proto file.
+func formatProto(pf pbparser.ProtoFile) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "syntax = \"%v\";\npackage %v;\n", pf.Syntax, pf.PackageName)
+	for _, ee := range pf.Enums {
+		formatEnum(&sb, ee, "")
+	}
+	for _, me := range pf.Messages {
+		formatMessage(&sb, me, "")
+	}
+	return sb.String()
+}
+
+func formatEnum(sb *strings.Builder, ee pbparser.EnumElement, indent string) {
+	fmt.Fprintf(sb, "%venum %v {\n", indent, ee.Name)
+	for _, ec := range ee.EnumConstants {
+		fmt.Fprintf(sb, "%v  %v = %v;\n", indent, ec.Name, ec.Tag)
+	}
+	fmt.Fprintf(sb, "%v}\n", indent)
+}
+
+func formatMessage(sb *strings.Builder, me pbparser.MessageElement, indent string) {
+	fmt.Fprintf(sb, "%vmessage %v {\n", indent, me.Name)
+	for _, rr := range me.ReservedRanges {
+		fmt.Fprintf(sb, "%v  reserved %v to %v;\n", indent, rr.Start, rr.End)
+	}
+	for _, name := range me.ReservedNames {
+		fmt.Fprintf(sb, "%v  reserved \"%v\";\n", indent, name)
+	}
+	formatField := func(f pbparser.FieldElement, indent string) {
+		label := f.Label
+		if label != "" {
+			label += " "
+		}
+		fmt.Fprintf(sb, "%v%v%v %v = %v;\n", indent, label, f.Type.Name(), f.Name, f.Tag)
+	}
+	for _, d := range me.Declarations() {
+		switch e := d.(type) {
+		case pbparser.FieldElement:
+			formatField(e, indent+"  ")
+		case pbparser.OneOfElement:
+			fmt.Fprintf(sb, "%v  oneof %v {\n", indent, e.Name)
+			for _, f := range e.Fields {
+				formatField(f, indent+"    ")
+			}
+			fmt.Fprintf(sb, "%v  }\n", indent)
+		case pbparser.EnumElement:
+			formatEnum(sb, e, indent+"  ")
+		case pbparser.MessageElement:
+			formatMessage(sb, e, indent+"  ")
+		}
+	}
+	fmt.Fprintf(sb, "%v}\n", indent)
+}
+
+// TestBuiltProtoFileRoundTrip ensures that a ProtoFile which is built & edited via
+// the mutation helpers is a valid one; the content rendered out of it being parsed
+// (& verified) back into the same model.
+func TestBuiltProtoFileRoundTrip(t *testing.T) {
+	pf := pbparser.ProtoFile{PackageName: "built", Syntax: "proto3"}
+	status := pbparser.EnumElement{Name: "Status"}
+	for i, name := range []string{"UNKNOWN", "ACTIVE"} {
+		if err := status.AddConstant(pbparser.EnumConstantElement{Name: name, Tag: i}); err != nil {
+			t.Fatalf("%v", err.Error())
+		}
+	}
+	if err := pf.AddEnum(status); err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+
+	strType, _ := pbparser.NewScalarDataType("string")
+	statusType, _ := pbparser.NewNamedDataType("Status")
+	user := pbparser.MessageElement{Name: "User", ReservedNames: []string{"email"}}
+	for _, f := range []pbparser.FieldElement{
+		{Name: "name", Type: strType, Tag: 1},
+		{Name: "status", Type: statusType, Tag: 4},
+	} {
+		if err := user.AddField(f); err != nil {
+			t.Fatalf("%v", err.Error())
+		}
+	}
+	if err := user.AddMessage(pbparser.MessageElement{Name: "Address"}); err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	if err := pf.AddMessage(user); err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+
+	// edit the model...
+	me := &pf.Messages[0]
+	if err := me.AddField(pbparser.FieldElement{Name: "alias", Type: strType, Tag: 6}); err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	if err := me.RemoveField("alias"); err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	addressType, _ := pbparser.NewNamedDataType("Address")
+	if err := me.AddField(pbparser.FieldElement{Name: "address", Type: addressType, Tag: 7}); err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	if err := me.AddEnum(pbparser.EnumElement{Name: "Kind", EnumConstants: []pbparser.EnumConstantElement{{Name: "KIND_NONE"}}}); err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	if _, err := me.Compact(); err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+
+	parsed, err := pbparser.Parse(strings.NewReader(formatProto(pf)), nil, pbparser.WithStrictFieldNumbers())
+	if err != nil {
+		t.Fatalf("%v\n%v", err.Error(), formatProto(pf))
+	}
+	pbparsertest.AssertEqual(t, pf, parsed)
+
+	// the names clashing with the other members of the message are rejected...
+	var tests = []struct {
+		name     string
+		errorstr string
+	}{
+		{name: "Address", errorstr: "Duplicate name 'Address' for a field in message built.User; already used by the message Address"},
+		{name: "Kind", errorstr: "Duplicate name 'Kind' for a field in message built.User; already used by the enum Kind"},
+	}
+	for _, tt := range tests {
+		err := me.AddField(pbparser.FieldElement{Name: tt.name, Type: strType, Tag: 10})
+		if err == nil || err.Error() != tt.errorstr {
+			t.Errorf("Expected error: %v, Actual: %v", tt.errorstr, err)
+		}
+	}
+	if err := me.AddMessage(pbparser.MessageElement{Name: "name"}); err == nil {
+		t.Errorf("Expected error on adding a message named after a field")
+	}
+}
+
+func TestAddMessageQualification(t *testing.T) {
+	strType, _ := pbparser.NewScalarDataType("string")
+	nested := []pbparser.MessageElement{{Name: "Inner", QualifiedName: "old.Outer.Inner"}}
+	extends := []pbparser.ExtendElement{{Name: "Other", QualifiedName: "old.Outer.Other"}}
+	outer := pbparser.MessageElement{
+		Name:               "Outer",
+		Messages:           nested,
+		Enums:              []pbparser.EnumElement{{Name: "Kind"}},
+		ExtendDeclarations: extends,
+		Fields:             []pbparser.FieldElement{{Name: "name", Type: strType, Tag: 1}},
+	}
+
+	pf := pbparser.ProtoFile{Syntax: "proto2"}
+	if err := pf.AddMessage(outer); err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	if err := pf.AddEnum(pbparser.EnumElement{Name: "Status"}); err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+
+	var tests = []struct {
+		actual   string
+		expected string
+	}{
+		{actual: pf.Messages[0].QualifiedName, expected: "Outer"},
+		{actual: pf.Enums[0].QualifiedName, expected: "Status"},
+		{actual: pf.Messages[0].Messages[0].QualifiedName, expected: "Outer.Inner"},
+		{actual: pf.Messages[0].Enums[0].QualifiedName, expected: "Outer.Kind"},
+		{actual: pf.Messages[0].ExtendDeclarations[0].QualifiedName, expected: "Outer.Other"},
+		{actual: nested[0].QualifiedName, expected: "old.Outer.Inner"},
+		{actual: extends[0].QualifiedName, expected: "old.Outer.Other"},
+	}
+	for _, tt := range tests {
+		if tt.actual != tt.expected {
+			t.Errorf("Expected: %v, Actual: %v", tt.expected, tt.actual)
+		}
+	}
+}
+
+func TestAddConstantReserved(t *testing.T) {
+	ee := pbparser.EnumElement{
+		Name:           "State",
+		QualifiedName:  "reserved.State",
+		ReservedRanges: []pbparser.ReservedRangeElement{{Start: 2, End: 4}},
+		ReservedNames:  []string{"STATE_GONE"},
+	}
+	var tests = []struct {
+		ec       pbparser.EnumConstantElement
+		errorstr string
+	}{
+		{ec: pbparser.EnumConstantElement{Name: "STATE_ACTIVE", Tag: 3}, errorstr: "Enum constant STATE_ACTIVE in enum reserved.State uses the reserved value 3"},
+		{ec: pbparser.EnumConstantElement{Name: "STATE_GONE", Tag: 5}, errorstr: "Enum constant STATE_GONE in enum reserved.State uses a reserved name"},
+		{ec: pbparser.EnumConstantElement{Name: "STATE_UNKNOWN", Tag: 0}},
+		{ec: pbparser.EnumConstantElement{Name: "STATE_DONE", Tag: 5}},
+	}
+	for _, tt := range tests {
+		err := ee.AddConstant(tt.ec)
+		if tt.errorstr == "" {
+			if err != nil {
+				t.Errorf("Constant: %v, Unexpected error: %v", tt.ec.Name, err.Error())
+			}
+		} else if err == nil || err.Error() != tt.errorstr {
+			t.Errorf("Constant: %v, Expected error: %v, Actual: %v", tt.ec.Name, tt.errorstr, err)
+		}
+	}
+	if len(ee.EnumConstants) != 2 {
+		t.Errorf("Expected 2 constants, Actual: %v", ee.EnumConstants)
+	}
+}
@@ -0,0 +1,41 @@
+package pbparser
+
+import "strings"
+
+// FullyQualify returns the qualified name of the message/enum which the given
+// (possibly relative) name refers to when referenced within the given scope; the
+// scope being the qualified name of a message (or the package of the proto file at
+// the top level). The name is resolved the same way as the verifier does (which is
+// the way protoc does) against the types of the proto file and, if the proto file
+// was produced by the Parse() or the ParseFile() api, the types of its imports. The
+// second value returned is false if the name does not resolve.
+func (pf *ProtoFile) FullyQualify(name string, scope string) (string, bool) {
+	return resolveTypeName(scope, name, pf.definesType())
+}
+
+// RelativeName returns the shortest reference to the message/enum with the given
+// qualified name which is valid within the given scope (as accepted by FullyQualify)
+// i.e. the shortest one which FullyQualify resolves back to the qualified name. If
+// there is no such reference, the fully qualified name prefixed with a '.' is returned.
+func (pf *ProtoFile) RelativeName(qname string, scope string) string {
+	qname = strings.TrimPrefix(qname, ".")
+	defined := pf.definesType()
+	parts := strings.Split(qname, ".")
+	for i := len(parts) - 1; i >= 0; i-- {
+		name := strings.Join(parts[i:], ".")
+		if resolved, found := resolveTypeName(scope, name, defined); found && resolved == qname {
+			return name
+		}
+	}
+	return "." + qname
+}
+
+// definesType returns a function which reports whether the given qualified name is
+// that of a message/enum of the proto file or of its imports.
+func (pf *ProtoFile) definesType() func(string) bool {
+	names := typeNames(pf)
+	return func(qname string) bool {
+		_, imported := pf.importedTypes[qname]
+		return names.has(qname) || imported
+	}
+}
@@ -0,0 +1,127 @@
+package pbparser_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tallstoat/pbparser"
+)
+
+const namesProto = `syntax = "proto3";
+package pkg.v1;
+
+import "common.proto";
+
+message Outer {
+  message Inner {
+    message Deep {
+      string id = 1;
+    }
+    Deep deep = 1;
+  }
+  enum Kind {
+    KIND_UNKNOWN = 0;
+  }
+  Inner inner = 1;
+  Kind kind = 2;
+  common.Audit audit = 3;
+}
+
+message Inner {
+  string id = 1;
+}
+
+message Other {
+  message Outer {
+    string id = 1;
+  }
+  Outer outer = 1;
+}
+`
+
+func parseNamesProto(t *testing.T) pbparser.ProtoFile {
+	provider := mapImportModuleProvider{
+		"common.proto": "syntax = \"proto3\";\npackage common;\nmessage Audit {\n  string by = 1;\n}\n",
+	}
+	pf, err := pbparser.Parse(strings.NewReader(namesProto), provider)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	return pf
+}
+
+func TestFullyQualify(t *testing.T) {
+	pf := parseNamesProto(t)
+
+	var tests = []struct {
+		name     string
+		scope    string
+		expected string
+		found    bool
+	}{
+		{name: "Inner", scope: "pkg.v1.Outer", expected: "pkg.v1.Outer.Inner", found: true},
+		{name: "Inner", scope: "pkg.v1.Outer.Inner", expected: "pkg.v1.Outer.Inner", found: true},
+		{name: "Inner", scope: "pkg.v1.Other", expected: "pkg.v1.Inner", found: true},
+		{name: "Inner", scope: "pkg.v1", expected: "pkg.v1.Inner", found: true},
+		{name: "Deep", scope: "pkg.v1.Outer.Inner", expected: "pkg.v1.Outer.Inner.Deep", found: true},
+		{name: "Deep", scope: "pkg.v1.Outer.Inner.Deep", expected: "pkg.v1.Outer.Inner.Deep", found: true},
+		{name: "Inner.Deep", scope: "pkg.v1.Outer", expected: "pkg.v1.Outer.Inner.Deep", found: true},
+		{name: "Outer.Inner.Deep", scope: "pkg.v1", expected: "pkg.v1.Outer.Inner.Deep", found: true},
+		{name: "v1.Outer", scope: "pkg.v1.Other", expected: "pkg.v1.Outer", found: true},
+		{name: "Outer", scope: "pkg.v1.Other", expected: "pkg.v1.Other.Outer", found: true},
+		{name: "Kind", scope: "pkg.v1.Outer.Inner", expected: "pkg.v1.Outer.Kind", found: true},
+		{name: "pkg.v1.Outer.Kind", scope: "pkg.v1.Other", expected: "pkg.v1.Outer.Kind", found: true},
+		{name: ".pkg.v1.Inner", scope: "pkg.v1.Outer", expected: "pkg.v1.Inner", found: true},
+		{name: "common.Audit", scope: "pkg.v1.Outer", expected: "common.Audit", found: true},
+		{name: ".common.Audit", scope: "pkg.v1.Outer", expected: "common.Audit", found: true},
+		{name: "Deep", scope: "pkg.v1.Outer", expected: "Deep"},
+		{name: "Missing", scope: "pkg.v1.Outer", expected: "Missing"},
+		{name: ".Inner", scope: "pkg.v1", expected: "Inner"},
+	}
+
+	for _, tt := range tests {
+		actual, found := pf.FullyQualify(tt.name, tt.scope)
+		if actual != tt.expected || found != tt.found {
+			t.Errorf("FullyQualify(%q, %q) Expected: %q (%v), Actual: %q (%v)", tt.name, tt.scope, tt.expected, tt.found, actual, found)
+		}
+	}
+}
+
+func TestRelativeName(t *testing.T) {
+	pf := parseNamesProto(t)
+
+	var tests = []struct {
+		qname    string
+		scope    string
+		expected string
+	}{
+		{qname: "pkg.v1.Outer.Inner", scope: "pkg.v1.Outer", expected: "Inner"},
+		{qname: "pkg.v1.Outer.Inner", scope: "pkg.v1.Outer.Inner.Deep", expected: "Inner"},
+		{qname: "pkg.v1.Outer.Inner", scope: "pkg.v1", expected: "Outer.Inner"},
+		{qname: "pkg.v1.Outer.Inner", scope: "pkg.v1.Other", expected: "Outer.Inner"},
+		{qname: "pkg.v1.Inner", scope: "pkg.v1", expected: "Inner"},
+		{qname: "pkg.v1.Inner", scope: "pkg.v1.Other", expected: "Inner"},
+		{qname: "pkg.v1.Inner", scope: "pkg.v1.Outer", expected: "v1.Inner"},
+		{qname: ".pkg.v1.Inner", scope: "pkg.v1.Outer.Inner", expected: "v1.Inner"},
+		{qname: "pkg.v1.Outer.Inner.Deep", scope: "pkg.v1.Outer", expected: "Inner.Deep"},
+		{qname: "pkg.v1.Outer.Kind", scope: "pkg.v1.Outer.Inner.Deep", expected: "Kind"},
+		{qname: "pkg.v1.Other.Outer", scope: "pkg.v1.Other", expected: "Outer"},
+		{qname: "pkg.v1.Outer", scope: "pkg.v1.Other", expected: "v1.Outer"},
+		{qname: "common.Audit", scope: "pkg.v1.Outer", expected: "common.Audit"},
+		{qname: "pkg.v1.Missing", scope: "pkg.v1", expected: ".pkg.v1.Missing"},
+	}
+
+	for _, tt := range tests {
+		actual := pf.RelativeName(tt.qname, tt.scope)
+		if actual != tt.expected {
+			t.Errorf("RelativeName(%q, %q) Expected: %q, Actual: %q", tt.qname, tt.scope, tt.expected, actual)
+			continue
+		}
+		// the relative name must resolve back to the qualified name...
+		if tt.expected[0] != '.' {
+			if resolved, _ := pf.FullyQualify(actual, tt.scope); resolved != strings.TrimPrefix(tt.qname, ".") {
+				t.Errorf("FullyQualify(%q, %q) Expected: %q, Actual: %q", actual, tt.scope, tt.qname, resolved)
+			}
+		}
+	}
+}
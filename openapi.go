@@ -0,0 +1,321 @@
+package pbparser
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// OpenAPIOptions holds the knobs which can be tweaked while generating an
+// OpenAPI document via the GenerateOpenAPI() api.
+type OpenAPIOptions struct {
+	// Format is the format of the generated document; either "json" (the default) or "yaml".
+	Format string
+	// Title is the title of the api. Defaults to the package of the proto file.
+	Title string
+	// Version is the version of the api. Defaults to "1.0.0".
+	Version string
+}
+
+// GenerateOpenAPI generates an OpenAPI 3 document skeleton for the services in
+// the given proto file. Every rpc becomes an operation; on the path & method in
+// its google.api.http option if present or else, on POST /<package>.<Service>/<Method>.
+// The request and response messages of the rpcs (and any messages or enums defined
+// in the proto file which they refer to) are described as schemas in the components
+// section of the document. Rpcs with streaming requests or responses are marked
+// with the x-client-streaming and x-server-streaming extension fields.
+//
+// Messages which are not defined in the given proto file (e.g. imported ones) are
+// described as free-form objects.
+func GenerateOpenAPI(pf *ProtoFile, opts OpenAPIOptions) ([]byte, error) {
+	g := openAPIGenerator{
+		msgs:    make(map[string]MessageElement),
+		enums:   make(map[string]EnumElement),
+		schemas: make(map[string]interface{}),
+	}
+	g.index(pf.Messages, pf.Enums)
+
+	title := opts.Title
+	if title == "" {
+		title = pf.PackageName
+	}
+	version := opts.Version
+	if version == "" {
+		version = "1.0.0"
+	}
+
+	paths := make(map[string]interface{})
+	for _, se := range pf.Services {
+		for _, rpc := range se.RPCs {
+			method, path, body := httpRule(rpc)
+			if path == "" {
+				method, path, body = "post", "/"+se.QualifiedName+"/"+rpc.Name, "*"
+			}
+			pathItem, found := paths[path].(map[string]interface{})
+			if !found {
+				pathItem = make(map[string]interface{})
+				paths[path] = pathItem
+			}
+			if _, found := pathItem[method]; found {
+				msg := fmt.Sprintf("Rpc %v in service %v reuses %v %v", rpc.Name, se.QualifiedName, strings.ToUpper(method), path)
+				return nil, errors.New(msg)
+			}
+			pathItem[method] = g.operation(se, rpc, path, body)
+		}
+	}
+
+	doc := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   title,
+			"version": version,
+		},
+		"paths": paths,
+	}
+	if len(g.schemas) > 0 {
+		doc["components"] = map[string]interface{}{"schemas": g.schemas}
+	}
+
+	switch opts.Format {
+	case "", "json":
+		return json.MarshalIndent(doc, "", "  ")
+	case "yaml":
+		var buf bytes.Buffer
+		writeYAML(&buf, doc, 0)
+		return buf.Bytes(), nil
+	}
+	return nil, fmt.Errorf("'%v' is not a supported OpenAPI format", opts.Format)
+}
+
+type openAPIGenerator struct {
+	msgs    map[string]MessageElement
+	enums   map[string]EnumElement
+	schemas map[string]interface{}
+}
+
+// index records the given messages and enums (howsoever deep) by qualified name.
+func (g *openAPIGenerator) index(msgs []MessageElement, enums []EnumElement) {
+	for _, ee := range enums {
+		g.enums[ee.QualifiedName] = ee
+	}
+	for _, me := range msgs {
+		g.msgs[me.QualifiedName] = me
+		g.index(me.Messages, me.Enums)
+	}
+}
+
+func (g *openAPIGenerator) defined(name string) bool {
+	_, isMsg := g.msgs[name]
+	_, isEnum := g.enums[name]
+	return isMsg || isEnum
+}
+
+func (g *openAPIGenerator) operation(se ServiceElement, rpc RPCElement, path string, body string) map[string]interface{} {
+	op := map[string]interface{}{
+		"operationId": se.Name + "_" + rpc.Name,
+		"tags":        []interface{}{se.Name},
+		"responses": map[string]interface{}{
+			"200": map[string]interface{}{
+				"description": "OK",
+				"content":     jsonContent(g.namedSchema(se.QualifiedName, rpc.ResponseType.Name())),
+			},
+		},
+	}
+	if lines := docLines(rpc.Documentation.Leading); len(lines) > 0 {
+		op["description"] = strings.Join(lines, "\n")
+	}
+
+	var params []interface{}
+	for _, m := range pathParamRegex.FindAllStringSubmatch(path, -1) {
+		params = append(params, map[string]interface{}{
+			"name":     m[1],
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]interface{}{"type": "string"},
+		})
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+
+	if body != "" {
+		op["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  jsonContent(g.namedSchema(se.QualifiedName, rpc.RequestType.Name())),
+		}
+	}
+	if rpc.RequestType.IsStream() {
+		op["x-client-streaming"] = true
+	}
+	if rpc.ResponseType.IsStream() {
+		op["x-server-streaming"] = true
+	}
+	return op
+}
+
+// namedSchema returns the schema referring to the given message/enum, adding the
+// schema of the message/enum to the components if not already added.
+func (g *openAPIGenerator) namedSchema(scope string, name string) map[string]interface{} {
+	qname, found := resolveTypeName(scope, name, g.defined)
+	if !found {
+		return map[string]interface{}{"type": "object"}
+	}
+	ref := map[string]interface{}{"$ref": "#/components/schemas/" + qname}
+	if _, found := g.schemas[qname]; found {
+		return ref
+	}
+
+	if ee, isEnum := g.enums[qname]; isEnum {
+		var values []interface{}
+		for _, ec := range ee.EnumConstants {
+			values = append(values, ec.Name)
+		}
+		schema := map[string]interface{}{"type": "string", "enum": values}
+		addDescription(schema, ee.Documentation)
+		g.schemas[qname] = schema
+		return ref
+	}
+
+	me := g.msgs[qname]
+	props := make(map[string]interface{})
+	schema := map[string]interface{}{"type": "object", "properties": props}
+	addDescription(schema, me.Documentation)
+	// add the schema before describing the fields so that recursive messages terminate...
+	g.schemas[qname] = schema
+	for _, f := range me.allFields() {
+		fs := g.fieldSchema(qname, f.Type)
+		if f.IsRepeated() {
+			fs = map[string]interface{}{"type": "array", "items": fs}
+		}
+		addDescription(fs, f.Documentation)
+		props[f.JSONName()] = fs
+	}
+	return ref
+}
+
+func (g *openAPIGenerator) fieldSchema(scope string, dt DataType) map[string]interface{} {
+	switch t := dt.(type) {
+	case ScalarDataType:
+		return scalarSchema(t)
+	case MapDataType:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.fieldSchema(scope, t.valueType)}
+	}
+	return g.namedSchema(scope, dt.Name())
+}
+
+// scalarSchema returns the schema of a scalar type as per the proto3 JSON mapping.
+func scalarSchema(sdt ScalarDataType) map[string]interface{} {
+	schema := make(map[string]interface{})
+	if t := sdt.JSONType(); t != "" {
+		schema["type"] = t
+	}
+	switch {
+	case sdt.Type() == BytesScalar:
+		schema["format"] = "byte"
+	case sdt.IsFloatingPoint():
+		schema["format"] = sdt.Name()
+	case sdt.IsInteger() && sdt.IsUnsigned() && sdt.is64Bit():
+		schema["format"] = "uint64"
+	case sdt.IsInteger() && sdt.IsUnsigned():
+		schema["format"] = "uint32"
+	case sdt.IsInteger() && sdt.is64Bit():
+		schema["format"] = "int64"
+	case sdt.IsInteger():
+		schema["format"] = "int32"
+	}
+	return schema
+}
+
+// Regexes for picking the path & body out of the value of a google.api.http option
+// and for picking the path parameters out of a path template
+var httpRuleRegex = regexp.MustCompile(`\b(get|put|post|delete|patch)\s*:\s*"([^"]*)"`)
+var httpBodyRegex = regexp.MustCompile(`\bbody\s*:\s*"([^"]*)"`)
+var pathParamRegex = regexp.MustCompile(`\{([^}=]+)(=[^}]*)?\}`)
+
+// httpRule returns the method, path and body specified in the google.api.http
+// option of the given rpc. An empty path is returned if no such option exists.
+func httpRule(rpc RPCElement) (string, string, string) {
+	for _, op := range rpc.Options {
+		if op.Name != "google.api.http" || !op.IsParenthesized {
+			continue
+		}
+		m := httpRuleRegex.FindStringSubmatch(op.Value)
+		if m == nil {
+			continue
+		}
+		var body string
+		if b := httpBodyRegex.FindStringSubmatch(op.Value); b != nil {
+			body = b[1]
+		}
+		// OpenAPI paths can't carry the segment patterns of the path template...
+		path := pathParamRegex.ReplaceAllString(m[2], "{$1}")
+		return m[1], path, body
+	}
+	return "", "", ""
+}
+
+func jsonContent(schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"application/json": map[string]interface{}{"schema": schema},
+	}
+}
+
+func addDescription(schema map[string]interface{}, doc Documentation) {
+	if _, isRef := schema["$ref"]; isRef {
+		return
+	}
+	if lines := docLines(doc.Leading); len(lines) > 0 {
+		schema["description"] = strings.Join(lines, "\n")
+	}
+}
+
+// writeYAML writes the given document (made up of maps, slices, strings, ints &
+// bools only) as YAML. Strings are always written as quoted JSON strings which
+// YAML accepts as is.
+func writeYAML(buf *bytes.Buffer, v interface{}, indent int) {
+	pad := strings.Repeat("  ", indent)
+	switch t := v.(type) {
+	case map[string]interface{}:
+		var keys []string
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			key, _ := json.Marshal(k)
+			fmt.Fprintf(buf, "%v%s:", pad, key)
+			writeYAMLValue(buf, t[k], indent)
+		}
+	case []interface{}:
+		for _, e := range t {
+			fmt.Fprintf(buf, "%v-", pad)
+			writeYAMLValue(buf, e, indent)
+		}
+	}
+}
+
+func writeYAMLValue(buf *bytes.Buffer, v interface{}, indent int) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if len(t) == 0 {
+			buf.WriteString(" {}\n")
+			return
+		}
+		buf.WriteString("\n")
+		writeYAML(buf, t, indent+1)
+	case []interface{}:
+		if len(t) == 0 {
+			buf.WriteString(" []\n")
+			return
+		}
+		buf.WriteString("\n")
+		writeYAML(buf, t, indent+1)
+	default:
+		s, _ := json.Marshal(t)
+		fmt.Fprintf(buf, " %s\n", s)
+	}
+}
@@ -0,0 +1,131 @@
+package pbparser_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/tallstoat/pbparser"
+)
+
+const openAPIProto = `
+syntax = "proto3";
+package api;
+
+// Item is a thing.
+message Item {
+  string name = 1;
+  int64 size_bytes = 2;
+  repeated Item children = 3;
+  Kind kind = 4;
+  enum Kind {
+    UNKNOWN = 0;
+    BIG = 1;
+  }
+}
+
+message GetItemRequest {
+  string name = 1;
+}
+
+service Items {
+  // GetItem fetches an item.
+  rpc GetItem(GetItemRequest) returns (Item) {}
+  rpc WatchItems(stream GetItemRequest) returns (stream Item) {}
+}
+`
+
+func TestGenerateOpenAPI(t *testing.T) {
+	pf, err := pbparser.Parse(strings.NewReader(openAPIProto), nil)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	// the value of an aggregate google.api.http option...
+	rpc := &pf.Services[0].RPCs[0]
+	rpc.Options = append(rpc.Options, pbparser.OptionElement{
+		Name:            "google.api.http",
+		Value:           `get: "/v1/{name=items/*}"`,
+		IsParenthesized: true,
+	})
+
+	out, err := pbparser.GenerateOpenAPI(&pf, pbparser.OpenAPIOptions{Title: "Items API"})
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+
+	var tests = []struct {
+		path     string
+		expected interface{}
+	}{
+		{path: "openapi", expected: "3.0.3"},
+		{path: "info.title", expected: "Items API"},
+		{path: "paths./v1/{name}.get.operationId", expected: "Items_GetItem"},
+		{path: "paths./v1/{name}.get.description", expected: "GetItem fetches an item."},
+		{path: "paths./v1/{name}.get.parameters.0.name", expected: "name"},
+		{path: "paths./v1/{name}.get.requestBody", expected: nil},
+		{path: "paths./v1/{name}.get.responses.200.content.application/json.schema.$ref", expected: "#/components/schemas/api.Item"},
+		{path: "paths./api.Items/WatchItems.post.requestBody.content.application/json.schema.$ref", expected: "#/components/schemas/api.GetItemRequest"},
+		{path: "paths./api.Items/WatchItems.post.x-client-streaming", expected: true},
+		{path: "paths./api.Items/WatchItems.post.x-server-streaming", expected: true},
+		{path: "components.schemas.api.Item.description", expected: "Item is a thing."},
+		{path: "components.schemas.api.Item.properties.sizeBytes.format", expected: "int64"},
+		{path: "components.schemas.api.Item.properties.children.items.$ref", expected: "#/components/schemas/api.Item"},
+		{path: "components.schemas.api.Item.properties.kind.$ref", expected: "#/components/schemas/api.Item.Kind"},
+		{path: "components.schemas.api.Item.Kind.enum.1", expected: "BIG"},
+	}
+	for _, tt := range tests {
+		if actual := lookup(doc, tt.path); actual != tt.expected {
+			t.Errorf("Path: %v, Expected: %v, Actual: %v", tt.path, tt.expected, actual)
+		}
+	}
+
+	out, err = pbparser.GenerateOpenAPI(&pf, pbparser.OpenAPIOptions{Format: "yaml"})
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	if s := "\"paths\":\n  \"/api.Items/WatchItems\":\n    \"post\":\n"; !strings.Contains(string(out), s) {
+		t.Errorf("Expected yaml to contain %q, Actual:\n%v", s, string(out))
+	}
+}
+
+// lookup walks the given document along the given path. Since the keys of the
+// document can contain dots themselves, the longest matching key is picked at
+// every step.
+func lookup(v interface{}, path string) interface{} {
+	for path != "" {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			key := path
+			for {
+				if _, found := t[key]; found {
+					break
+				}
+				i := strings.LastIndex(key, ".")
+				if i < 0 {
+					return nil
+				}
+				key = key[:i]
+			}
+			v = t[key]
+			path = strings.TrimPrefix(strings.TrimPrefix(path, key), ".")
+		case []interface{}:
+			i := strings.Index(path+".", ".")
+			idx := 0
+			for _, c := range path[:i] {
+				idx = idx*10 + int(c-'0')
+			}
+			if idx >= len(t) {
+				return nil
+			}
+			v = t[idx]
+			path = strings.TrimPrefix(path[i:], ".")
+		default:
+			return nil
+		}
+	}
+	return v
+}
@@ -0,0 +1,121 @@
+package pbparser
+
+import "errors"
+
+// ParseOption is a functional option which can be passed to the Parse() and
+// ParseFile() apis to tweak the default behavior of the parser.
+type ParseOption func(*parseOptions)
+
+// parseOptions holds the knobs which can be tweaked via ParseOption(s).
+type parseOptions struct {
+	lenient               bool
+	lateSyntax            bool
+	duplicateTypeWarnings bool
+	wellKnownImports      bool
+	strictProto3Labels    bool
+	strictFieldNumbers    bool
+	verifyRules           []VerifyRule
+	interner              *Interner
+	includes              []string
+	excludes              []string
+	dependencies          map[string]dependency // the dependencies parsed so far (if shared)
+}
+
+// WithLenientParsing enables the lenient parsing mode. In this mode, any
+// declaration which the parser does not recognize at the file or message
+// level is captured verbatim as a RawDeclaration instead of failing the
+// parse, so that the rest of the file can still be parsed.
+func WithLenientParsing() ParseOption {
+	return func(o *parseOptions) {
+		o.lenient = true
+	}
+}
+
+// WithLateSyntax allows the syntax statement to appear after other statements
+// in the file, as some legacy files do. By default, the syntax statement (when
+// present) must be the first statement in the file as protoc requires. Note that
+// the proto3 specific checks are not applied to the fields declared before the
+// syntax statement.
+func WithLateSyntax() ParseOption {
+	return func(o *parseOptions) {
+		o.lateSyntax = true
+	}
+}
+
+// WithDuplicateTypeWarnings reports a message or enum which is defined both in
+// the main file & in one of its imports (or in two of its imports) as a warning
+// in ProtoFile.Warnings instead of failing the verification; for codebases which
+// intentionally shadow types.
+func WithDuplicateTypeWarnings() ParseOption {
+	return func(o *parseOptions) {
+		o.duplicateTypeWarnings = true
+	}
+}
+
+// WithWellKnownImports tolerates the well-known imports (the ones under the
+// google/protobuf/ prefix) when no ImportModuleProvider is given to Parse(). The
+// references to their types are verified against the names of the types known
+// to the library. Any other import still requires an ImportModuleProvider.
+func WithWellKnownImports() ParseOption {
+	return func(o *parseOptions) {
+		o.wellKnownImports = true
+	}
+}
+
+// WithStrictProto3Labels rejects the explicit optional label on the fields of the
+// proto3 files; as protoc did before the 3.15 release. By default, such fields are
+// accepted & have FieldElement.Proto3Optional set.
+func WithStrictProto3Labels() ParseOption {
+	return func(o *parseOptions) {
+		o.strictProto3Labels = true
+	}
+}
+
+// WithStrictFieldNumbers verifies the field numbers of the messages as protoc does;
+// rejecting a number which is used by more than one field of a message, which is
+// out of the valid range or which falls within a reserved range, an extensions
+// range or the range 19000 to 19999 reserved for the protocol buffers implementation.
+// By default, the field numbers are not verified.
+func WithStrictFieldNumbers() ParseOption {
+	return func(o *parseOptions) {
+		o.strictFieldNumbers = true
+	}
+}
+
+// WithVerifyRules registers custom verification rules which are applied (in the
+// given order) after the built-in checks. The first violation reported by the
+// rules fails the verification.
+func WithVerifyRules(rules ...VerifyRule) ParseOption {
+	return func(o *parseOptions) {
+		o.verifyRules = append(o.verifyRules, rules...)
+	}
+}
+
+// WithInterning makes the parser share the storage of identical strings (names,
+// type names, labels, options, imports etc) of the parsed models via the given
+// Interner; to reduce the memory held by the models of a large number of files.
+// The same Interner is meant to be passed to each of the parse calls of a batch.
+// The models are the same as the ones produced without interning.
+func WithInterning(in *Interner) ParseOption {
+	return func(o *parseOptions) {
+		o.interner = in
+	}
+}
+
+// checkFileOptions returns an Error if the options hold any of the knobs which
+// only ParseDir() supports.
+func (o parseOptions) checkFileOptions() error {
+	if len(o.includes) > 0 || len(o.excludes) > 0 {
+		return errors.New("WithIncludes() and WithExcludes() are only supported by ParseDir()")
+	}
+	return nil
+}
+
+// newParseOptions applies the given ParseOption(s) over the defaults.
+func newParseOptions(opts []ParseOption) parseOptions {
+	o := parseOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
@@ -0,0 +1,71 @@
+package pbparser
+
+import "sort"
+
+// Declarations returns the fields, oneofs, enums, nested messages & extend
+// declarations of the message in the order in which they were declared. The
+// elements are returned as values of the types FieldElement, OneOfElement,
+// EnumElement, MessageElement & ExtendElement respectively.
+//
+// Elements which have no Ordinal recorded (i.e. zero) follow the others; in
+// the order listed above.
+func (me *MessageElement) Declarations() []interface{} {
+	type declaration struct {
+		ordinal int
+		element interface{}
+	}
+	var decls []declaration
+	for _, f := range me.Fields {
+		decls = append(decls, declaration{f.Ordinal, f})
+	}
+	for _, oo := range me.OneOfs {
+		decls = append(decls, declaration{oo.Ordinal, oo})
+	}
+	for _, ee := range me.Enums {
+		decls = append(decls, declaration{ee.Ordinal, ee})
+	}
+	for _, nested := range me.Messages {
+		decls = append(decls, declaration{nested.Ordinal, nested})
+	}
+	for _, ee := range me.ExtendDeclarations {
+		decls = append(decls, declaration{ee.Ordinal, ee})
+	}
+	sort.SliceStable(decls, func(i, j int) bool {
+		if decls[j].ordinal == 0 {
+			return decls[i].ordinal != 0
+		}
+		return decls[i].ordinal != 0 && decls[i].ordinal < decls[j].ordinal
+	})
+
+	l := make([]interface{}, len(decls))
+	for i, d := range decls {
+		l[i] = d.element
+	}
+	return l
+}
+
+// nextOrdinal returns the ordinal for the next declaration within the message.
+func (me *MessageElement) nextOrdinal() int {
+	max := 0
+	update := func(ordinal int) {
+		if ordinal > max {
+			max = ordinal
+		}
+	}
+	for _, f := range me.Fields {
+		update(f.Ordinal)
+	}
+	for _, oo := range me.OneOfs {
+		update(oo.Ordinal)
+	}
+	for _, ee := range me.Enums {
+		update(ee.Ordinal)
+	}
+	for _, nested := range me.Messages {
+		update(nested.Ordinal)
+	}
+	for _, ee := range me.ExtendDeclarations {
+		update(ee.Ordinal)
+	}
+	return max + 1
+}
@@ -0,0 +1,58 @@
+package pbparser_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tallstoat/pbparser"
+)
+
+func TestDeclarationOrder(t *testing.T) {
+	me, err := pbparser.ParseMessage(strings.NewReader(`message Task {
+  string id = 1;
+  oneof due {
+    int64 timestamp = 2;
+    string date = 3;
+  }
+  enum State { STATE_UNKNOWN = 0; }
+  State state = 4;
+  message Note { string text = 1; }
+  repeated Note notes = 5;
+}`))
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+
+	name := func(decl interface{}) string {
+		switch d := decl.(type) {
+		case pbparser.FieldElement:
+			return d.Name
+		case pbparser.OneOfElement:
+			return d.Name
+		case pbparser.EnumElement:
+			return d.Name
+		case pbparser.MessageElement:
+			return d.Name
+		}
+		return ""
+	}
+	names := func() string {
+		var l []string
+		for _, decl := range me.Declarations() {
+			l = append(l, name(decl))
+		}
+		return strings.Join(l, ",")
+	}
+
+	if expected := "id,due,State,state,Note,notes"; names() != expected {
+		t.Errorf("Expected order: %v, Actual: %v", expected, names())
+	}
+
+	strType, _ := pbparser.NewScalarDataType("string")
+	if err := me.AddField(pbparser.FieldElement{Name: "owner", Type: strType, Tag: 6, Ordinal: 1}); err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	if expected := "id,due,State,state,Note,notes,owner"; names() != expected {
+		t.Errorf("Expected order: %v, Actual: %v", expected, names())
+	}
+}
@@ -0,0 +1,41 @@
+package pbparser
+
+import (
+	"sort"
+	"strings"
+)
+
+// packageSet is a lookup structure of the package of the main proto file & the
+// packages of its dependencies which finds the package qualifying a type name
+// by the longest match; so that a package which is a prefix of another one
+// (e.g. foo & foo.bar) does not shadow it.
+type packageSet struct {
+	main string
+	deps []string
+	set  map[string]bool
+}
+
+// newPackageSet creates a packageSet for the given package of the main proto
+// file & the given packages of its dependencies.
+func newPackageSet(main string, deps []string) packageSet {
+	ps := packageSet{main: main, deps: append([]string{}, deps...), set: make(map[string]bool)}
+	sort.Strings(ps.deps)
+	for _, pkg := range ps.deps {
+		ps.set[pkg] = true
+	}
+	if main != "" {
+		ps.set[main] = true
+	}
+	return ps
+}
+
+// packageOf returns the longest of the packages which qualifies the given type
+// name; or an empty string if none of them do.
+func (ps packageSet) packageOf(name string) string {
+	for i := strings.LastIndex(name, "."); i > 0; i = strings.LastIndex(name[:i], ".") {
+		if ps.set[name[:i]] {
+			return name[:i]
+		}
+	}
+	return ""
+}
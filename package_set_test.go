@@ -0,0 +1,116 @@
+package pbparser_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tallstoat/pbparser"
+)
+
+var nestedPackageModules = mapImportModuleProvider{
+	"foo.proto": `syntax = "proto3";
+package foo;
+message Bar { string id = 1; }
+`,
+	"foo-bar.proto": `syntax = "proto3";
+package foo.bar;
+message Baz { string id = 1; }
+`,
+}
+
+func TestNestedPackageNames(t *testing.T) {
+	var tests = []struct {
+		content  string
+		errorstr string
+	}{
+		{
+			content: `syntax = "proto3";
+package app;
+import "foo.proto";
+import "foo-bar.proto";
+message Main {
+  foo.Bar bar = 1;
+  foo.bar.Baz baz = 2;
+}
+`,
+		},
+		{
+			content: `syntax = "proto3";
+package app;
+import "foo.proto";
+import "foo-bar.proto";
+message Main {
+  foo.bar.Baz baz = 1;
+}
+`,
+			errorstr: "Imported package: foo but not used",
+		},
+		{
+			content: `syntax = "proto3";
+package app;
+import "foo.proto";
+import "foo-bar.proto";
+message Main {
+  foo.Bar bar = 1;
+  foo.bar.Bar baz = 2;
+}
+`,
+			errorstr: "Datatype: 'foo.bar.Bar' referenced in field: 'baz' is not defined",
+		},
+		{
+			content: `syntax = "proto3";
+package foo.bar.app;
+import "foo.proto";
+message Main {
+  foo.Bar bar = 1;
+}
+service Mains {
+  rpc Get (foo.Bar) returns (Main);
+}
+`,
+		},
+	}
+
+	for i, tt := range tests {
+		// parse each case a few times since the packages used to be matched in map order...
+		for n := 0; n < 10; n++ {
+			_, err := pbparser.Parse(strings.NewReader(tt.content), nestedPackageModules)
+			if tt.errorstr == "" && err != nil {
+				t.Errorf("Case: %v, %v", i, err.Error())
+				break
+			}
+			if tt.errorstr != "" && (err == nil || err.Error() != tt.errorstr) {
+				t.Errorf("Case: %v, Expected error: %v, Actual: %v", i, tt.errorstr, err)
+				break
+			}
+		}
+	}
+}
+
+// BenchmarkParseManyImports benchmarks the Parse() API for a file which imports
+// many packages & refers to the types of each of them.
+func BenchmarkParseManyImports(b *testing.B) {
+	b.ReportAllocs()
+	modules := mapImportModuleProvider{}
+	var sb strings.Builder
+	sb.WriteString("syntax = \"proto3\";\npackage app;\n")
+	for i := 0; i < 100; i++ {
+		file := fmt.Sprintf("dep%v.proto", i)
+		modules[file] = fmt.Sprintf("syntax = \"proto3\";\npackage org.dep%v;\nmessage Dep { string id = 1; }\n", i)
+		fmt.Fprintf(&sb, "import %q;\n", file)
+	}
+	sb.WriteString("message Main {\n")
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&sb, "  org.dep%v.Dep dep_%v = %v;\n", i%100, i, i+1)
+	}
+	sb.WriteString("}\n")
+	content := sb.String()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := pbparser.Parse(strings.NewReader(content), modules); err != nil {
+			b.Fatalf("%v", err.Error())
+		}
+	}
+}
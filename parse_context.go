@@ -6,6 +6,7 @@ package pbparser
 type parseCtx struct {
 	obj     interface{}
 	ctxType ctxType
+	ordinal *int // The ordinal of the last declaration read within the message (msgCtx only)
 }
 
 // Type of context
@@ -37,6 +38,17 @@ func (pc parseCtx) String() string {
 	return ctxTypeToStringMap[pc.ctxType]
 }
 
+// returns the ordinal for the next declaration within the message of this ctx.
+func (pc parseCtx) nextOrdinal() int {
+	*pc.ordinal++
+	return *pc.ordinal
+}
+
+// does this ctx permit only field declarations?
+func (pc parseCtx) permitsOnlyFields() bool {
+	return pc.ctxType == extendCtx
+}
+
 // does this ctx permit package support?
 func (pc parseCtx) permitsPackage() bool {
 	return pc.ctxType == fileCtx
@@ -79,7 +91,7 @@ func (pc parseCtx) permitsExtend() bool {
 
 // does this ctx permit reserved keyword support?
 func (pc parseCtx) permitsReserved() bool {
-	return pc.ctxType == msgCtx
+	return pc.ctxType == msgCtx || pc.ctxType == enumCtx
 }
 
 // does this ctx permit rpc support?
@@ -87,6 +99,11 @@ func (pc parseCtx) permitsRPC() bool {
 	return pc.ctxType == serviceCtx
 }
 
+// does this ctx permit service support?
+func (pc parseCtx) permitsService() bool {
+	return pc.ctxType == fileCtx
+}
+
 // does this ctx permit OneOf support?
 func (pc parseCtx) permitsOneOf() bool {
 	return pc.ctxType == msgCtx
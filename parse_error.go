@@ -0,0 +1,34 @@
+package pbparser
+
+import "fmt"
+
+// ParseError is the Error returned when the protobuf content can not be parsed; as
+// opposed to a *ValidationError which is returned when the parsed content fails the
+// post-parsing validation. Msg holds the description of the failure (without the
+// position) and Position holds the line (and the column, if known; else zero) at
+// which the parsing failed. File holds the path given to ParseFile() (if any).
+type ParseError struct {
+	Msg      string
+	Position Position
+	File     string
+	format   string // the layout of Error(); with the msg, line & column as operands
+}
+
+// the layouts of the description returned by Error()...
+const (
+	lineErrFormat   = "%[1]v on line: %[2]v"
+	columnErrFormat = "%[1]v on line: %[2]v, column: %[3]v"
+)
+
+// Error returns the human-readable description of the failure along with its
+// position.
+func (pe *ParseError) Error() string {
+	format := pe.format
+	if format == "" {
+		format = columnErrFormat
+		if pe.Position.Column == 0 {
+			format = lineErrFormat
+		}
+	}
+	return fmt.Sprintf(format, pe.Msg, pe.Position.Line, pe.Position.Column)
+}
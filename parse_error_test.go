@@ -0,0 +1,51 @@
+package pbparser_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tallstoat/pbparser"
+)
+
+func TestParseError(t *testing.T) {
+	var tests = []struct {
+		file     string
+		msg      string
+		position pbparser.Position
+	}{
+		{file: "wrong-hex-tag.proto", msg: "Invalid integer literal: '0x'", position: pbparser.Position{Line: 5, Column: 16}},
+		{file: "required-in-proto3.proto", msg: "Required fields are not allowed in proto3", position: pbparser.Position{Line: 6}},
+		{file: "unterminated-string-option.proto", msg: "Unterminated string literal", position: pbparser.Position{Line: 5, Column: 21}},
+		{file: "unterminated-block-comment.proto", msg: "Unterminated block comment", position: pbparser.Position{Line: 7}},
+		{file: "invalid-escape.proto", msg: "Invalid escape sequence '\\q'", position: pbparser.Position{Line: 5, Column: 35}},
+	}
+
+	for _, tt := range tests {
+		_, err := pbparser.ParseFile(errResourceDir + tt.file)
+		var pe *pbparser.ParseError
+		if !errors.As(err, &pe) {
+			t.Errorf("File: %v, Expected a ParseError, Actual: %v", tt.file, err)
+			continue
+		}
+		if pe.Msg != tt.msg || pe.Position != tt.position || pe.File != errResourceDir+tt.file {
+			t.Errorf("File: %v, Unexpected ParseError: %+v", tt.file, pe)
+		}
+	}
+
+	// the failures of the verification are not ParseErrors...
+	_, err := pbparser.ParseFile(errResourceDir + "missing-msg.proto")
+	var pe *pbparser.ParseError
+	if errors.As(err, &pe) {
+		t.Errorf("Expected a ValidationError, Actual: %+v", pe)
+	}
+
+	// the description of a ParseError built by the client code...
+	pe = &pbparser.ParseError{Msg: "Unexpected token", Position: pbparser.Position{Line: 3, Column: 7}}
+	if pe.Error() != "Unexpected token on line: 3, column: 7" {
+		t.Errorf("Unexpected description: %v", pe.Error())
+	}
+	pe.Position.Column = 0
+	if pe.Error() != "Unexpected token on line: 3" {
+		t.Errorf("Unexpected description: %v", pe.Error())
+	}
+}
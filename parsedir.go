@@ -0,0 +1,161 @@
+package pbparser
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ParseDir parses all the .proto files in the directory tree rooted at the given
+// directory; skipping the hidden directories (and files). The imports are resolved
+// relative to the root directory (as protoc -I root does) and each dependency is
+// parsed only once for the whole tree. Any ParseOption(s) passed in are handed over
+// to the Parse() function; WithIncludes() & WithExcludes() narrowing down the files
+// which are parsed.
+//
+// Once all the files are parsed, a message/enum defined in more than one of them is
+// reported as a *ValidationError (or as a warning if WithDuplicateTypeWarnings() is
+// given) as well.
+//
+// This function returns the parsed ProtoFile(s) keyed by their (slash separated)
+// paths relative to the root directory. If the parsing or validation of a file
+// fails, it returns an Error which identifies the file; along with the files parsed
+// till then. The File of a *ParseError holds the path of the file (relative to the
+// root directory), as does the File of a *ValidationError unless the violation is
+// in one of its imports.
+func ParseDir(root string, opts ...ParseOption) (map[string]ProtoFile, error) {
+	if root == "" {
+		return nil, errors.New("Root directory is mandatory")
+	}
+	o := newParseOptions(opts)
+
+	files, err := findProtoFiles(root, o)
+	if err != nil {
+		return nil, err
+	}
+
+	// share the dependencies parsed across the files; the files to be parsed having
+	// been found already...
+	opts = append(opts[:len(opts):len(opts)], func(o *parseOptions) {
+		o.dependencies = make(map[string]dependency)
+		o.includes, o.excludes = nil, nil
+	})
+	impr := defaultImportModuleProviderImpl{dir: root}
+	l := make(map[string]ProtoFile, len(files))
+	definedIn := make(map[string]string)
+	for _, file := range files {
+		r, err := os.Open(filepath.Join(root, filepath.FromSlash(file)))
+		if err != nil {
+			return l, err
+		}
+		pf, err := Parse(r, &impr, opts...)
+		r.Close()
+		if ve, ok := err.(*ValidationError); ok {
+			if ve.File == "" {
+				ve.File = file
+			}
+			return l, ve
+		} else if pe, ok := err.(*ParseError); ok {
+			pe.File = file
+			return l, pe
+		} else if err != nil {
+			msg := fmt.Sprintf("Unable to parse %v. Reason:: %v", file, err.Error())
+			return l, errors.New(msg)
+		}
+
+		// check that no other file defines the types of the file; the ones merged into
+		// the model from the imports of the same package being left out...
+		var names []string
+		for qname := range typeNames(&pf) {
+			if _, imported := pf.importedTypes[qname]; !imported {
+				names = append(names, qname)
+			}
+		}
+		sort.Strings(names)
+		for _, qname := range names {
+			if other, found := definedIn[qname]; found {
+				if err := reportDuplicateType(&pf, o, qname, file, other); err != nil {
+					return l, err
+				}
+				continue
+			}
+			definedIn[qname] = file
+		}
+		l[file] = pf
+	}
+	return l, nil
+}
+
+// WithIncludes narrows down the files parsed by ParseDir() to the ones which match
+// any of the given glob patterns (as per path.Match). A pattern is matched against
+// both the (slash separated) path of a file relative to the root directory and the
+// name of the file e.g. both "api/*.proto" and "*_service.proto" work. The other
+// apis return an Error if given this option.
+func WithIncludes(patterns ...string) ParseOption {
+	return func(o *parseOptions) {
+		o.includes = append(o.includes, patterns...)
+	}
+}
+
+// WithExcludes leaves out the files which match any of the given glob patterns (as
+// per WithIncludes) from the files parsed by ParseDir(). A pattern which matches
+// the path of a directory leaves out the whole directory. The other apis return
+// an Error if given this option.
+func WithExcludes(patterns ...string) ParseOption {
+	return func(o *parseOptions) {
+		o.excludes = append(o.excludes, patterns...)
+	}
+}
+
+// findProtoFiles returns the (slash separated) paths relative to the given root of
+// the .proto files to be parsed; in lexical order.
+func findProtoFiles(root string, o parseOptions) ([]string, error) {
+	var files []string
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		hidden := strings.HasPrefix(info.Name(), ".")
+		if info.IsDir() {
+			if hidden || matchesAny(o.excludes, rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if hidden || filepath.Ext(rel) != ".proto" || matchesAny(o.excludes, rel) {
+			return nil
+		}
+		if len(o.includes) > 0 && !matchesAny(o.includes, rel) {
+			return nil
+		}
+		files = append(files, rel)
+		return nil
+	})
+	return files, err
+}
+
+// matchesAny returns true if the given relative path (or its last element) matches
+// any of the given glob patterns.
+func matchesAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, path.Base(rel)); ok {
+			return true
+		}
+	}
+	return false
+}
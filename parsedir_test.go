@@ -0,0 +1,124 @@
+package pbparser_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/tallstoat/pbparser"
+)
+
+// writeTree writes the given files (keyed by slash separated paths) under a new
+// temporary directory; which is returned.
+func writeTree(t *testing.T, files map[string]string) string {
+	root, err := ioutil.TempDir("", "pbparser")
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("%v", err.Error())
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatalf("%v", err.Error())
+		}
+	}
+	return root
+}
+
+var dirTree = map[string]string{
+	"shop/item.proto":   "syntax = \"proto3\";\npackage shop;\nmessage Item {\n  string id = 1;\n}\n",
+	"shop/order.proto":  "syntax = \"proto3\";\npackage shop;\nimport \"shop/item.proto\";\nmessage Order {\n  repeated Item items = 1;\n}\n",
+	"api/service.proto": "syntax = \"proto3\";\npackage api;\nimport \"shop/order.proto\";\nservice Orders {\n  rpc Get (shop.Order) returns (shop.Order);\n}\n",
+	".git/junk.proto":   "not a proto file",
+	"vendor/bad.proto":  "not a proto file either",
+	"README.md":         "# protos",
+}
+
+func TestParseDir(t *testing.T) {
+	root := writeTree(t, dirTree)
+	defer os.RemoveAll(root)
+
+	pfs, err := pbparser.ParseDir(root, pbparser.WithExcludes("vendor"))
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	var files []string
+	for file := range pfs {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	expected := []string{"api/service.proto", "shop/item.proto", "shop/order.proto"}
+	if !reflect.DeepEqual(expected, files) {
+		t.Errorf("Expected files: %v, Actual: %v", expected, files)
+	}
+	if pfs["api/service.proto"].Services[0].RPCs[0].RequestType.Name() != "shop.Order" {
+		t.Errorf("Expected the service to be parsed")
+	}
+
+	pfs, err = pbparser.ParseDir(root, pbparser.WithIncludes("shop/*.proto", "service.proto"), pbparser.WithExcludes("order.proto"))
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	if len(pfs) != 2 || pfs["shop/item.proto"].PackageName != "shop" || pfs["api/service.proto"].PackageName != "api" {
+		t.Errorf("Expected the included files to be parsed, Actual: %v", len(pfs))
+	}
+
+	// the apis other than ParseDir() refuse the options which only it supports...
+	errorstr := "WithIncludes() and WithExcludes() are only supported by ParseDir()"
+	for _, opt := range []pbparser.ParseOption{pbparser.WithIncludes("*.proto"), pbparser.WithExcludes("vendor")} {
+		_, err := pbparser.ParseFile(filepath.Join(root, "shop", "item.proto"), opt)
+		if err == nil || err.Error() != errorstr {
+			t.Errorf("Expected error: %v, Actual: %v", errorstr, err)
+		}
+		_, err = pbparser.ParseMessage(strings.NewReader("message Foo {}"), opt)
+		if err == nil || err.Error() != errorstr {
+			t.Errorf("Expected error: %v, Actual: %v", errorstr, err)
+		}
+	}
+}
+
+func TestParseDirErrors(t *testing.T) {
+	root := writeTree(t, dirTree)
+	defer os.RemoveAll(root)
+
+	_, err := pbparser.ParseDir(root)
+	var pe *pbparser.ParseError
+	if !errors.As(err, &pe) || pe.File != "vendor/bad.proto" {
+		t.Fatalf("Expected a ParseError for vendor/bad.proto, Actual: %v", err)
+	}
+	expected := "Unexpected 'not' in context: file on line: 1"
+	if pe.Error() != expected || pe.Position.Line != 1 {
+		t.Errorf("Expected error: %v, Actual: %v", expected, pe.Error())
+	}
+
+	// a type defined in two of the files...
+	dup := writeTree(t, map[string]string{
+		"a.proto": "syntax = \"proto3\";\npackage shop;\nmessage Item {\n  string id = 1;\n}\n",
+		"b.proto": "syntax = \"proto3\";\npackage shop;\nmessage Item {\n  string id = 1;\n}\n",
+	})
+	defer os.RemoveAll(dup)
+
+	_, err = pbparser.ParseDir(dup)
+	ve, ok := err.(*pbparser.ValidationError)
+	if !ok || ve.Code != pbparser.DuplicateTypeCode || ve.File != "b.proto" {
+		t.Fatalf("Expected a DuplicateTypeCode error for b.proto, Actual: %v", err)
+	}
+	if ve.Error() != "Type shop.Item is defined in both a.proto and b.proto" {
+		t.Errorf("Unexpected error: %v", ve.Error())
+	}
+
+	pfs, err := pbparser.ParseDir(dup, pbparser.WithDuplicateTypeWarnings())
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	if len(pfs["b.proto"].Warnings) != 1 {
+		t.Errorf("Expected the duplicate type to be reported as a warning, Actual: %v", pfs["b.proto"].Warnings)
+	}
+}
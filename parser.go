@@ -6,9 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
+	"os"
 	"path/filepath"
-	"regexp"
 	"strconv"
 	"strings"
 )
@@ -18,22 +17,30 @@ import (
 // code for any imports in the protobuf content. If there are no imports, the client
 // can choose to pass this as nil.
 //
+// Any ParseOption(s) passed in tweak the default behavior of the parser.
+//
 // This function returns populated ProtoFile struct if parsing is successful.
-// If the parsing or validation fails, it returns an Error.
-func Parse(r io.Reader, p ImportModuleProvider) (ProtoFile, error) {
+// If the parsing or validation fails, it returns an Error; a *ParseError in case
+// of the former and a *ValidationError in case of the latter (unless raised by a
+// custom VerifyRule).
+func Parse(r io.Reader, p ImportModuleProvider, opts ...ParseOption) (ProtoFile, error) {
 	if r == nil {
 		return ProtoFile{}, errors.New("Reader for protobuf content is mandatory")
 	}
 
 	pf := ProtoFile{}
+	o := newParseOptions(opts)
+	if err := o.checkFileOptions(); err != nil {
+		return pf, err
+	}
 
 	// parse the main proto file...
-	if err := parse(r, &pf); err != nil {
+	if err := parse(r, &pf, o); err != nil {
 		return pf, err
 	}
 
 	// verify via extra checks...
-	if err := verify(&pf, p); err != nil {
+	if err := verify(&pf, p, o); err != nil {
 		return pf, err
 	}
 
@@ -43,37 +50,43 @@ func Parse(r io.Reader, p ImportModuleProvider) (ProtoFile, error) {
 // ParseFile function reads and parses the content of the protobuf file whose
 // path is provided as sole argument to the function. If there are any imports
 // in the protobuf file, the parser will look for them in the same directory
-// where the protobuf file resides.
+// where the protobuf file resides. Any ParseOption(s) passed in are handed
+// over to the Parse() function.
 //
 // This function returns populated ProtoFile struct if parsing is successful.
 // If the parsing or validation fails, it returns an Error.
-func ParseFile(file string) (ProtoFile, error) {
+func ParseFile(file string, opts ...ParseOption) (ProtoFile, error) {
 	if file == "" {
 		return ProtoFile{}, errors.New("File is mandatory")
 	}
 
-	// read the proto file contents & create a reader...
-	raw, err := ioutil.ReadFile(file)
+	// open the proto file; its contents are streamed to the parser...
+	r, err := os.Open(file)
 	if err != nil {
 		return ProtoFile{}, err
 	}
-	r := strings.NewReader(string(raw[:]))
+	defer r.Close()
 
 	// create default import module provider...
 	dir := filepath.Dir(file)
 	impr := defaultImportModuleProviderImpl{dir: dir}
 
-	return Parse(r, &impr)
+	pf, err := Parse(r, &impr, opts...)
+	if ve, ok := err.(*ValidationError); ok && ve.File == "" {
+		ve.File = file
+	} else if pe, ok := err.(*ParseError); ok {
+		pe.File = file
+	}
+	return pf, err
 }
 
 // parse is an internal function which is invoked with the reader for the main proto file
 // & a pointer to the ProtoFile struct to be populated post parsing & verification.
-func parse(r io.Reader, pf *ProtoFile) error {
-	br := bufio.NewReader(r)
-
+func parse(r io.Reader, pf *ProtoFile, opts parseOptions) error {
 	// initialize parser...
-	loc := location{line: 1, column: 0}
-	parser := parser{br: br, loc: &loc}
+	parser := newParser(r, opts)
+	defer parser.release()
+	parser.skipBOM()
 
 	// parse the file contents...
 	return parser.parse(pf)
@@ -93,6 +106,14 @@ type parser struct {
 	eofReached     bool   // We set this flag, when eof is encountered
 	prefix         string // The current package name + nested type names, separated by dots
 	lastColumnRead int
+	opts           parseOptions
+	pendingDoc     *Documentation   // Documentation already read for the next declaration
+	pendingLines   lineRange        // The lines spanned by the leading comment of the pending documentation
+	leadingLines   lineRange        // The lines spanned by the leading comment of the current declaration
+	trailingLines  lineRange        // The lines spanned by the last trailing comment read
+	declared       bool             // We set this flag, once any statement has been read at the file level
+	buf            bytes.Buffer     // The scratch buffer for reading words & numbers
+	groups         []MessageElement // The messages of the groups read within the current extend declaration(s)
 }
 
 // This function just looks for documentation and
@@ -126,30 +147,140 @@ func (p *parser) parse(pf *ProtoFile) error {
 	return nil
 }
 
-func (p *parser) readDocumentationIfFound() (string, error) {
-	for {
-		c := p.read()
-		if c == eof {
-			p.eofReached = true
+// readDocumentationIfFound returns the documentation for the next declaration. This
+// may have already been read while looking for the trailing comment of the previous
+// declaration; if not, it is read now.
+func (p *parser) readDocumentationIfFound() (Documentation, error) {
+	if p.pendingDoc == nil {
+		cc := commentCollector{}
+		if err := p.readComments(&cc); err != nil {
+			return Documentation{}, err
+		}
+	}
+	doc := *p.pendingDoc
+	p.pendingDoc = nil
+	p.leadingLines = p.pendingLines
+	return doc, nil
+}
+
+// readTrailingComment is invoked right after the token which ends a declaration
+// (typically ';' or '{') and returns the comment trailing the declaration, if any.
+// The comments following it (upto the next token) are read as well and held on to
+// as the documentation of the next declaration.
+func (p *parser) readTrailingComment() (string, error) {
+	p.pendingDoc = nil
+	cc := commentCollector{canAttachToPrev: true}
+
+	// a comment appearing on the same line must be attached to the previous declaration...
+	p.skipWhitespaceOnLine()
+	switch p.peekComment() {
+	case lineComment:
+		line := p.loc.line
+		cc.addLineComment(p.readLineComment(), line)
+		cc.flush()
+	case blockComment:
+		start := p.loc.line
+		s, err := p.readBlockComment()
+		if err != nil {
+			return "", err
+		}
+		cc.addBlockComment(s, start, p.loc.line)
+		p.skipWhitespaceOnLine()
+		if c := p.read(); c != '\n' {
+			// the next token is on the same line; so it is unclear whom the comment belongs to...
+			p.unread()
 			return "", nil
-		} else if isWhitespace(c) {
-			p.skipWhitespace()
+		}
+		cc.flush()
+	default:
+		if c := p.read(); c != '\n' {
+			// the next token is on the same line; so there are no comments...
+			p.unread()
+			return "", nil
+		}
+	}
+
+	// we are now on the line after the previous declaration...
+	if err := p.readComments(&cc); err != nil {
+		return "", err
+	}
+	p.trailingLines = cc.trailingLines
+	return cc.trailing, nil
+}
+
+// readTrailingDoc reads the comment trailing the declaration of the given element
+// into its documentation. The lines spanned by the leading & trailing comments of
+// the element are recorded as owned by it.
+func (p *parser) readTrailingDoc(pf *ProtoFile, doc *Documentation, owner string) error {
+	if doc.Leading != "" {
+		pf.commentOwners = append(pf.commentOwners, commentOwner{lines: p.leadingLines, owner: owner})
+	}
+	trailing, err := p.readTrailingComment()
+	if err != nil {
+		return err
+	}
+	doc.Trailing = trailing
+	if trailing != "" {
+		pf.commentOwners = append(pf.commentOwners, commentOwner{lines: p.trailingLines, owner: owner})
+	}
+	return nil
+}
+
+// readComments reads all the comments, line by line, upto the next token and
+// holds on to the documentation of the next declaration.
+func (p *parser) readComments(cc *commentCollector) error {
+	for {
+		p.skipWhitespaceOnLine()
+		kind := p.peekComment()
+		if kind == lineComment {
+			line := p.loc.line
+			cc.addLineComment(p.readLineComment(), line)
 			continue
-		} else if isStartOfComment(c) {
-			documentation, err := p.readDocumentation()
+		} else if kind == blockComment {
+			start := p.loc.line
+			s, err := p.readBlockComment()
 			if err != nil {
-				return "", err
+				return err
+			}
+			cc.addBlockComment(s, start, p.loc.line)
+			// consume the rest of the line so that it is not seen as a blank line...
+			p.skipWhitespaceOnLine()
+			if c := p.read(); c != '\n' {
+				p.unread()
 			}
-			return documentation, nil
+			continue
+		}
+
+		c := p.read()
+		if c == '\n' {
+			// a completely blank line...
+			cc.flush()
+			cc.detachFromPrev()
+			continue
+		}
+		if c == eof {
+			p.eofReached = true
+			cc.flush()
+			break
+		}
+		if isStartOfComment(c) {
+			c2 := p.read()
+			return p.errline("Expected '/' or '*', but found: %v", strconv.QuoteRune(c2))
 		}
-		// this is not documentation, break out of the loop...
 		p.unread()
+		if c == '}' {
+			// we are at the end of a scope; no point attaching a comment to the '}'...
+			cc.flush()
+		}
 		break
 	}
-	return "", nil
+	doc := cc.documentation()
+	p.pendingDoc = &doc
+	p.pendingLines = cc.lines
+	return nil
 }
 
-func (p *parser) readDeclaration(pf *ProtoFile, documentation string, ctx parseCtx) error {
+func (p *parser) readDeclaration(pf *ProtoFile, documentation Documentation, ctx parseCtx) error {
 	// Skip unnecessary semicolons...
 	c := p.read()
 	if c == ';' {
@@ -158,18 +289,43 @@ func (p *parser) readDeclaration(pf *ProtoFile, documentation string, ctx parseC
 	p.unread()
 
 	// Read next label...
+	pos := p.position()
 	label := p.readWord()
+	if ctx.ctxType == msgCtx || ctx.ctxType == extendCtx || ctx.ctxType == oneOfCtx {
+		// a field may well be of a type named after a keyword; as in 'service service = 1;'...
+		if declarationKeywords[label] && label != "option" && p.isStartOfKeywordTypedField() {
+			return p.readField(pf, label, documentation, ctx)
+		}
+	}
+	if ctx.permitsOnlyFields() && declarationKeywords[label] {
+		return p.errline("'%v' is not allowed inside %v", label, ctx)
+	}
+	if ctx.ctxType == fileCtx && label != "syntax" {
+		p.declared = true
+	}
+	if ctx.ctxType == enumCtx && declarationKeywords[label] {
+		// a constant may well be named after a keyword; as in 'option = 1;'...
+		if p.isStartOfEnumConstant() {
+			return p.readEnumConstant(pf, label, documentation, ctx)
+		}
+		if label == "message" || label == "enum" || label == "oneof" || label == "extend" || label == "service" {
+			return p.errline("'%v' declarations are not allowed inside enum %v", label, ctx.obj.(*EnumElement).Name)
+		}
+	}
+
 	if label == "package" {
 		if !ctx.permitsPackage() {
 			return p.unexpected(label, ctx)
 		}
-		p.skipWhitespace()
-		pf.PackageName = p.readWord()
-		p.prefix = pf.PackageName + "."
+		return p.readPackage(pf)
 	} else if label == "syntax" {
 		if !ctx.permitsSyntax() {
 			return p.unexpected(label, ctx)
 		}
+		if p.declared && !p.opts.lateSyntax {
+			return p.errline("The syntax statement must be the first statement in the file")
+		}
+		p.declared = true
 		return p.readSyntax(pf)
 	} else if label == "import" {
 		if !ctx.permitsImport() {
@@ -197,6 +353,9 @@ func (p *parser) readDeclaration(pf *ProtoFile, documentation string, ctx parseC
 		}
 		return p.readExtend(pf, documentation, ctx)
 	} else if label == "service" {
+		if !ctx.permitsService() {
+			return p.unexpected(label, ctx)
+		}
 		return p.readService(pf, documentation)
 	} else if label == "rpc" {
 		if !ctx.permitsRPC() {
@@ -223,58 +382,229 @@ func (p *parser) readDeclaration(pf *ProtoFile, documentation string, ctx parseC
 		if !ctx.permitsField() {
 			return p.errline("fields must be nested")
 		}
+		if p.opts.lenient && ctx.ctxType == msgCtx && !p.isStartOfField(label) {
+			return p.readRawDeclaration(pf, label, pos, documentation, ctx)
+		}
 		return p.readField(pf, label, documentation, ctx)
 	} else if ctx.ctxType == enumCtx {
 		return p.readEnumConstant(pf, label, documentation, ctx)
+	} else if p.opts.lenient && ctx.ctxType == fileCtx {
+		return p.readRawDeclaration(pf, label, pos, documentation, ctx)
 	} else if label != "" {
 		return p.unexpected(label, ctx)
+	} else if c := p.read(); c != eof {
+		// a character which can not start any declaration...
+		return p.errcol("Unexpected %v in context: %v", quoteRune(c), ctx)
 	}
 	return nil
 }
 
 func (p *parser) readDeclarationsInLoop(pf *ProtoFile, ctx parseCtx) error {
 	for {
-		doc, err := p.readDocumentationIfFound()
-		if err != nil {
+		done, err := p.readNextDeclaration(pf, ctx)
+		if err != nil || done {
 			return err
 		}
-		p.skipWhitespace()
-		if p.eofReached {
-			return fmt.Errorf("Reached end of input in %v definition (missing '}')", ctx)
+	}
+}
+
+// readNextDeclaration reads the next declaration within the given scope. It returns
+// true if the '}' ending the scope was read instead.
+func (p *parser) readNextDeclaration(pf *ProtoFile, ctx parseCtx) (bool, error) {
+	doc, err := p.readDocumentationIfFound()
+	if err != nil {
+		return false, err
+	}
+	p.skipWhitespace()
+	if p.eofReached {
+		msg := fmt.Sprintf("Reached end of input in %v definition (missing '}')", ctx)
+		return false, &ParseError{Msg: msg, Position: Position{Line: p.loc.line}, format: "%[1]v"}
+	}
+	if c := p.read(); c == '}' {
+		// any comment trailing the '}' is not attached to anything...
+		_, err = p.readTrailingComment()
+		return true, err
+	}
+	p.unread()
+
+	return false, p.readDeclaration(pf, doc, ctx)
+}
+
+// isStartOfEnumConstant peeks ahead (without consuming anything) to figure out
+// whether the label just read is the name of an enum constant i.e. it is followed
+// by '='.
+func (p *parser) isStartOfEnumConstant() bool {
+	for n := 1; ; n++ {
+		b, err := p.br.Peek(n)
+		if err != nil {
+			return false
 		}
-		if c := p.read(); c == '}' {
-			break
+		if c := rune(b[n-1]); !isWhitespace(c) {
+			return c == '='
 		}
-		p.unread()
+	}
+}
 
-		if err = p.readDeclaration(pf, doc, ctx); err != nil {
-			return err
+// isStartOfMapType peeks ahead (without consuming anything) to figure out whether
+// the 'map' just read is followed by the '<' of a map type.
+func (p *parser) isStartOfMapType() bool {
+	b, err := p.br.Peek(1)
+	return err == nil && b[0] == '<'
+}
+
+// isStartOfKeywordTypedField peeks ahead (without consuming anything) to figure out
+// whether the keyword just read is rather the type of a field i.e. the keyword is
+// followed by a name and then by an '='.
+func (p *parser) isStartOfKeywordTypedField() bool {
+	seenName, nameEnded := false, false
+	for n := 1; ; n++ {
+		b, err := p.br.Peek(n)
+		if err != nil {
+			return false
+		}
+		c := rune(b[n-1])
+		switch {
+		case isWhitespace(c):
+			nameEnded = seenName
+		case !nameEnded && (isLetter(c) || c == '_' || (seenName && isDigit(c))):
+			seenName = true
+		default:
+			return seenName && c == '='
+		}
+	}
+}
+
+// isStartOfField peeks ahead (without consuming anything) to figure out whether
+// the declaration starting with the given label looks like a field i.e. the label
+// is followed by a name.
+func (p *parser) isStartOfField(label string) bool {
+	if label == "" {
+		return false
+	}
+	for n := 1; ; n++ {
+		b, err := p.br.Peek(n)
+		if err != nil {
+			return false
+		}
+		c := rune(b[n-1])
+		if n == 1 && label == "map" && c == '<' {
+			return true
+		}
+		if !isWhitespace(c) {
+			return isLetter(c) || c == '_' || c == '.'
+		}
+	}
+}
+
+// readRawDeclaration consumes the rest of a declaration which the parser does not
+// understand and captures it verbatim. Any braces in the declaration are balanced
+// and quoted strings are skipped over, so that the parsing can resume after it.
+func (p *parser) readRawDeclaration(pf *ProtoFile, label string, pos Position, documentation Documentation, ctx parseCtx) error {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString(label)
+
+	depth := 0
+	for {
+		c := p.read()
+		if c == eof {
+			p.eofReached = true
+			if strings.TrimSpace(buf.String()) == "" {
+				return nil
+			}
+			return p.errline("Reached end of input in unrecognized declaration starting on line: %v", pos.Line)
+		}
+		if c == '}' && depth == 0 {
+			// the enclosing block ends here; leave the '}' for it...
+			p.unread()
+			break
 		}
+		_, _ = buf.WriteRune(c)
+		if c == '"' || c == '\'' {
+			for {
+				c2 := p.read()
+				if c2 == eof {
+					p.eofReached = true
+					return p.errline("Reached end of input in unrecognized declaration starting on line: %v", pos.Line)
+				}
+				_, _ = buf.WriteRune(c2)
+				if c2 == '\\' {
+					_, _ = buf.WriteRune(p.read())
+				} else if c2 == c {
+					break
+				}
+			}
+		} else if c == '{' {
+			depth++
+		} else if c == '}' {
+			depth--
+			if depth == 0 {
+				break
+			}
+		} else if c == ';' && depth == 0 {
+			break
+		}
+	}
+
+	rd := RawDeclaration{Documentation: documentation, Text: strings.TrimSpace(buf.String()), Position: pos}
+
+	// add raw declaration to the proper parent...
+	if ctx.ctxType == msgCtx {
+		me := ctx.obj.(*MessageElement)
+		me.RawDeclarations = append(me.RawDeclarations, rd)
+	} else {
+		pf.RawDeclarations = append(pf.RawDeclarations, rd)
 	}
 	return nil
 }
 
-func (p *parser) readReserved(pf *ProtoFile, documentation string, ctx parseCtx) error {
-	me := ctx.obj.(*MessageElement)
+func (p *parser) readReserved(pf *ProtoFile, documentation Documentation, ctx parseCtx) error {
+	// the reservations are made within either a message or an enum...
+	var ranges *[]ReservedRangeElement
+	var names *[]string
+	max := maxFieldNumber
+	if ctx.ctxType == enumCtx {
+		ee := ctx.obj.(*EnumElement)
+		ranges, names, max = &ee.ReservedRanges, &ee.ReservedNames, maxEnumValue
+	} else {
+		me := ctx.obj.(*MessageElement)
+		ranges, names = &me.ReservedRanges, &me.ReservedNames
+	}
 
 	p.skipWhitespace()
 	c := p.read()
 	p.unread()
 
+	n := len(*ranges)
 	if isDigit(c) {
-		if err := p.readReservedRanges(documentation, me); err != nil {
+		if err := p.readReservedRanges(documentation, ranges, max); err != nil {
 			return err
 		}
 	} else {
-		if err := p.readReservedNames(documentation, me); err != nil {
+		if err := p.readReservedNames(documentation, names); err != nil {
 			return err
 		}
 	}
+
+	// the trailing comment applies to all the ranges in the statement...
+	trailing, err := p.readTrailingComment()
+	if err != nil {
+		return err
+	}
+	for i := n; i < len(*ranges); i++ {
+		(*ranges)[i].Documentation.Trailing = trailing
+	}
 	return nil
 }
 
-func (p *parser) readReservedRanges(documentation string, me *MessageElement) error {
+// readReservedRanges reads the comma separated ranges of a reserved statement; an
+// upper bound of 'max' standing for the given max value.
+func (p *parser) readReservedRanges(documentation Documentation, ranges *[]ReservedRangeElement, max int) error {
 	for {
+		if c := p.read(); c == '"' {
+			return p.errline(reservedMixErr)
+		}
+		p.unread()
+
 		start, err := p.readInt()
 		if err != nil {
 			return err
@@ -285,10 +615,10 @@ func (p *parser) readReservedRanges(documentation string, me *MessageElement) er
 		// check if we are done providing the reserved names
 		c := p.read()
 		if c == ';' {
-			me.ReservedRanges = append(me.ReservedRanges, rr)
+			*ranges = append(*ranges, rr)
 			break
 		} else if c == ',' {
-			me.ReservedRanges = append(me.ReservedRanges, rr)
+			*ranges = append(*ranges, rr)
 			p.skipWhitespace()
 		} else {
 			p.unread()
@@ -297,17 +627,26 @@ func (p *parser) readReservedRanges(documentation string, me *MessageElement) er
 				return p.errline("Expected 'to', but found: %v", w)
 			}
 			p.skipWhitespace()
-			end, err := p.readInt()
-			if err != nil {
-				return err
+			if c := p.read(); c == 'm' {
+				p.unread()
+				if w := p.readWord(); w != "max" {
+					return p.errcol("Invalid integer literal: '%v'", w)
+				}
+				rr.End = max
+			} else {
+				p.unread()
+				end, err := p.readInt()
+				if err != nil {
+					return err
+				}
+				rr.End = end
 			}
-			rr.End = end
 			c2 := p.read()
 			if c2 == ';' {
-				me.ReservedRanges = append(me.ReservedRanges, rr)
+				*ranges = append(*ranges, rr)
 				break
 			} else if c2 == ',' {
-				me.ReservedRanges = append(me.ReservedRanges, rr)
+				*ranges = append(*ranges, rr)
 				p.skipWhitespace()
 			} else {
 				return p.errline("Expected ',' or ';', but found: %v", strconv.QuoteRune(c2))
@@ -317,13 +656,25 @@ func (p *parser) readReservedRanges(documentation string, me *MessageElement) er
 	return nil
 }
 
-func (p *parser) readReservedNames(documentation string, me *MessageElement) error {
+func (p *parser) readReservedNames(documentation Documentation, names *[]string) error {
 	for {
-		name, err := p.readQuotedString(nil)
+		if c := p.read(); isDigit(c) {
+			return p.errline(reservedMixErr)
+		}
+		p.unread()
+
+		pos := p.position()
+		if c := p.read(); c != '"' {
+			return p.throw('"', c)
+		}
+		name, err := p.readStringLiteral(pos)
 		if err != nil {
 			return err
 		}
-		me.ReservedNames = append(me.ReservedNames, name)
+		if !isIdentifier(name) {
+			return p.errline("Reserved name '%v' is not a valid identifier", name)
+		}
+		*names = append(*names, name)
 
 		// check if we are done providing the reserved names
 		c := p.read()
@@ -340,13 +691,11 @@ func (p *parser) readReservedNames(documentation string, me *MessageElement) err
 	return nil
 }
 
-func (p *parser) readField(pf *ProtoFile, label string, documentation string, ctx parseCtx) error {
-	if label == optional && pf.Syntax == proto3 {
-		return p.errline("Explicit 'optional' labels are disallowed in the proto3 syntax. " +
-			"To define 'optional' fields in proto3, simply remove the 'optional' label, as fields " +
-			"are 'optional' by default.")
-	} else if label == required && pf.Syntax == proto3 {
+func (p *parser) readField(pf *ProtoFile, label string, documentation Documentation, ctx parseCtx) error {
+	if label == required && pf.IsProto3() {
 		return p.errline("Required fields are not allowed in proto3")
+	} else if label == optional && pf.IsProto3() && p.opts.strictProto3Labels {
+		return p.errline("Explicit 'optional' labels are disallowed in the proto3 syntax")
 	} else if label == required && ctx.ctxType == extendCtx {
 		return p.errline("Message extensions cannot have required fields")
 	}
@@ -362,17 +711,22 @@ func (p *parser) readField(pf *ProtoFile, label string, documentation string, ct
 			return p.errline("Label '%v' is disallowed in oneoff field", label)
 		}
 		fe.Label = label
+		fe.Proto3Optional = label == optional && pf.IsProto3()
 		p.skipWhitespace()
 		dataTypeStr = p.readWord()
 	}
 
+	if dataTypeStr == "group" {
+		return p.readGroup(pf, fe, ctx)
+	}
+
 	// figure out the dataType
 	if fe.Type, err = p.readDataTypeInternal(dataTypeStr); err != nil {
 		return err
 	}
 
 	// perform checks for map data type...
-	if fe.Type.Category() == MapDataTypeCategory {
+	if fe.IsMap() {
 		if fe.Label == repeated || fe.Label == required || fe.Label == optional {
 			return p.errline("Label %v is not allowed on map fields", fe.Label)
 		}
@@ -383,10 +737,10 @@ func (p *parser) readField(pf *ProtoFile, label string, documentation string, ct
 			return p.errline("Map fields are not allowed to be extensions")
 		}
 		mdt := fe.Type.(MapDataType)
-		if mdt.keyType.Name() == "float" || mdt.keyType.Name() == "double" || mdt.keyType.Name() == "bytes" {
+		if kt := mdt.KeyType().Name(); kt == "float" || kt == "double" || kt == "bytes" {
 			return p.errline("Key in map fields cannot be float, double or bytes")
 		}
-		if mdt.keyType.Category() == NamedDataTypeCategory {
+		if mdt.KeyType().Category() == NamedDataTypeCategory {
 			return p.errline("Key in map fields cannot be a named type")
 		}
 	}
@@ -413,19 +767,92 @@ func (p *parser) readField(pf *ProtoFile, label string, documentation string, ct
 	if fe.Options, err = p.readListOptionsOnALine(); err != nil {
 		return err
 	}
+	if err = p.readTrailingDoc(pf, &fe.Documentation, p.prefix+fe.Name); err != nil {
+		return err
+	}
+
+	p.addField(fe, ctx)
+	return nil
+}
+
+// readGroup reads a (proto2) group; the given field holding its label and docs. The
+// body of the group is added as a message to the enclosing message (or to the scope
+// of the enclosing extend declaration) and the field of that message type is added
+// to the enclosing message or extend declaration.
+func (p *parser) readGroup(pf *ProtoFile, fe FieldElement, ctx parseCtx) error {
+	if pf.IsProto3() {
+		return p.errline("Groups are not allowed in proto3")
+	}
+	if ctx.ctxType == oneOfCtx {
+		return p.errline("Groups are not supported in oneofs")
+	}
+	if fe.Label == "" {
+		return p.errline("Groups must be declared with a label")
+	}
+
+	p.skipWhitespace()
+	name, _, err := p.readName()
+	if err != nil {
+		return err
+	}
+	if name == "" || name[0] < 'A' || name[0] > 'Z' {
+		return p.errline("Group names must start with a capital letter. Found: %v", name)
+	}
+	fe.Name = p.intern(strings.ToLower(name))
+	fe.Group = true
+	if fe.Type, err = p.readDataTypeInternal(name); err != nil {
+		return err
+	}
+
+	p.skipWhitespace()
+	if c := p.read(); c != '=' {
+		return p.throw('=', c)
+	}
+	p.skipWhitespace()
+	if fe.Tag, err = p.readInt(); err != nil {
+		return err
+	}
+	p.skipWhitespace()
+	if c := p.read(); c == '[' {
+		if fe.Options, err = p.readListOptions(); err != nil {
+			return err
+		}
+		p.skipWhitespace()
+	} else {
+		p.unread()
+	}
+
+	me := MessageElement{Name: name, QualifiedName: p.intern(p.prefix + name)}
+	if err = p.readMessageBody(pf, &me); err != nil {
+		return err
+	}
+
+	p.addField(fe, ctx)
+	if ctx.ctxType == msgCtx {
+		parent := ctx.obj.(*MessageElement)
+		me.Ordinal = ctx.nextOrdinal()
+		parent.Messages = append(parent.Messages, me)
+	} else {
+		// the extend declaration adds it to its own parent once read...
+		p.groups = append(p.groups, me)
+	}
+	return nil
+}
 
-	// add field to the proper parent	...
+// addField adds the given field to the proper parent.
+func (p *parser) addField(fe FieldElement, ctx parseCtx) {
 	if ctx.ctxType == msgCtx {
 		me := ctx.obj.(*MessageElement)
+		fe.Ordinal = ctx.nextOrdinal()
 		me.Fields = append(me.Fields, fe)
 	} else if ctx.ctxType == extendCtx {
 		ee := ctx.obj.(*ExtendElement)
 		ee.Fields = append(ee.Fields, fe)
 	} else if ctx.ctxType == oneOfCtx {
 		oe := ctx.obj.(*OneOfElement)
+		fe.OneOf = oe.Name
 		oe.Fields = append(oe.Fields, fe)
 	}
-	return nil
 }
 
 // readListOptionsOnALine reads list options provided on a line.
@@ -439,6 +866,7 @@ func (p *parser) readListOptionsOnALine() ([]OptionElement, error) {
 		if options, err = p.readListOptions(); err != nil {
 			return nil, err
 		}
+		p.skipWhitespace()
 		c2 := p.read()
 		if c2 != ';' {
 			return nil, p.throw(';', c2)
@@ -446,29 +874,37 @@ func (p *parser) readListOptionsOnALine() ([]OptionElement, error) {
 	} else if c != ';' {
 		return nil, p.throw(';', c)
 	}
-	// Gobble up any inline documentation for a field
-	p.skipUntilNewline()
 	return options, nil
 }
 
+// readListOptions reads the options specified within brackets. It expects the
+// opening '[' to have been read already and reads up to (and including) the closing ']'.
 func (p *parser) readListOptions() ([]OptionElement, error) {
 	var options []OptionElement
-	optionsStr := p.readUntil(']')
-	pairs := strings.Split(optionsStr, ",")
-	for _, pair := range pairs {
-		arr := strings.Split(pair, "=")
-		if len(arr) != 2 {
-			return nil, p.errline("Option '%v' is not specified as expected", arr)
-		}
-		oname, hasParenthesis := stripParenthesis(strings.TrimSpace(arr[0]))
-		oval := stripQuotes(strings.TrimSpace(arr[1]))
-		oe := OptionElement{Name: oname, Value: oval, IsParenthesized: hasParenthesis}
+	optionsStr, err := p.readUntilUnnested(']')
+	if err != nil {
+		return nil, err
+	}
+	for _, pair := range splitUnnested(optionsStr, ',') {
+		i := indexUnnested(pair, '=')
+		if i < 0 {
+			return nil, p.errline("Option '%v' is not specified as expected", strings.TrimSpace(pair))
+		}
+		oname, fieldPath := splitOptionName(strings.TrimSpace(pair[:i]))
+		oname, hasParenthesis := stripParenthesis(oname)
+		oval, quoted := stripQuotes(strings.TrimSpace(pair[i+1:]))
+		if quoted {
+			if oval, err = unescapeString(oval); err != nil {
+				return nil, p.errline("%v in option '%v'", err.Error(), strings.TrimSpace(pair[:i]))
+			}
+		}
+		oe := OptionElement{Name: oname, Value: oval, IsParenthesized: hasParenthesis, FieldPath: fieldPath}
 		options = append(options, oe)
 	}
 	return options, nil
 }
 
-func (p *parser) readOption(pf *ProtoFile, documentation string, ctx parseCtx) error {
+func (p *parser) readOption(pf *ProtoFile, documentation Documentation, ctx parseCtx) error {
 	var err error
 	var enc enclosure
 	oe := OptionElement{}
@@ -478,6 +914,11 @@ func (p *parser) readOption(pf *ProtoFile, documentation string, ctx parseCtx) e
 		return err
 	}
 	oe.IsParenthesized = (enc == parenthesis)
+	if oe.IsParenthesized {
+		if oe.FieldPath, err = p.readOptionFieldPath(); err != nil {
+			return err
+		}
+	}
 
 	p.skipWhitespace()
 	if c := p.read(); c != '=' {
@@ -485,8 +926,22 @@ func (p *parser) readOption(pf *ProtoFile, documentation string, ctx parseCtx) e
 	}
 	p.skipWhitespace()
 
-	if p.read() == '"' {
-		oe.Value = p.readUntil('"')
+	// the value can be a string, a list, an aggregate or a word (identifier/number)...
+	pos := p.position()
+	if c := p.read(); c == '"' {
+		if oe.Value, err = p.readStringLiteral(pos); err != nil {
+			return err
+		}
+	} else if c == '[' || c == '{' {
+		closer := ']'
+		if c == '{' {
+			closer = '}'
+		}
+		s, err := p.readUntilUnnested(closer)
+		if err != nil {
+			return err
+		}
+		oe.Value = string(c) + s + string(closer)
 	} else {
 		p.unread()
 		oe.Value = p.readWord()
@@ -516,23 +971,20 @@ func (p *parser) readOption(pf *ProtoFile, documentation string, ctx parseCtx) e
 	} else if ctx.ctxType == fileCtx {
 		pf.Options = append(pf.Options, oe)
 	}
-	return nil
-}
-
-func (p *parser) readMessage(pf *ProtoFile, documentation string, ctx parseCtx) error {
-	p.skipWhitespace()
-	name, _, err := p.readName()
-	if err != nil {
-		return err
-	}
 
-	me := MessageElement{Name: name, QualifiedName: p.prefix + name, Documentation: documentation}
+	// any comment trailing the declaration is not attached to anything...
+	_, err = p.readTrailingComment()
+	return err
+}
 
+// readMessageBody reads the declarations of the given message (or group) from its
+// opening '{' up to (and including) its closing '}'.
+func (p *parser) readMessageBody(pf *ProtoFile, me *MessageElement) error {
 	// store previous prefix...
 	var previousPrefix = p.prefix
 
 	// update prefix...
-	p.prefix = p.prefix + name + "."
+	p.prefix = p.prefix + me.Name + "."
 
 	// reset prefix when we are done processing all fields in the message...
 	defer func() {
@@ -543,15 +995,31 @@ func (p *parser) readMessage(pf *ProtoFile, documentation string, ctx parseCtx)
 	if c := p.read(); c != '{' {
 		return p.throw('{', c)
 	}
+	if err := p.readTrailingDoc(pf, &me.Documentation, me.QualifiedName); err != nil {
+		return err
+	}
 
-	innerCtx := parseCtx{ctxType: msgCtx, obj: &me}
-	if err = p.readDeclarationsInLoop(pf, innerCtx); err != nil {
+	ordinal := 0
+	innerCtx := parseCtx{ctxType: msgCtx, obj: me, ordinal: &ordinal}
+	return p.readDeclarationsInLoop(pf, innerCtx)
+}
+
+func (p *parser) readMessage(pf *ProtoFile, documentation Documentation, ctx parseCtx) error {
+	p.skipWhitespace()
+	name, _, err := p.readName()
+	if err != nil {
+		return err
+	}
+
+	me := MessageElement{Name: name, QualifiedName: p.intern(p.prefix + name), Documentation: documentation}
+	if err = p.readMessageBody(pf, &me); err != nil {
 		return err
 	}
 
 	// add msg to the proper parent...
 	if ctx.ctxType == msgCtx {
 		parent := ctx.obj.(*MessageElement)
+		me.Ordinal = ctx.nextOrdinal()
 		parent.Messages = append(parent.Messages, me)
 	} else {
 		pf.Messages = append(pf.Messages, me)
@@ -559,47 +1027,70 @@ func (p *parser) readMessage(pf *ProtoFile, documentation string, ctx parseCtx)
 	return nil
 }
 
-func (p *parser) readExtensions(pf *ProtoFile, documentation string, ctx parseCtx) error {
-	if pf.Syntax == proto3 {
+func (p *parser) readExtensions(pf *ProtoFile, documentation Documentation, ctx parseCtx) error {
+	if pf.IsProto3() {
 		return p.errline("Extension ranges are not allowed in proto3")
 	}
 
-	p.skipWhitespace()
-	start, err := p.readInt()
-	if err != nil {
-		return err
-	}
-
-	// At this point, make End be same as Start...
-	xe := ExtensionsElement{Documentation: documentation, Start: start, End: start}
-
-	c := p.read()
-	if c != ';' {
-		p.unread()
+	// read the comma separated ranges...
+	var ranges []ExtensionsElement
+	for {
 		p.skipWhitespace()
-		if w := p.readWord(); w != "to" {
-			return p.errline("Expected 'to', but found: %v", w)
+		start, err := p.readInt()
+		if err != nil {
+			return err
 		}
+
+		// At this point, make End be same as Start...
+		xe := ExtensionsElement{Documentation: documentation, Start: start, End: start}
+
 		p.skipWhitespace()
-		var end int
-		endStr := p.readWord()
-		if endStr == "max" {
-			end = 536870911
-		} else {
-			end, err = strconv.Atoi(endStr)
-			if err != nil {
-				return err
+		c := p.read()
+		p.unread()
+		if c != ';' && c != '[' && c != ',' {
+			if w := p.readWord(); w != "to" {
+				return p.errline("Expected 'to', but found: %v", w)
 			}
+			p.skipWhitespace()
+			var end int
+			var ok bool
+			endStr := p.readWord()
+			if endStr == "max" {
+				end = maxFieldNumber
+			} else if end, ok = parseIntLiteral(endStr); !ok {
+				return p.errcol("Invalid integer literal: '%v'", endStr)
+			}
+			xe.End = end
+			p.skipWhitespace()
 		}
-		xe.End = end
+		ranges = append(ranges, xe)
+
+		if c := p.read(); c != ',' {
+			p.unread()
+			break
+		}
+	}
+
+	// If semicolon is next; we are done. If '[' is next, we must parse options for the extensions
+	options, err := p.readListOptionsOnALine()
+	if err != nil {
+		return err
+	}
+	trailing, err := p.readTrailingComment()
+	if err != nil {
+		return err
 	}
 
 	me := ctx.obj.(*MessageElement)
-	me.Extensions = append(me.Extensions, xe)
+	for _, xe := range ranges {
+		xe.Options = options
+		xe.Documentation.Trailing = trailing
+		me.Extensions = append(me.Extensions, xe)
+	}
 	return nil
 }
 
-func (p *parser) readEnumConstant(pf *ProtoFile, label string, documentation string, ctx parseCtx) error {
+func (p *parser) readEnumConstant(pf *ProtoFile, label string, documentation Documentation, ctx parseCtx) error {
 	p.skipWhitespace()
 	if c := p.read(); c != '=' {
 		return p.throw('=', c)
@@ -610,20 +1101,142 @@ func (p *parser) readEnumConstant(pf *ProtoFile, label string, documentation str
 	ec := EnumConstantElement{Name: label, Documentation: documentation}
 
 	if ec.Tag, err = p.readInt(); err != nil {
-		return p.errline("Unable to read tag for Enum Constant: %v due to: %v", label, err.Error())
+		// keep the position of the malformed literal; adding the context to the message...
+		if pe, ok := err.(*ParseError); ok {
+			pe.Msg = fmt.Sprintf("Unable to read tag for Enum Constant: %v due to: %v", label, pe.Msg)
+		}
+		return err
 	}
 
 	// If semicolon is next; we are done. If '[' is next, we must parse options for the enum constant
 	if ec.Options, err = p.readListOptionsOnALine(); err != nil {
 		return err
 	}
-
 	ee := ctx.obj.(*EnumElement)
+	if err = p.readTrailingDoc(pf, &ec.Documentation, ee.QualifiedName+"."+ec.Name); err != nil {
+		return err
+	}
 	ee.EnumConstants = append(ee.EnumConstants, ec)
 	return nil
 }
 
-func (p *parser) readOneOf(pf *ProtoFile, documentation string, ctx parseCtx) error {
+// readEnumBody reads the declarations within an enum upto the closing '}'. Enums can
+// be very large (think generated ones with thousands of constants), so the constants
+// of the common 'NAME = 123;' shape are read straight off the buffered input; all
+// else is read one declaration at a time by the general path.
+func (p *parser) readEnumBody(pf *ProtoFile, ctx parseCtx) error {
+	ee := ctx.obj.(*EnumElement)
+	for {
+		read, err := p.readSimpleEnumConstant(pf, ee)
+		if err != nil {
+			return err
+		}
+		if read {
+			continue
+		}
+		done, err := p.readNextDeclaration(pf, ctx)
+		if err != nil || done {
+			return err
+		}
+	}
+}
+
+// readSimpleEnumConstant reads the next enum constant if it is an undocumented one of
+// the 'NAME = 123;' shape which is followed by nothing but the end of the line. It
+// returns false, having consumed nothing, for anything else.
+func (p *parser) readSimpleEnumConstant(pf *ProtoFile, ee *EnumElement) (bool, error) {
+	if p.pendingDoc == nil || p.pendingDoc.Leading != "" || len(p.pendingDoc.Detached) > 0 {
+		return false, nil
+	}
+	b, _ := p.br.Peek(p.br.Buffered())
+	if bytes.IndexByte(b, '\n') < 0 {
+		// less than a line is buffered; so fill up the buffer...
+		b, _ = p.br.Peek(p.br.Size())
+	}
+
+	i := 0
+	skipBlanks := func() {
+		for i < len(b) && (b[i] == ' ' || b[i] == '\t' || b[i] == '\r') {
+			i++
+		}
+	}
+	skipBlanks()
+	start := i
+	for i < len(b) && (isLetter(rune(b[i])) || b[i] == '_' || (i > start && isDigit(rune(b[i])))) {
+		i++
+	}
+	name := b[start:i]
+	if len(name) == 0 || declarationKeywords[string(name)] {
+		return false, nil
+	}
+	skipBlanks()
+	if i == len(b) || b[i] != '=' {
+		return false, nil
+	}
+	i++
+	skipBlanks()
+	tag, digits := 0, 0
+	for ; i < len(b) && isDigit(rune(b[i])); i++ {
+		tag = tag*10 + int(b[i]-'0')
+		digits++
+	}
+	// the larger tags (and the octal ones) are left to the general path...
+	if digits == 0 || digits > 9 || (digits > 1 && b[i-digits] == '0') {
+		return false, nil
+	}
+	skipBlanks()
+	if i == len(b) || b[i] != ';' {
+		return false, nil
+	}
+	i++
+	skipBlanks()
+	if i == len(b) || b[i] != '\n' {
+		return false, nil
+	}
+
+	// the constant is simple; so consume it along with the end of the line...
+	ec := EnumConstantElement{Name: p.internBytes(name), Tag: tag}
+	p.lastColumnRead = p.loc.column + i
+	p.loc.line++
+	p.loc.column = 0
+	n := i + 1
+	for i = n; i < len(b) && (b[i] == ' ' || b[i] == '\t'); i++ {
+	}
+	if i < len(b) && b[i] != '\n' && b[i] != '\r' && b[i] != '/' {
+		// the next token is on the very next line; so there are no comments to read and
+		// the (empty) pending documentation holds for the next declaration as well...
+		p.loc.column = i - n
+		if _, err := p.br.Discard(i); err != nil {
+			return false, err
+		}
+	} else {
+		if _, err := p.br.Discard(n); err != nil {
+			return false, err
+		}
+		// the comments on the lines which follow may yet trail the constant...
+		p.pendingDoc = nil
+		cc := commentCollector{canAttachToPrev: true}
+		if err := p.readComments(&cc); err != nil {
+			return true, err
+		}
+		p.trailingLines = cc.trailingLines
+		if cc.trailing != "" {
+			ec.Documentation.Trailing = cc.trailing
+			pf.commentOwners = append(pf.commentOwners, commentOwner{lines: p.trailingLines, owner: ee.QualifiedName + "." + ec.Name})
+		}
+	}
+
+	if len(ee.EnumConstants) == cap(ee.EnumConstants) {
+		// grow the slice by (atleast) the number of lines already buffered ahead...
+		grown := make([]EnumConstantElement, len(ee.EnumConstants), 2*cap(ee.EnumConstants)+bytes.Count(b, []byte{'\n'}))
+		copy(grown, ee.EnumConstants)
+		ee.EnumConstants = grown
+	}
+	ee.EnumConstants = append(ee.EnumConstants, ec)
+	return true, nil
+}
+
+func (p *parser) readOneOf(pf *ProtoFile, documentation Documentation, ctx parseCtx) error {
 	p.skipWhitespace()
 	name, _, err := p.readName()
 	if err != nil {
@@ -636,6 +1249,9 @@ func (p *parser) readOneOf(pf *ProtoFile, documentation string, ctx parseCtx) er
 	if c := p.read(); c != '{' {
 		return p.throw('{', c)
 	}
+	if err = p.readTrailingDoc(pf, &oe.Documentation, p.prefix+oe.Name); err != nil {
+		return err
+	}
 
 	innerCtx := parseCtx{ctxType: oneOfCtx, obj: &oe}
 	if err = p.readDeclarationsInLoop(pf, innerCtx); err != nil {
@@ -643,11 +1259,12 @@ func (p *parser) readOneOf(pf *ProtoFile, documentation string, ctx parseCtx) er
 	}
 
 	me := ctx.obj.(*MessageElement)
+	oe.Ordinal = ctx.nextOrdinal()
 	me.OneOfs = append(me.OneOfs, oe)
 	return nil
 }
 
-func (p *parser) readExtend(pf *ProtoFile, documentation string, ctx parseCtx) error {
+func (p *parser) readExtend(pf *ProtoFile, documentation Documentation, ctx parseCtx) error {
 	p.skipWhitespace()
 	name, _, err := p.readName()
 	if err != nil {
@@ -657,29 +1274,41 @@ func (p *parser) readExtend(pf *ProtoFile, documentation string, ctx parseCtx) e
 	if !strings.Contains(name, ".") && p.prefix != "" {
 		qualifiedName = p.prefix + name
 	}
-	ee := ExtendElement{Name: name, QualifiedName: qualifiedName, Documentation: documentation}
+	ee := ExtendElement{Name: name, QualifiedName: p.intern(qualifiedName), Documentation: documentation}
 
 	p.skipWhitespace()
 	if c := p.read(); c != '{' {
 		return p.throw('{', c)
 	}
+	if ee.Documentation.Trailing, err = p.readTrailingComment(); err != nil {
+		return err
+	}
 
 	innerCtx := parseCtx{ctxType: extendCtx, obj: &ee}
+	n := len(p.groups)
 	if err = p.readDeclarationsInLoop(pf, innerCtx); err != nil {
 		return err
 	}
+	groups := p.groups[n:]
+	p.groups = p.groups[:n]
 
-	// add extend declaration to the proper parent...
+	// add extend declaration (and the messages of its groups) to the proper parent...
 	if ctx.ctxType == msgCtx {
 		me := ctx.obj.(*MessageElement)
+		ee.Ordinal = ctx.nextOrdinal()
 		me.ExtendDeclarations = append(me.ExtendDeclarations, ee)
+		for _, g := range groups {
+			g.Ordinal = ctx.nextOrdinal()
+			me.Messages = append(me.Messages, g)
+		}
 	} else {
 		pf.ExtendDeclarations = append(pf.ExtendDeclarations, ee)
+		pf.Messages = append(pf.Messages, groups...)
 	}
 	return nil
 }
 
-func (p *parser) readRPC(pf *ProtoFile, se *ServiceElement, documentation string) error {
+func (p *parser) readRPC(pf *ProtoFile, se *ServiceElement, documentation Documentation) error {
 	p.skipWhitespace()
 	name, _, err := p.readName()
 	if err != nil {
@@ -724,10 +1353,17 @@ func (p *parser) readRPC(pf *ProtoFile, se *ServiceElement, documentation string
 
 	c := p.read()
 	if c == '{' {
+		if err = p.readTrailingDoc(pf, &rpc.Documentation, se.QualifiedName+"."+rpc.Name); err != nil {
+			return err
+		}
 		ctx := parseCtx{ctxType: rpcCtx, obj: &rpc}
 		for {
 			c2 := p.read()
 			if c2 == '}' {
+				// any comment trailing the '}' is not attached to anything...
+				if _, err = p.readTrailingComment(); err != nil {
+					return err
+				}
 				break
 			}
 			p.unread()
@@ -748,13 +1384,15 @@ func (p *parser) readRPC(pf *ProtoFile, se *ServiceElement, documentation string
 		}
 	} else if c != ';' {
 		return p.throw(';', c)
+	} else if err = p.readTrailingDoc(pf, &rpc.Documentation, se.QualifiedName+"."+rpc.Name); err != nil {
+		return err
 	}
 
 	se.RPCs = append(se.RPCs, rpc)
 	return nil
 }
 
-func (p *parser) readService(pf *ProtoFile, documentation string) error {
+func (p *parser) readService(pf *ProtoFile, documentation Documentation) error {
 	p.skipWhitespace()
 	name, _, err := p.readName()
 	if err != nil {
@@ -765,7 +1403,10 @@ func (p *parser) readService(pf *ProtoFile, documentation string) error {
 		return p.throw('{', c)
 	}
 
-	se := ServiceElement{Name: name, QualifiedName: p.prefix + name, Documentation: documentation}
+	se := ServiceElement{Name: name, QualifiedName: p.intern(p.prefix + name), Documentation: documentation}
+	if err = p.readTrailingDoc(pf, &se.Documentation, se.QualifiedName); err != nil {
+		return err
+	}
 
 	ctx := parseCtx{ctxType: serviceCtx, obj: &se}
 	if err = p.readDeclarationsInLoop(pf, ctx); err != nil {
@@ -776,7 +1417,7 @@ func (p *parser) readService(pf *ProtoFile, documentation string) error {
 	return nil
 }
 
-func (p *parser) readEnum(pf *ProtoFile, documentation string, ctx parseCtx) error {
+func (p *parser) readEnum(pf *ProtoFile, documentation Documentation, ctx parseCtx) error {
 	p.skipWhitespace()
 	name, _, err := p.readName()
 	if err != nil {
@@ -787,15 +1428,19 @@ func (p *parser) readEnum(pf *ProtoFile, documentation string, ctx parseCtx) err
 		return p.throw('{', c)
 	}
 
-	ee := EnumElement{Name: name, QualifiedName: p.prefix + name, Documentation: documentation}
+	ee := EnumElement{Name: name, QualifiedName: p.intern(p.prefix + name), Documentation: documentation}
+	if err = p.readTrailingDoc(pf, &ee.Documentation, ee.QualifiedName); err != nil {
+		return err
+	}
 	innerCtx := parseCtx{ctxType: enumCtx, obj: &ee}
-	if err = p.readDeclarationsInLoop(pf, innerCtx); err != nil {
+	if err = p.readEnumBody(pf, innerCtx); err != nil {
 		return err
 	}
 
 	// add enum to the proper parent...
 	if ctx.ctxType == msgCtx {
 		me := ctx.obj.(*MessageElement)
+		ee.Ordinal = ctx.nextOrdinal()
 		me.Enums = append(me.Enums, ee)
 	} else {
 		pf.Enums = append(pf.Enums, ee)
@@ -819,21 +1464,45 @@ func (p *parser) readImport(pf *ProtoFile) error {
 		}
 		pf.Dependencies = append(pf.Dependencies, importString)
 	} else {
-		publicStr := p.readWord()
-		if "public" != publicStr {
-			return p.errline("Expected 'public', but found: %v", publicStr)
+		modifier := p.readWord()
+		if modifier != "public" && modifier != "weak" {
+			return p.errline("Expected 'public' or 'weak', but found: %v", modifier)
 		}
 		p.skipWhitespace()
 		importString, err := p.readQuotedString(f)
 		if err != nil {
 			return err
 		}
-		pf.PublicDependencies = append(pf.PublicDependencies, importString)
+		if modifier == "public" {
+			pf.PublicDependencies = append(pf.PublicDependencies, importString)
+		} else {
+			pf.WeakDependencies = append(pf.WeakDependencies, importString)
+		}
 	}
 	if c := p.read(); c != ';' {
 		return p.throw(';', c)
 	}
-	return nil
+
+	// any comment trailing the declaration is not attached to anything...
+	_, err := p.readTrailingComment()
+	return err
+}
+
+func (p *parser) readPackage(pf *ProtoFile) error {
+	p.skipWhitespace()
+	pf.PackageName = p.readWord()
+	p.prefix = pf.PackageName + "."
+
+	// the parser has always tolerated a missing semicolon here...
+	p.skipWhitespaceOnLine()
+	if c := p.read(); c != ';' {
+		p.unread()
+		return nil
+	}
+
+	// any comment trailing the declaration is not attached to anything...
+	_, err := p.readTrailingComment()
+	return err
 }
 
 func (p *parser) readSyntax(pf *ProtoFile) error {
@@ -842,31 +1511,80 @@ func (p *parser) readSyntax(pf *ProtoFile) error {
 		return p.throw('=', c)
 	}
 	p.skipWhitespace()
-	syntax, err := p.readQuotedString(nil)
+	s, err := p.readQuotedString(nil)
 	if err != nil {
 		return err
 	}
-	if syntax != "proto2" && syntax != proto3 {
+	syntax := Syntax(s)
+	if syntax != SyntaxProto2 && syntax != SyntaxProto3 {
 		return p.errline("'syntax' must be 'proto2' or 'proto3'. Found: %v", syntax)
 	}
 	if c := p.read(); c != ';' {
 		return p.throw(';', c)
 	}
 	pf.Syntax = syntax
-	return nil
+
+	// any comment trailing the declaration is not attached to anything...
+	_, err = p.readTrailingComment()
+	return err
 }
 
 func (p *parser) readQuotedString(f func(r rune) bool) (string, error) {
+	pos := p.position()
 	if c := p.read(); c != '"' {
 		return "", p.throw('"', c)
 	}
 	str := p.readWordAdvanced(f)
 	if c := p.read(); c != '"' {
-		return "", p.throw('"', c)
+		err := p.throw('"', c)
+		// is the closing quote missing altogether?
+		if c == '\n' || c == eof || !strings.Contains(p.readUntilNewline(), "\"") {
+			err = unterminatedStringErr(pos)
+		}
+		return "", err
 	}
 	return str, nil
 }
 
+// readStringLiteral reads the rest of a string literal whose opening quote, at the
+// given position, has already been read. The closing quote is consumed but is not
+// part of the returned string; the escape sequences within the literal are replaced
+// by what they stand for. A string literal can not span multiple lines.
+func (p *parser) readStringLiteral(pos Position) (string, error) {
+	var buf bytes.Buffer
+	for {
+		cpos := p.position()
+		c := p.read()
+		if c == '"' {
+			break
+		}
+		if c == '\\' {
+			// a backslash at the end of the line does not continue the literal...
+			if c2 := p.read(); c2 == '\n' || c2 == eof {
+				c = c2
+			} else {
+				p.unread()
+				if err := unescape(p.read, p.unread, &buf); err != nil {
+					return "", &ParseError{Msg: err.Error(), Position: cpos}
+				}
+				continue
+			}
+		}
+		if c == '\n' || c == eof {
+			p.eofReached = c == eof
+			return "", unterminatedStringErr(pos)
+		}
+		_, _ = buf.WriteRune(c)
+	}
+	return p.intern(buf.String()), nil
+}
+
+// unterminatedStringErr returns the error reported when the string literal starting
+// at the given position is not terminated on the same line.
+func unterminatedStringErr(pos Position) error {
+	return &ParseError{Msg: "Unterminated string literal", Position: pos, format: "%[1]v starting at line %[2]v, column %[3]v"}
+}
+
 func (p *parser) readRequestResponseType() (NamedDataType, error) {
 	name := p.readWord()
 
@@ -888,7 +1606,7 @@ func (p *parser) readRequestResponseType() (NamedDataType, error) {
 		ndt.stream(requiresStreaming)
 		return ndt, err
 	default:
-		return NamedDataType{}, errors.New("Expected message type")
+		return NamedDataType{}, p.errline("Expected message type")
 	}
 }
 
@@ -899,11 +1617,9 @@ func (p *parser) readDataType() (DataType, error) {
 }
 
 func (p *parser) readDataTypeInternal(name string) (DataType, error) {
-	// is it a map type?
-	if name == "map" {
-		if c := p.read(); c != '<' {
-			return nil, p.throw('<', c)
-		}
+	// is it a map type (rather than a message named map)?
+	if name == "map" && p.isStartOfMapType() {
+		p.read()
 		var err error
 		var keyType, valueType DataType
 		keyType, err = p.readDataType()
@@ -921,17 +1637,17 @@ func (p *parser) readDataTypeInternal(name string) (DataType, error) {
 		if c := p.read(); c != '>' {
 			return nil, p.throw('>', c)
 		}
-		return MapDataType{keyType: keyType, valueType: valueType}, nil
+		return p.internType(MapDataType{keyType: keyType, valueType: valueType}), nil
 	}
 
 	// is it a scalar type?
 	sdt, err := NewScalarDataType(name)
 	if err == nil {
-		return sdt, nil
+		return p.internType(sdt), nil
 	}
 
 	// must be a named type
-	return NamedDataType{name: name}, nil
+	return p.internType(NamedDataType{name: name}), nil
 }
 
 func (p *parser) unexpected(label string, ctx parseCtx) error {
@@ -939,17 +1655,31 @@ func (p *parser) unexpected(label string, ctx parseCtx) error {
 }
 
 func (p *parser) throw(expected rune, actual rune) error {
-	return p.errcol("Expected %v, but found: %v", strconv.QuoteRune(expected), strconv.QuoteRune(actual))
+	return p.errcol("Expected %v, but found: %v", strconv.QuoteRune(expected), quoteRune(actual))
+}
+
+// quoteRune quotes the given rune for the error messages; calling out the byte order
+// mark which is allowed only at the start of the input.
+func quoteRune(c rune) string {
+	if c == '\uFEFF' {
+		return "byte order mark '\\ufeff' (allowed only at the start of the input)"
+	}
+	return strconv.QuoteRune(c)
 }
 
 func (p *parser) errline(msg string, a ...interface{}) error {
 	s := fmt.Sprintf(msg, a...)
-	return fmt.Errorf(s+" on line: %v", p.loc.line)
+	return &ParseError{Msg: s, Position: Position{Line: p.loc.line}, format: lineErrFormat}
 }
 
 func (p *parser) errcol(msg string, a ...interface{}) error {
 	s := fmt.Sprintf(msg, a...)
-	return fmt.Errorf(s+" on line: %v, column: %v", p.loc.line, p.loc.column)
+	return &ParseError{Msg: s, Position: Position{Line: p.loc.line, Column: p.loc.column}, format: columnErrFormat}
+}
+
+// position returns the location of the next rune to be read.
+func (p *parser) position() Position {
+	return Position{Line: p.loc.line, Column: p.loc.column + 1}
 }
 
 func (p *parser) readName() (string, enclosure, error) {
@@ -959,17 +1689,15 @@ func (p *parser) readName() (string, enclosure, error) {
 	if c == '(' {
 		enc = parenthesis
 		name = p.readWord()
-		if p.read() != ')' {
-			return "", enc, p.errline("Expected ')'")
+		if c2 := p.read(); c2 != ')' {
+			return "", enc, p.throw(')', c2)
 		}
-		p.unread()
 	} else if c == '[' {
 		enc = bracket
 		name = p.readWord()
-		if p.read() != ']' {
-			return "", enc, p.errline("Expected ']'")
+		if c2 := p.read(); c2 != ']' {
+			return "", enc, p.throw(']', c2)
 		}
-		p.unread()
 	} else {
 		p.unread()
 		name = p.readWord()
@@ -977,58 +1705,139 @@ func (p *parser) readName() (string, enclosure, error) {
 	return name, enc, nil
 }
 
+// readOptionFieldPath reads the dotted path of the field which follows the name of a
+// custom option e.g. the string.min_len of (validate.rules).string.min_len; the path
+// can itself refer to extensions e.g. (my.opt).(my.ext).name
+func (p *parser) readOptionFieldPath() (string, error) {
+	var parts []string
+	for {
+		if c := p.read(); c != '.' {
+			p.unread()
+			break
+		}
+		name, enc, err := p.readName()
+		if err != nil {
+			return "", err
+		}
+		if enc == parenthesis {
+			name = "(" + name + ")"
+		}
+		parts = append(parts, name)
+	}
+	return strings.Join(parts, "."), nil
+}
+
 func (p *parser) readWord() string {
 	return p.readWordAdvanced(nil)
 }
 
 func (p *parser) readWordAdvanced(f func(r rune) bool) string {
-	var buf bytes.Buffer
+	p.buf.Reset()
 	for {
 		c := p.read()
 		if isValidCharInWord(c, f) {
-			_, _ = buf.WriteRune(c)
+			_, _ = p.buf.WriteRune(c)
 		} else {
 			p.unread()
 			break
 		}
 	}
-	return buf.String()
+	if p.opts.interner != nil {
+		return p.opts.interner.internBytes(p.buf.Bytes())
+	}
+	return p.buf.String()
 }
 
+// readInt reads an integer literal; which can be a decimal, a hexadecimal (0x or 0X
+// prefixed) or an octal (0 prefixed) one as per the intLit grammar of protobuf.
 func (p *parser) readInt() (int, error) {
-	var buf bytes.Buffer
+	p.buf.Reset()
 	for {
 		c := p.read()
-		if isDigit(c) {
-			_, _ = buf.WriteRune(c)
+		if isDigit(c) || isLetter(c) {
+			_, _ = p.buf.WriteRune(c)
 		} else {
 			p.unread()
 			break
 		}
 	}
-	str := buf.String()
-	intVal, err := strconv.Atoi(str)
-	return intVal, err
+	str := p.buf.String()
+	intVal, ok := parseIntLiteral(str)
+	if !ok {
+		return 0, p.errcol("Invalid integer literal: '%v'", str)
+	}
+	return intVal, nil
 }
 
-func (p *parser) readDocumentation() (string, error) {
-	c := p.read()
-	if c == '/' {
-		return p.readSingleLineComment(), nil
-	} else if c == '*' {
-		return p.readMultiLineComment(), nil
+// parseIntLiteral returns the value of the given decimal, hexadecimal or octal
+// integer literal. The second value returned is false if the literal is malformed.
+func parseIntLiteral(s string) (int, bool) {
+	base := 10
+	if len(s) > 2 && (s[:2] == "0x" || s[:2] == "0X") {
+		s, base = s[2:], 16
+	} else if len(s) > 1 && s[0] == '0' {
+		s, base = s[1:], 8
+	}
+	if s == "" || s[0] == '+' || s[0] == '-' {
+		return 0, false
+	}
+	v, err := strconv.ParseInt(s, base, 64)
+	if err != nil {
+		return 0, false
+	}
+	return int(v), true
+}
+
+// peekComment checks, without consuming anything, if a comment starts next.
+func (p *parser) peekComment() commentKind {
+	b, err := p.br.Peek(2)
+	if err != nil || b[0] != '/' {
+		return noComment
+	}
+	if b[1] == '/' {
+		return lineComment
+	} else if b[1] == '*' {
+		return blockComment
+	}
+	return noComment
+}
+
+// readLineComment reads a single line comment including the newline ending it.
+func (p *parser) readLineComment() string {
+	p.read()
+	p.read()
+	return strings.TrimSpace(p.readUntilNewline())
+}
+
+// readBlockComment reads a block comment.
+func (p *parser) readBlockComment() (string, error) {
+	line := p.loc.line
+	p.read()
+	p.read()
+	s, ok := p.readMultiLineComment()
+	if !ok {
+		p.eofReached = true
+		return "", &ParseError{Msg: "Unterminated block comment", Position: Position{Line: line}, format: "%[1]v starting at line %[2]v"}
 	}
-	return "", p.errline("Expected '/' or '*', but found: %v", strconv.QuoteRune(c))
+	return s, nil
 }
 
-func (p *parser) readMultiLineComment() string {
+// readMultiLineComment reads the rest of a block comment. It returns false if
+// the end of input is reached before the end of the comment.
+func (p *parser) readMultiLineComment() (string, bool) {
 	var buf bytes.Buffer
 	for {
 		c := p.read()
+		if c == eof {
+			return "", false
+		}
 		if c != '*' {
 			_, _ = buf.WriteRune(c)
 		} else {
 			c2 := p.read()
+			if c2 == eof {
+				return "", false
+			}
 			if c2 == '/' {
 				break
 			}
@@ -1036,25 +1845,7 @@ func (p *parser) readMultiLineComment() string {
 		}
 	}
 	str := buf.String()
-	return strings.TrimSpace(str)
-}
-
-// Reads one or multiple single line comments
-func (p *parser) readSingleLineComment() string {
-	str := strings.TrimSpace(p.readUntilNewline())
-	for {
-		p.skipWhitespace()
-		if c := p.read(); c != '/' {
-			p.unread()
-			break
-		}
-		if c := p.read(); c != '/' {
-			p.unread()
-			break
-		}
-		str += " " + strings.TrimSpace(p.readUntilNewline())
-	}
-	return str
+	return strings.TrimSpace(str), true
 }
 
 func (p *parser) readUntil(delimiter byte) string {
@@ -1062,32 +1853,110 @@ func (p *parser) readUntil(delimiter byte) string {
 	if err == io.EOF {
 		p.eofReached = true
 	}
+	p.advance(s)
 	return strings.TrimSuffix(s, string(delimiter))
 }
 
-func (p *parser) readUntilNewline() string {
-	return p.readUntil('\n')
+// advance updates the location for a string which was read in bulk
+// i.e. without going through read().
+func (p *parser) advance(s string) {
+	for _, c := range s {
+		p.lastColumnRead = p.loc.column
+		if c == '\n' {
+			p.loc.line++
+			p.loc.column = 0
+		} else {
+			p.loc.column++
+		}
+	}
 }
 
-func (p *parser) skipUntilNewline() {
+// readUntilUnnested reads up to (and including) the given delimiter, as long as the
+// delimiter is not nested within a quoted string, braces or parenthesis. It returns
+// the content read excluding the delimiter.
+func (p *parser) readUntilUnnested(delimiter rune) (string, error) {
+	var buf bytes.Buffer
+	var quote rune
+	var quotePos Position
+	var closers []rune // the closing runes expected for the open braces, brackets & parenthesis
 	for {
+		pos := p.position()
 		c := p.read()
-		if c == '\n' {
-			return
+		if quote != 0 && (c == '\n' || c == eof) {
+			p.eofReached = c == eof
+			return "", unterminatedStringErr(quotePos)
 		}
 		if c == eof {
 			p.eofReached = true
-			return
+			return "", p.errline("Reached end of input while looking for %v", strconv.QuoteRune(delimiter))
+		}
+		if quote != 0 {
+			if c == '\\' {
+				_, _ = buf.WriteRune(c)
+				c = p.read()
+			} else if c == quote {
+				quote = 0
+			}
+		} else if c == delimiter && len(closers) == 0 {
+			break
+		} else if c == '"' || c == '\'' {
+			quote = c
+			quotePos = pos
+		} else if c == '{' {
+			closers = append(closers, '}')
+		} else if c == '(' {
+			closers = append(closers, ')')
+		} else if c == '[' {
+			closers = append(closers, ']')
+		} else if c == '}' || c == ')' || c == ']' {
+			if len(closers) == 0 || closers[len(closers)-1] != c {
+				expected := delimiter
+				if len(closers) > 0 {
+					expected = closers[len(closers)-1]
+				}
+				return "", p.throw(expected, c)
+			}
+			closers = closers[:len(closers)-1]
+		}
+		_, _ = buf.WriteRune(c)
+	}
+	return buf.String(), nil
+}
+
+func (p *parser) readUntilNewline() string {
+	return p.readUntil('\n')
+}
+
+// skipWhitespaceOnLine skips any whitespace on the current line, but not the newline.
+func (p *parser) skipWhitespaceOnLine() {
+	for {
+		c := p.read()
+		if c == '\n' || !isWhitespace(c) {
+			p.unread()
+			break
 		}
 	}
 }
 
+// skipBOM skips the UTF-8 byte order mark at the start of the input (if any); as
+// saved by some editors. The location of the parser is not affected.
+func (p *parser) skipBOM() {
+	if c, _, err := p.br.ReadRune(); err == nil && c != '\uFEFF' {
+		_ = p.br.UnreadRune()
+	}
+}
+
 func (p *parser) unread() {
+	// nothing to do if the last read did not yield a rune (eof)...
+	if err := p.br.UnreadRune(); err != nil {
+		return
+	}
 	if p.loc.column == 0 {
 		p.loc.line--
 		p.loc.column = p.lastColumnRead
+	} else {
+		p.loc.column--
 	}
-	_ = p.br.UnreadRune()
 }
 
 func (p *parser) read() rune {
@@ -1120,18 +1989,73 @@ func (p *parser) skipWhitespace() {
 	}
 }
 
+// splitUnnested splits the given string around each instance of the separator,
+// ignoring any instances which are nested within a quoted string, braces,
+// brackets or parenthesis.
+func splitUnnested(s string, sep rune) []string {
+	var parts []string
+	for {
+		i := indexUnnested(s, sep)
+		if i < 0 {
+			return append(parts, s)
+		}
+		parts = append(parts, s[:i])
+		s = s[i+1:]
+	}
+}
+
+// indexUnnested returns the index of the first instance of the separator in the
+// given string which is not nested within a quoted string, braces, brackets or
+// parenthesis; or -1 if there is no such instance.
+func indexUnnested(s string, sep rune) int {
+	var quote rune
+	var escaped bool
+	depth := 0
+	for i, c := range s {
+		if quote != 0 {
+			if escaped {
+				escaped = false
+			} else if c == '\\' {
+				escaped = true
+			} else if c == quote {
+				quote = 0
+			}
+		} else if c == sep && depth == 0 {
+			return i
+		} else if c == '"' || c == '\'' {
+			quote = c
+		} else if c == '{' || c == '(' || c == '[' {
+			depth++
+		} else if c == '}' || c == ')' || c == ']' {
+			depth--
+		}
+	}
+	return -1
+}
+
+// splitOptionName splits the name of a custom option e.g. (validate.rules).string.min_len
+// into the parenthesized name and the dotted path of the field following it.
+func splitOptionName(s string) (string, string) {
+	if len(s) > 0 && s[0] == '(' {
+		if i := strings.Index(s, ")."); i > 0 {
+			return s[:i+1], s[i+2:]
+		}
+	}
+	return s, ""
+}
+
 func stripParenthesis(s string) (string, bool) {
-	if s[0] == '(' && s[len(s)-1] == ')' {
-		return parenthesisRemovalRegex.ReplaceAllString(s, "${1}"), true
+	if len(s) > 1 && s[0] == '(' && s[len(s)-1] == ')' {
+		return s[1 : len(s)-1], true
 	}
 	return s, false
 }
 
-func stripQuotes(s string) string {
-	if s[0] == '"' && s[len(s)-1] == '"' {
-		return quoteRemovalRegex.ReplaceAllString(s, "${1}")
+func stripQuotes(s string) (string, bool) {
+	if len(s) > 1 && s[0] == '"' && s[len(s)-1] == '"' {
+		return s[1 : len(s)-1], true
 	}
-	return s
+	return s, false
 }
 
 func isValidCharInWord(c rune, f func(r rune) bool) bool {
@@ -1143,6 +2067,17 @@ func isValidCharInWord(c rune, f func(r rune) bool) bool {
 	return false
 }
 
+// isIdentifier returns true if the given string is a letter followed by letters,
+// digits or underscores.
+func isIdentifier(s string) bool {
+	for i, c := range s {
+		if !isLetter(c) && (i == 0 || (!isDigit(c) && c != '_')) {
+			return false
+		}
+	}
+	return s != ""
+}
+
 func isStartOfComment(c rune) bool {
 	return c == '/'
 }
@@ -1162,11 +2097,15 @@ func isDigit(c rune) bool {
 // End of the file...
 var eof = rune(0)
 
-// Regex for removing bounding quotes
-var quoteRemovalRegex = regexp.MustCompile(`"([^"]*)"`)
+// kind of comment
+type commentKind int
 
-// Regex for removing bounding parenthesis
-var parenthesisRemovalRegex = regexp.MustCompile(`\(([^"]*)\)`)
+// the various kinds of comments
+const (
+	noComment commentKind = iota
+	lineComment
+	blockComment
+)
 
 // enclousure used to bound/enclose a string
 type enclosure int
@@ -1178,10 +2117,28 @@ const (
 	unenclosed
 )
 
+// error reported when a reserved statement has both field names and numbers
+const reservedMixErr = "Cannot mix field names and numbers in one reserved statement"
+
+// keywords which start a declaration other than a field
+var declarationKeywords = map[string]bool{
+	"syntax":     true,
+	"package":    true,
+	"import":     true,
+	"option":     true,
+	"message":    true,
+	"enum":       true,
+	"extend":     true,
+	"service":    true,
+	"rpc":        true,
+	"oneof":      true,
+	"extensions": true,
+	"reserved":   true,
+}
+
 // some often-used string constants
 const (
-	proto3   = "proto3"
-	optional = "optional"
-	required = "required"
-	repeated = "repeated"
+	optional = LabelOptional
+	required = LabelRequired
+	repeated = LabelRepeated
 )
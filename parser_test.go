@@ -2,10 +2,18 @@ package pbparser_test
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
 	"regexp"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/tallstoat/pbparser"
+	"github.com/tallstoat/pbparser/pbparsertest"
 )
 
 const (
@@ -37,6 +45,60 @@ func BenchmarkParseFile(b *testing.B) {
 	result = pf
 }
 
+// BenchmarkParseFileLarge benchmarks the ParseFile() API for a large synthetic .proto
+// file; the allocations of which should be dominated by the parsed model rather than
+// by copies of the content of the file.
+func BenchmarkParseFileLarge(b *testing.B) {
+	b.ReportAllocs()
+	dir, err := ioutil.TempDir("", "pbparser")
+	if err != nil {
+		b.Fatalf("%v", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	var sb strings.Builder
+	sb.WriteString("syntax = \"proto3\";\npackage large;\n")
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&sb, "\n// Message%v is a generated message.\nmessage Message%v {\n", i, i)
+		for j := 1; j <= 10; j++ {
+			fmt.Fprintf(&sb, "  string field_%v = %v; // a generated field\n", j, j)
+		}
+		sb.WriteString("}\n")
+	}
+	file := filepath.Join(dir, "large.proto")
+	if err := ioutil.WriteFile(file, []byte(sb.String()), 0644); err != nil {
+		b.Fatalf("%v", err.Error())
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if result, err = pbparser.ParseFile(file); err != nil {
+			b.Fatalf("%v", err.Error())
+		}
+	}
+}
+
+// BenchmarkParseLargeEnum benchmarks the Parse() API for an enum with 50k
+// constants of the plain 'NAME = 123;' shape.
+func BenchmarkParseLargeEnum(b *testing.B) {
+	b.ReportAllocs()
+	var sb strings.Builder
+	sb.WriteString("syntax = \"proto3\";\npackage large;\n\nenum Large {\n")
+	for i := 0; i < 50000; i++ {
+		fmt.Fprintf(&sb, "  LARGE_%v = %v;\n", i, i)
+	}
+	sb.WriteString("}\n")
+	content := sb.String()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var err error
+		if result, err = pbparser.Parse(strings.NewReader(content), nil); err != nil {
+			b.Fatalf("%v", err.Error())
+		}
+	}
+}
+
 // TestParseErrors is a test which is meant to cover most of the exception coditions
 // that the parser needs to catch. As such, this needs to be updated whenever new validations
 // are added in the parser or old validations are changed. Thus, this test ensures that the code
@@ -53,21 +115,25 @@ func TestParseErrors(t *testing.T) {
 		{file: "wrong-syntax.proto", expectedErrors: []string{"'syntax' must be 'proto2' or 'proto3'"}},
 		{file: "wrong-syntax2.proto", expectedErrors: []string{"Expected ';'"}},
 		{file: "wrong-syntax3.proto", expectedErrors: []string{"Expected '='"}},
-		{file: "optional-in-proto3.proto", expectedErrors: []string{"Explicit 'optional' labels are disallowed in the proto3 syntax"}},
 		{file: "required-in-proto3.proto", expectedErrors: []string{"Required fields are not allowed in proto3"}},
 		{file: "rpc-in-wrong-context.proto", expectedErrors: []string{"Unexpected 'rpc' in context"}},
 		{file: "dup-enum.proto", expectedErrors: []string{"Duplicate name"}},
 		{file: "dup-enum-constant.proto", expectedErrors: []string{"Enum constant", "is already defined in package missing"}},
 		{file: "enum-constant-same-tag.proto", expectedErrors: []string{"is reusing an enum value. If this is intended, set 'option allow_alias = true;'"}},
-		{file: "wrong-enum-constant-tag.proto", expectedErrors: []string{"Unable to read tag for Enum Constant: UNKNOWN"}},
+		{file: "wrong-enum-constant-tag.proto", expectedErrors: []string{"Unable to read tag for Enum Constant: UNKNOWN due to: Invalid integer literal: 'string' on line: 5, column: 18$"}},
 		{file: "wrong-msg.proto", expectedErrors: []string{"Expected '{'"}},
 		{file: "dup-msg.proto", expectedErrors: []string{"Duplicate name"}},
 		{file: "dup-nested-msg.proto", expectedErrors: []string{"Duplicate name"}},
+		{file: "dup-oneof.proto", expectedErrors: []string{"Duplicate name owner in message Task"}},
+		{file: "dup-oneof-msg.proto", expectedErrors: []string{"Duplicate name owner in message Task"}},
+		{file: "dup-field-msg.proto", expectedErrors: []string{"Duplicate name details in message Parent"}},
 		{file: "missing-msg.proto", expectedErrors: []string{"Datatype: 'TaskDetails' referenced in field: 'details' is not defined"}},
+		{file: "near-miss-int.proto", expectedErrors: []string{"Datatype: 'int' referenced in field: 'count' is not defined. Did you mean 'int32'\\?"}},
+		{file: "near-miss-float64.proto", expectedErrors: []string{"Datatype: 'float64' referenced in field: 'value' is not defined. Did you mean 'double'\\?"}},
 		{file: "missing-package.proto", expectedErrors: []string{"Datatype: 'abcd.TaskDetails' referenced in field: 'details' is not defined"}},
 		{file: "wrong-import.proto", expectedErrors: []string{"ImportModuleReader is unable to provide content of dependency module"}},
-		{file: "wrong-import2.proto", expectedErrors: []string{"Expected 'public'"}},
-		{file: "wrong-import3.proto", expectedErrors: []string{"Expected '\"'"}},
+		{file: "wrong-import2.proto", expectedErrors: []string{"Expected 'public' or 'weak'"}},
+		{file: "wrong-import3.proto", expectedErrors: []string{"Unterminated string literal starting at line 4, column 8"}},
 		{file: "wrong-public-import.proto", expectedErrors: []string{"ImportModuleReader is unable to provide content of dependency module"}},
 		{file: "wrong-rpc-datatype.proto", expectedErrors: []string{"Datatype: 'TaskId' referenced in RPC: 'AddTask' of Service: 'LogTask' is not defined"}},
 		{file: "wrong-label-in-oneof-field.proto", expectedErrors: []string{"Label 'repeated' is disallowed in oneoff field"}},
@@ -92,7 +158,42 @@ func TestParseErrors(t *testing.T) {
 		{file: "enum-in-wrong-context.proto", expectedErrors: []string{"Unexpected 'enum' in context: service"}},
 		{file: "extend-in-wrong-context.proto", expectedErrors: []string{"Unexpected 'extend' in context: service"}},
 		{file: "oneof-in-wrong-context.proto", expectedErrors: []string{"Unexpected 'oneof' in context: service"}},
+		{file: "service-in-msg.proto", expectedErrors: []string{"Unexpected 'service' in context: message"}},
+		{file: "service-in-service.proto", expectedErrors: []string{"Unexpected 'service' in context: service"}},
+		{file: "msg-in-enum.proto", expectedErrors: []string{"'message' declarations are not allowed inside enum Status on line: 7"}},
+		{file: "enum-in-enum.proto", expectedErrors: []string{"'enum' declarations are not allowed inside enum Status on line: 7"}},
+		{file: "oneof-in-enum.proto", expectedErrors: []string{"'oneof' declarations are not allowed inside enum Status on line: 7"}},
 		{file: "unused-import.proto", expectedErrors: []string{"Imported package: dummy but not used"}},
+		{file: "dup-type-import.proto", expectedErrors: []string{"Type dup.Config is defined in both dup-type-dep.proto and the main file"}},
+		{file: "dup-type-import2.proto", expectedErrors: []string{"Type dup.Config is defined in both dup-type-dep.proto and dup-type-dep2.proto"}},
+		{file: "proto3-uses-proto2-enum.proto", expectedErrors: []string{"Field 'color' in message mixed.Paint uses the proto2 enum mixed.Color which is not allowed in proto3"}},
+		{file: "unused-packageless-import.proto", expectedErrors: []string{"Imported file: common.proto declares no package and is not used"}},
+		{file: "reserved-mixed.proto", expectedErrors: []string{"Cannot mix field names and numbers in one reserved statement on line: 6"}},
+		{file: "reserved-mixed2.proto", expectedErrors: []string{"Cannot mix field names and numbers in one reserved statement on line: 6"}},
+		{file: "msg-in-extend.proto", expectedErrors: []string{"'message' is not allowed inside extend on line: 11"}},
+		{file: "oneof-in-extend.proto", expectedErrors: []string{"'oneof' is not allowed inside extend on line: 10"}},
+		{file: "enum-nonzero-in-proto3.proto", expectedErrors: []string{"The first enum value must be zero in proto3. Found otherwise in enum enums.Status"}},
+		{file: "unterminated-block-comment.proto", expectedErrors: []string{"Unterminated block comment starting at line 7"}},
+		{file: "unterminated-string-syntax.proto", expectedErrors: []string{"Unterminated string literal starting at line 1, column 10"}},
+		{file: "unterminated-string-import.proto", expectedErrors: []string{"Unterminated string literal starting at line 5, column 15"}},
+		{file: "bom-mid-file.proto", expectedErrors: []string{"Unexpected byte order mark '\\\\ufeff' \\(allowed only at the start of the input\\) in context: file on line: 4, column: 1"}},
+		{file: "group-proto3.proto", expectedErrors: []string{"Groups are not allowed in proto3 on line: 6"}},
+		{file: "invalid-escape.proto", expectedErrors: []string{"Invalid escape sequence '\\\\q' on line: 5, column: 35"}},
+		{file: "unterminated-string-reserved.proto", expectedErrors: []string{"Unterminated string literal starting at line 7, column 19"}},
+		{file: "unterminated-string-option.proto", expectedErrors: []string{"Unterminated string literal starting at line 5, column 21"}},
+		{file: "unterminated-string-inline-option.proto", expectedErrors: []string{"Unterminated string literal starting at line 6, column 30"}},
+		{file: "late-syntax.proto", expectedErrors: []string{"The syntax statement must be the first statement in the file on line: 4"}},
+		{file: "wrong-option-name.proto", expectedErrors: []string{"Expected '\\)', but found: ' ' on line: 5, column: 12"}},
+		{file: "wrong-inline-option-name.proto", expectedErrors: []string{"Expected '\\)', but found: '\\]' on line: 6, column: 26"}},
+		{file: "allow-alias-in-message.proto", expectedErrors: []string{"Option allow_alias is only allowed within enums. Found in message alias.Task"}},
+		{file: "wrong-bool-option.proto", expectedErrors: []string{"Option java_multiple_files in package options must be either true or false. Found: '1'"}},
+		{file: "wrong-bool-inline-option.proto", expectedErrors: []string{"Option deprecated in field options.Task.owner must be either true or false. Found: 'yes'"}},
+		{file: "allow-alias-not-bool.proto", expectedErrors: []string{"Option allow_alias in enum alias.Task.Status must be either true or false. Found: '1'"}},
+		{file: "wrong-optimize-for.proto", expectedErrors: []string{"Option optimize_for in package options must be one of SPEED, CODE_SIZE, LITE_RUNTIME. Found: 'SPEEED'"}},
+		{file: "wrong-hex-tag.proto", expectedErrors: []string{"Invalid integer literal: '0x' on line: 5, column: 16"}},
+		{file: "enum-reserved-value.proto", expectedErrors: []string{"Enum constant STATE_ACTIVE in enum reserved.State uses the reserved value 3"}},
+		{file: "packed-string.proto", expectedErrors: []string{"Option packed is only allowed for repeated fields of numeric, bool or enum types. Found in field packed.Task.tags of type string"}},
+		{file: "packed-singular.proto", expectedErrors: []string{"Option packed is only allowed for repeated fields. Found in field packed.Task.priority"}},
 	}
 
 	for _, tt := range tests {
@@ -109,12 +210,593 @@ func TestParseErrors(t *testing.T) {
 	}
 }
 
-// TestParseFile is a functional test which tests most success paths of the parser
-// by way of parsing a set of proto files. The proto files being used all conform to
-// the protobuf spec. This test also serves as a regression test which can be quickly
-// run post code changes to catch any regressions introduced.
-//
-// TODO: This is not an ideal test; needs assertions
+// TestUnterminatedBlockComment ensures that the parser returns promptly (instead of
+// spinning forever) when the input ends within a block comment.
+func TestUnterminatedBlockComment(t *testing.T) {
+	var tests = []string{
+		"/*",
+		"/* never closed *",
+		"syntax = \"proto3\";\nmessage Foo { /* never closed",
+		"syntax = \"proto3\";\nmessage Foo {}\n/** never closed **",
+	}
+
+	for _, tt := range tests {
+		done := make(chan error, 1)
+		go func(s string) {
+			_, err := pbparser.Parse(strings.NewReader(s), nil)
+			done <- err
+		}(tt)
+
+		select {
+		case err := <-done:
+			if err == nil || !strings.Contains(err.Error(), "Unterminated block comment") {
+				t.Errorf("Input: %q, Expected unterminated block comment error, Actual: %v", tt, err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Input: %q, Parse did not return", tt)
+		}
+	}
+}
+
+// TestParseParenthesizedOptionName ensures that the closing parenthesis of a custom
+// option name is consumed so that the rest of the option statement parses.
+func TestParseParenthesizedOptionName(t *testing.T) {
+	proto := `syntax = "proto3";
+package opts;
+option (foo) = 1;
+message Task {
+  option (bar) = "b";
+  string id = 1 [(baz) = true];
+}`
+	pf, err := pbparser.Parse(strings.NewReader(proto), nil)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	actual := []pbparser.OptionElement{
+		pf.Options[0],
+		pf.Messages[0].Options[0],
+		pf.Messages[0].Fields[0].Options[0],
+	}
+	expected := []pbparser.OptionElement{
+		{Name: "foo", Value: "1", IsParenthesized: true},
+		{Name: "bar", Value: "b", IsParenthesized: true},
+		{Name: "baz", Value: "true", IsParenthesized: true},
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected: %v, Actual: %v", expected, actual)
+	}
+}
+
+// TestParseIntegerLiterals ensures that the tags, enum values, extension & reserved
+// ranges can be written as hexadecimal and octal literals as well.
+func TestParseIntegerLiterals(t *testing.T) {
+	proto := `syntax = "proto2";
+package lits;
+message Task {
+  optional string id = 0x1F;
+  optional string name = 017;
+  extensions 0X64 to 0310;
+  reserved 0x10 to 020, 9;
+}
+enum State {
+  UNKNOWN = 0;
+  STARTED = 0x10;
+  STOPPED = 010;
+}`
+	pf, err := pbparser.Parse(strings.NewReader(proto), nil)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	msg, en := pf.Messages[0], pf.Enums[0]
+	actual := []int{msg.Fields[0].Tag, msg.Fields[1].Tag, msg.Extensions[0].Start, msg.Extensions[0].End,
+		msg.ReservedRanges[0].Start, msg.ReservedRanges[0].End, msg.ReservedRanges[1].Start,
+		en.EnumConstants[0].Tag, en.EnumConstants[1].Tag, en.EnumConstants[2].Tag}
+	expected := []int{31, 15, 100, 200, 16, 16, 9, 0, 16, 8}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected: %v, Actual: %v", expected, actual)
+	}
+
+	for _, lit := range []string{"0x", "0xG1", "019", "1a"} {
+		_, err := pbparser.Parse(strings.NewReader("syntax = \"proto3\";\nmessage Task {\n  string id = "+lit+";\n}\n"), nil)
+		expected := "Invalid integer literal: '" + lit + "' on line: 3, column: " + strconv.Itoa(14+len(lit))
+		if err == nil || err.Error() != expected {
+			t.Errorf("Expected error: %v, Actual: %v", expected, err)
+		}
+	}
+}
+
+// TestParseEnumReserved ensures that the values & names reserved within enums are
+// parsed into the model; and that the constants can not use them.
+func TestParseEnumReserved(t *testing.T) {
+	proto := `syntax = "proto3";
+package reserved;
+enum State {
+  STATE_UNKNOWN = 0;
+  reserved 2, 15, 9 to 11, 100 to max;
+  reserved "FOO", "BAR";
+  STATE_ACTIVE = 1;
+}`
+	pf, err := pbparser.Parse(strings.NewReader(proto), nil)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	en := pf.Enums[0]
+	expected := []pbparser.ReservedRangeElement{{Start: 2, End: 2}, {Start: 15, End: 15}, {Start: 9, End: 11}, {Start: 100, End: 2147483647}}
+	if !reflect.DeepEqual(expected, en.ReservedRanges) {
+		t.Errorf("Expected: %v, Actual: %v", expected, en.ReservedRanges)
+	}
+	if !reflect.DeepEqual([]string{"FOO", "BAR"}, en.ReservedNames) {
+		t.Errorf("Expected: [FOO BAR], Actual: %v", en.ReservedNames)
+	}
+	if len(en.EnumConstants) != 2 {
+		t.Errorf("Expected 2 enum constants, Actual: %v", len(en.EnumConstants))
+	}
+
+	_, err = pbparser.Parse(strings.NewReader(strings.Replace(proto, "STATE_ACTIVE", "BAR", 1)), nil)
+	if ve, ok := err.(*pbparser.ValidationError); !ok || ve.Code != pbparser.ReservedNameCode {
+		t.Errorf("Expected a ReservedNameCode error, Actual: %v", err)
+	}
+}
+
+// TestParseEnumConstantsNamedAfterKeywords ensures that enum constants can be named
+// after keywords; the options of the enum being told apart by the missing '='.
+func TestParseEnumConstantsNamedAfterKeywords(t *testing.T) {
+	proto := `syntax = "proto3";
+package keywords;
+enum Keyword {
+  option allow_alias = true;
+  option = 0;
+  reserved = 1;
+  message = 2;
+  MESSAGE = 2;
+}`
+	pf, err := pbparser.Parse(strings.NewReader(proto), nil)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	ee := pf.Enums[0]
+	if len(ee.Options) != 1 || ee.Options[0].Name != "allow_alias" {
+		t.Errorf("Expected the option allow_alias, Actual: %v", ee.Options)
+	}
+	var actual []string
+	for _, ec := range ee.EnumConstants {
+		actual = append(actual, ec.Name)
+	}
+	expected := []string{"option", "reserved", "message", "MESSAGE"}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected: %v, Actual: %v", expected, actual)
+	}
+}
+
+// enumTags renders the tags (given as {N}) of the enum constants in the given proto;
+// as octal literals if slow is true (which are left to the general path by the fast
+// path for the simple constants), else as decimal ones padded to the same width.
+func enumTags(proto string, slow bool) string {
+	return regexp.MustCompile(`\{(\d+)\}`).ReplaceAllStringFunc(proto, func(s string) string {
+		n, _ := strconv.Atoi(s[1 : len(s)-1])
+		octal := "0" + strconv.FormatInt(int64(n), 8)
+		if slow {
+			return octal
+		}
+		return fmt.Sprintf("%*d", len(octal), n)
+	})
+}
+
+// TestParseSimpleEnumConstants ensures that the simple enum constants (which are
+// read straight off the buffered input) are parsed just as the general path parses
+// them; the positions reported in the errors and the comments included.
+func TestParseSimpleEnumConstants(t *testing.T) {
+	var tests = []struct {
+		name     string
+		proto    string
+		errorstr string
+	}{
+		{
+			name:     "error after simple constants",
+			proto:    "syntax = \"proto3\";\nenum E {\n  A = {0};\n  B = {1};\n  C = x;\n}\n",
+			errorstr: "Unable to read tag for Enum Constant: C due to: Invalid integer literal: 'x' on line: 5, column: 7",
+		},
+		{
+			name:     "error on the line following a simple constant",
+			proto:    "syntax = \"proto3\";\nenum E {\n  A = {0};\n  B = {1} x;\n}\n",
+			errorstr: "Expected ';', but found: 'x' on line: 4, column: 10",
+		},
+		{
+			name:     "error after simple constants (CRLF)",
+			proto:    "syntax = \"proto3\";\r\nenum E {\r\n  A = {0};\r\n  B = {1};\r\n  C = x;\r\n}\r\n",
+			errorstr: "Unable to read tag for Enum Constant: C due to: Invalid integer literal: 'x' on line: 5, column: 7",
+		},
+		{
+			name:  "trailing comments",
+			proto: "syntax = \"proto3\";\nenum E {\n  A = {0}; // on the same line\n  B = {1};\n  // on the next line\n\n  C = {2};\n  D = {3};    \n  /* block */\n}\n",
+		},
+		{
+			name:  "leading comments",
+			proto: "syntax = \"proto3\";\nenum E {\n  // doc of A\n  A = {0};\n\n  // doc of B\n  // continued\n  B = {1};\n  C = {2};\n}\n",
+		},
+		{
+			name:  "options",
+			proto: "syntax = \"proto3\";\nenum E {\n  A = {0};\n  B = {1} [deprecated = true];\n  C = {2};\n}\n",
+		},
+		{
+			name:  "CRLF",
+			proto: "syntax = \"proto3\";\r\nenum E {\r\n  A = {0};\r\n  // doc of B\r\n  B = {1}; \r\n  // trailing B\r\n\r\n  C = {2};\r\n}\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		fast, fastErr := pbparser.Parse(strings.NewReader(enumTags(tt.proto, false)), nil)
+		slow, slowErr := pbparser.Parse(strings.NewReader(enumTags(tt.proto, true)), nil)
+		if tt.errorstr != "" {
+			if fastErr == nil || fastErr.Error() != tt.errorstr {
+				t.Errorf("Case: %v, Expected error: %v, Actual: %v", tt.name, tt.errorstr, fastErr)
+			}
+			if slowErr == nil || slowErr.Error() != tt.errorstr {
+				t.Errorf("Case: %v, Expected error (general path): %v, Actual: %v", tt.name, tt.errorstr, slowErr)
+			}
+			continue
+		}
+		if fastErr != nil || slowErr != nil {
+			t.Errorf("Case: %v, Unexpected errors: %v, %v", tt.name, fastErr, slowErr)
+			continue
+		}
+		for _, d := range pbparsertest.Diff(slow, fast) {
+			t.Errorf("Case: %v, %v", tt.name, d)
+		}
+	}
+
+	// the specifics which the two paths agree upon...
+	pf, err := pbparser.Parse(strings.NewReader(enumTags(tests[3].proto, false)), nil)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	var actual []string
+	for _, ec := range pf.Enums[0].EnumConstants {
+		actual = append(actual, ec.Documentation.Trailing)
+	}
+	expected := []string{"on the same line", "on the next line", "", "block"}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected trailing comments: %q, Actual: %q", expected, actual)
+	}
+
+	pf, err = pbparser.Parse(strings.NewReader(enumTags(tests[4].proto, false)), nil)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	actual = nil
+	for _, ec := range pf.Enums[0].EnumConstants {
+		actual = append(actual, ec.Documentation.Leading)
+	}
+	expected = []string{"doc of A", "doc of B continued", ""}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected leading comments: %q, Actual: %q", expected, actual)
+	}
+
+	pf, err = pbparser.Parse(strings.NewReader(enumTags(tests[5].proto, false)), nil)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	if ecs := pf.Enums[0].EnumConstants; len(ecs) != 3 || len(ecs[1].Options) != 1 || ecs[1].Options[0].Name != "deprecated" {
+		t.Errorf("Expected the option deprecated on constant B, Actual: %v", ecs)
+	}
+}
+
+// TestParseFullyQualifiedTypes ensures that the types referenced by their fully
+// qualified names (with a leading dot) are resolved from the root scope; both the
+// ones of the same package & the ones of the imported packages.
+func TestParseFullyQualifiedTypes(t *testing.T) {
+	proto := `syntax = "proto3";
+package mypkg;
+import "money.proto";
+message Outer {
+  message Inner {}
+  .mypkg.Outer.Inner inner = 1;
+  .money.Amount.Currency currency = 2;
+}
+service Prices {
+  rpc Get (.mypkg.Outer) returns (.money.Amount);
+  rpc Watch (stream .money.Amount) returns (stream .mypkg.Outer.Inner);
+}`
+	impr := mapImportModuleProvider{
+		"money.proto": "syntax = \"proto3\";\npackage money;\nmessage Amount {\n  enum Currency {\n    USD = 0;\n  }\n}\n",
+	}
+	pf, err := pbparser.Parse(strings.NewReader(proto), impr)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	if ndt := pf.Messages[0].Fields[0].Type.(pbparser.NamedDataType); !ndt.IsFullyQualified() || ndt.Name() != ".mypkg.Outer.Inner" {
+		t.Errorf("Expected a fully qualified type .mypkg.Outer.Inner, Actual: %v", ndt)
+	}
+	if rt := pf.Services[0].RPCs[1].RequestType; !rt.IsStream() || !rt.IsFullyQualified() {
+		t.Errorf("Expected a fully qualified stream request type, Actual: %v", rt)
+	}
+
+	var tests = []struct {
+		replace  string
+		with     string
+		expected string
+	}{
+		{replace: ".mypkg.Outer.Inner inner", with: ".Outer.Inner inner", expected: "Datatype: '.Outer.Inner' referenced in field: 'inner' is not defined"},
+		{replace: ".money.Amount.Currency", with: ".Amount.Currency", expected: "Datatype: '.Amount.Currency' referenced in field: 'currency' is not defined"},
+		{replace: "returns (.money.Amount)", with: "returns (.money.Amount.Currency)",
+			expected: "Datatype: '.money.Amount.Currency' referenced in RPC: 'Get' of Service: 'Prices' is not defined OR is not a message type"},
+	}
+	for _, tt := range tests {
+		_, err := pbparser.Parse(strings.NewReader(strings.Replace(proto, tt.replace, tt.with, 1)), impr)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("Expected error: %v, Actual: %v", tt.expected, err)
+		}
+	}
+}
+
+// TestParseKeywordIdentifiers ensures that the fields & messages can be named after
+// the keywords; and that the fields can be of the types named after the keywords.
+func TestParseKeywordIdentifiers(t *testing.T) {
+	proto := `syntax = "proto3";
+package kw;
+message service {
+  string message = 1;
+  string option = 2;
+  int32 oneof = 3;
+}
+message map {}
+message Holder {
+  option deprecated = true;
+  service service = 1;
+  map map = 2;
+  map<string, map> maps = 3;
+  oneof choice {
+    service svc = 4;
+  }
+  reserved 10;
+}
+service Services {
+  rpc Get (service) returns (service);
+}`
+	pf, err := pbparser.Parse(strings.NewReader(proto), nil)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	expected := pbparsertest.File("kw").
+		Msg(pbparsertest.Msg("service").
+			Field("message", pbparsertest.String, 1).
+			Field("option", pbparsertest.String, 2).
+			Field("oneof", pbparsertest.Int32, 3)).
+		Msg(pbparsertest.Msg("map")).
+		Msg(pbparsertest.Msg("Holder").
+			Option("deprecated", "true").
+			Field("service", pbparsertest.Named("service"), 1).
+			Field("map", pbparsertest.Named("map"), 2).
+			Field("maps", pbparsertest.Map(pbparsertest.String, pbparsertest.Named("map")), 3).
+			OneOf("choice", pbparsertest.F("svc", pbparsertest.Named("service"), 4)).
+			Reserved(10, 10)).
+		Service(pbparsertest.Service("Services").
+			RPC("Get", pbparsertest.Named("service"), pbparsertest.Named("service"))).
+		Build()
+	pbparsertest.AssertEqual(t, expected, pf)
+}
+
+// TestParseWeakImports ensures that the weak imports are parsed alongside the plain
+// & public ones; and that those are not required to be used.
+func TestParseWeakImports(t *testing.T) {
+	proto := `syntax = "proto3";
+package shop;
+import "money.proto";
+import public "audit.proto";
+import weak "legacy.proto";
+message Item {
+  money.Amount price = 1;
+  audit.Trail trail = 2;
+}`
+	impr := mapImportModuleProvider{
+		"money.proto":  "syntax = \"proto3\";\npackage money;\nmessage Amount {}\n",
+		"audit.proto":  "syntax = \"proto3\";\npackage audit;\nmessage Trail {}\n",
+		"legacy.proto": "syntax = \"proto3\";\npackage legacy;\nmessage Old {}\n",
+	}
+	pf, err := pbparser.Parse(strings.NewReader(proto), impr)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	expected := pbparsertest.File("shop").
+		Import("money.proto").
+		PublicImport("audit.proto").
+		WeakImport("legacy.proto").
+		Msg(pbparsertest.Msg("Item").
+			Field("price", pbparsertest.Named("money.Amount"), 1).
+			Field("trail", pbparsertest.Named("audit.Trail"), 2)).
+		Build()
+	pbparsertest.AssertEqual(t, expected, pf)
+
+	// a plain import which is not used is still rejected...
+	_, err = pbparser.Parse(strings.NewReader(strings.Replace(proto, "import weak", "import", 1)), impr)
+	if err == nil || err.Error() != "Imported package: legacy but not used" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+// TestParseGroups ensures that a group is modelled as a field of the type of the
+// message nested within its scope; both within messages and extend declarations.
+func TestParseGroups(t *testing.T) {
+	proto := `syntax = "proto2";
+package search;
+message SearchResponse {
+  // The results.
+  repeated group Result = 1 [deprecated = true] {
+    required string url = 2;
+    optional string title = 3;
+  }
+  optional int32 total = 4;
+  extensions 100 to 199;
+}
+extend SearchResponse {
+  optional group Summary = 100 {
+    optional string text = 1;
+  }
+}`
+	pf, err := pbparser.Parse(strings.NewReader(proto), nil)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	summary := pbparsertest.F("summary", pbparsertest.Named("Summary"), 100)
+	summary.Label, summary.Group = pbparser.LabelOptional, true
+
+	expected := pbparsertest.File("search").Syntax(pbparser.SyntaxProto2).
+		Msg(pbparsertest.Msg("SearchResponse").
+			Group(pbparser.LabelRepeated, 1, pbparsertest.Msg("Result").
+				Required("url", pbparsertest.String, 2).
+				Optional("title", pbparsertest.String, 3),
+				pbparsertest.Opt("deprecated", "true")).
+			Optional("total", pbparsertest.Int32, 4)).
+		Msg(pbparsertest.Msg("Summary").
+			Optional("text", pbparsertest.String, 1)).
+		Build()
+	expected.Messages[0].Fields[0].Documentation.Leading = "The results."
+	expected.Messages[0].Extensions = []pbparser.ExtensionsElement{{Start: 100, End: 199}}
+	expected.ExtendDeclarations = []pbparser.ExtendElement{{Name: "SearchResponse", QualifiedName: "search.SearchResponse", Fields: []pbparser.FieldElement{summary}}}
+	pbparsertest.AssertEqual(t, expected, pf)
+
+	_, err = pbparser.Parse(strings.NewReader(strings.Replace(proto, "group Result", "group result", 1)), nil)
+	if err == nil || err.Error() != "Group names must start with a capital letter. Found: result on line: 5" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+// TestParseStringEscapes ensures that the escape sequences within string literals
+// are replaced by what they stand for.
+func TestParseStringEscapes(t *testing.T) {
+	proto := `syntax = "proto3";
+package strs;
+option (my.opt) = "line1\nline2 \"quoted\"";
+option (my.bytes) = "\x41\101\0\377\t\\\'\?";
+option (my.unicode) = "\u00e9\U0001F600";
+message Item {
+  string id = 1 [(my.f) = "a\",b"];
+  reserved "\x66oo";
+}`
+	pf, err := pbparser.Parse(strings.NewReader(proto), nil)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	expected := pbparsertest.File("strs").
+		Option("(my.opt)", "line1\nline2 \"quoted\"").
+		Option("(my.bytes)", "AA\x00\xff\t\\'?").
+		Option("(my.unicode)", "\u00e9\U0001F600").
+		Msg(pbparsertest.Msg("Item").
+			Field("id", pbparsertest.String, 1, pbparsertest.Opt("(my.f)", `a",b`)).
+			ReservedNames("foo")).
+		Build()
+	pbparsertest.AssertEqual(t, expected, pf)
+
+	_, err = pbparser.Parse(strings.NewReader(`syntax = "proto3";
+message Item {
+  reserved "a b";
+}`), nil)
+	if err == nil || err.Error() != "Reserved name 'a b' is not a valid identifier on line: 3" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+// TestParseListOptions ensures that the options within brackets are split at the
+// commas (and '=') which are not nested within quoted strings, braces etc.
+func TestParseListOptions(t *testing.T) {
+	proto := `syntax = "proto2";
+package opts;
+message Item {
+  optional string id = 1 [(gogoproto.customtype) = "github.com/foo/bar.UUID", default = "a,b=c"];
+  repeated int32 ids = 2 [deprecated=true,packed=true];
+  optional string name = 3 [default = "x\"],y", (my.o) = { a: "1,2" b: [1, 2] }];
+}`
+	pf, err := pbparser.Parse(strings.NewReader(proto), nil)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	expected := pbparsertest.File("opts").Syntax(pbparser.SyntaxProto2).
+		Msg(pbparsertest.Msg("Item").
+			Optional("id", pbparsertest.String, 1,
+				pbparsertest.Opt("(gogoproto.customtype)", "github.com/foo/bar.UUID"),
+				pbparsertest.Opt("default", "a,b=c")).
+			Repeated("ids", pbparsertest.Int32, 2, pbparsertest.Opt("deprecated", "true"), pbparsertest.Opt("packed", "true")).
+			Optional("name", pbparsertest.String, 3,
+				pbparsertest.Opt("default", `x"],y`),
+				pbparsertest.Opt("(my.o)", `{ a: "1,2" b: [1, 2] }`))).
+		Build()
+	pbparsertest.AssertEqual(t, expected, pf)
+}
+
+// TestParseOptionFieldPaths ensures that the path of the field following the name of
+// a custom option is captured; both for the options & the list options.
+func TestParseOptionFieldPaths(t *testing.T) {
+	proto := `syntax = "proto3";
+package shop;
+option (my.custom.opt).nested.field = 42;
+message Item {
+  option (my.msg).(my.ext).name = "item";
+  string id = 1 [(validate.rules).string.min_len = 1, (validate.rules).string.max_len = 64];
+}`
+	pf, err := pbparser.Parse(strings.NewReader(proto), nil)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	expected := pbparsertest.File("shop").
+		Option("(my.custom.opt).nested.field", "42").
+		Msg(pbparsertest.Msg("Item").
+			Option("(my.msg).(my.ext).name", "item").
+			Field("id", pbparsertest.String, 1,
+				pbparsertest.Opt("(validate.rules).string.min_len", "1"),
+				pbparsertest.Opt("(validate.rules).string.max_len", "64"))).
+		Build()
+	pbparsertest.AssertEqual(t, expected, pf)
+
+	if name := pf.Messages[0].Fields[0].Options[1].FullName(); name != "(validate.rules).string.max_len" {
+		t.Errorf("Expected: (validate.rules).string.max_len, Actual: %v", name)
+	}
+}
+
+// TestParseListOptionValues ensures that list & aggregate values of options are
+// captured verbatim; including lists nested within aggregates and vice versa.
+func TestParseListOptionValues(t *testing.T) {
+	proto := `syntax = "proto3";
+package opts;
+option (my.tags) = ["a", "b", "c"];
+option (my.limits) = { ids: [1, 2, 3] name: "x" };
+service Items {
+  rpc Get (Task) returns (Task) {
+    option (google.api.http) = {
+      get: "/v1/items/{id}"
+      additional_bindings: [{ get: "/v1/tasks/{id}" }, { post: "/v1/items:get" body: "*" }]
+    };
+  }
+}
+message Task {
+  string id = 1 [(my.ids) = [1, 2], (my.rule) = { in: ["a", "b"] }];
+}`
+	pf, err := pbparser.Parse(strings.NewReader(proto), nil)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+
+	var tests = []struct {
+		actual   pbparser.OptionElement
+		expected string
+	}{
+		{actual: pf.Options[0], expected: `["a", "b", "c"]`},
+		{actual: pf.Options[1], expected: `{ ids: [1, 2, 3] name: "x" }`},
+		{actual: pf.Services[0].RPCs[0].Options[0], expected: "{\n      get: \"/v1/items/{id}\"\n      additional_bindings: " +
+			`[{ get: "/v1/tasks/{id}" }, { post: "/v1/items:get" body: "*" }]` + "\n    }"},
+		{actual: pf.Messages[0].Fields[0].Options[0], expected: "[1, 2]"},
+		{actual: pf.Messages[0].Fields[0].Options[1], expected: `{ in: ["a", "b"] }`},
+	}
+	for _, tt := range tests {
+		if tt.actual.Value != tt.expected {
+			t.Errorf("Option: %v, Expected value: %q, Actual: %q", tt.actual.Name, tt.expected, tt.actual.Value)
+		}
+	}
+}
+
+// TestParseFile verifies the model parsed out of each of the given files against
+// the golden dump (under resources/golden) of the file; run the tests with the
+// PBPARSER_UPDATE_GOLDEN environment variable set to regenerate the golden dumps
+// after an intended change.
 func TestParseFile(t *testing.T) {
 	var tests = []struct {
 		file string
@@ -124,159 +806,442 @@ func TestParseFile(t *testing.T) {
 		{file: "./resources/descriptor.proto"},
 		{file: "./resources/dep/dependent.proto"},
 		{file: "./resources/dep/dependent2.proto"},
+		{file: "./resources/dep/packageless-dependent.proto"},
+		{file: "./resources/extension-declarations.proto"},
+		{file: "./resources/comments.proto"},
+		{file: "./resources/integer-message.proto"},
+		{file: "./resources/bom.proto"},
 	}
 
-	for i, tt := range tests {
-		fmt.Printf("Running test: %v \n\n", i)
-
-		fmt.Printf("Parsing file: %v \n", tt.file)
+	for _, tt := range tests {
 		pf, err := pbparser.ParseFile(tt.file)
 		if err != nil {
 			t.Errorf("%v", err.Error())
 			continue
 		}
+		golden := "./resources/golden/" + strings.TrimSuffix(filepath.Base(tt.file), ".proto") + ".txt"
+		pbparsertest.AssertGoldenModel(t, golden, pf)
+	}
+}
 
-		fmt.Println("Syntax: " + pf.Syntax)
-		fmt.Println("PackageName: " + pf.PackageName)
-		for _, d := range pf.Dependencies {
-			fmt.Println("Dependency: " + d)
-		}
-		for _, d := range pf.PublicDependencies {
-			fmt.Println("PublicDependency: " + d)
-		}
-		options(pf.Options, "")
+// TestParseFileBuilt verifies the model parsed out of a file against one built by hand.
+func TestParseFileBuilt(t *testing.T) {
+	pf, err := pbparser.Parse(strings.NewReader(`syntax = "proto3";
+package shop;
+option go_package = "example.com/shop";
 
-		for _, m := range pf.Messages {
-			printMessage(&m, "")
-		}
+message Item {
+  int32 id = 1;
+  repeated string tags = 2 [(shop.indexed) = true];
+  oneof price {
+    int64 cents = 3;
+    string text = 4;
+  }
+  enum State {
+    STATE_UNKNOWN = 0;
+    STATE_LISTED = 1;
+  }
+  map<string, Item> related = 5;
+  reserved 8 to 10;
+}
 
-		for _, ed := range pf.ExtendDeclarations {
-			fmt.Println("Extend: " + ed.Name)
-			fmt.Println("QualifiedName: " + ed.QualifiedName)
-			doc(ed.Documentation, "")
-			fields(ed.Fields, tab)
+service Catalog {
+  rpc Watch (Item) returns (stream Item);
+}
+`), nil)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+
+	expected := pbparsertest.File("shop").
+		Option("go_package", "example.com/shop").
+		Msg(pbparsertest.Msg("Item").
+			Field("id", pbparsertest.Int32, 1).
+			Repeated("tags", pbparsertest.String, 2, pbparsertest.Opt("(shop.indexed)", "true")).
+			OneOf("price",
+				pbparsertest.F("cents", pbparsertest.Int64, 3),
+				pbparsertest.F("text", pbparsertest.String, 4)).
+			Enum(pbparsertest.Enum("State").Const("STATE_UNKNOWN", 0).Const("STATE_LISTED", 1)).
+			Field("related", pbparsertest.Map(pbparsertest.String, pbparsertest.Named("Item")), 5).
+			Reserved(8, 10)).
+		Service(pbparsertest.Service("Catalog").RPC("Watch", pbparsertest.Named("Item"), pbparsertest.Stream("Item"))).
+		Build()
+	pbparsertest.AssertEqual(t, expected, pf)
+}
+
+// TestParseFileLateSyntax verifies that a syntax statement appearing after other
+// statements is only accepted when asked for.
+func TestParseFileLateSyntax(t *testing.T) {
+	const file = errResourceDir + "late-syntax.proto"
+
+	if _, err := pbparser.ParseFile(file); err == nil {
+		t.Errorf("File: %v, expected an error for the late syntax statement", file)
+	}
+
+	pf, err := pbparser.ParseFile(file, pbparser.WithLateSyntax())
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	if pf.Syntax != "proto3" || pf.PackageName != "late" {
+		t.Errorf("Expected syntax proto3 & package late, found: %v & %v", pf.Syntax, pf.PackageName)
+	}
+}
+
+// TestFieldPredicates verifies the labels, presence & oneof membership of the parsed fields.
+func TestFieldPredicates(t *testing.T) {
+	pf, err := pbparser.ParseFile("./resources/fields.proto")
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+
+	var tests = []struct {
+		name           string
+		label          string
+		repeated       bool
+		isMap          bool
+		oneof          string
+		proto3Optional bool
+	}{
+		{name: "id", label: pbparser.LabelNone},
+		{name: "owner", label: pbparser.LabelOptional, proto3Optional: true},
+		{name: "tags", label: pbparser.LabelRepeated, repeated: true},
+		{name: "subtasks", label: pbparser.LabelNone, isMap: true},
+		{name: "timestamp", label: pbparser.LabelNone, oneof: "due"},
+		{name: "date", label: pbparser.LabelNone, oneof: "due"},
+	}
+
+	me := pf.Messages[0]
+	fields := append(append([]pbparser.FieldElement{}, me.Fields...), me.OneOfs[0].Fields...)
+	for i, tt := range tests {
+		f := fields[i]
+		if f.Name != tt.name || f.Label != tt.label || f.IsRepeated() != tt.repeated || f.IsRequired() ||
+			f.IsMap() != tt.isMap || f.OneOf != tt.oneof || f.IsOneOfMember() != (tt.oneof != "") ||
+			f.Proto3Optional != tt.proto3Optional {
+			t.Errorf("Field: %v, Unexpected field: %+v", tt.name, f)
 		}
+	}
 
-		for _, s := range pf.Services {
-			fmt.Println("Service: " + s.Name)
-			fmt.Println("QualifiedName: " + s.QualifiedName)
-			doc(s.Documentation, "")
-			options(s.Options, "")
-			for _, rpc := range s.RPCs {
-				printRPC(&rpc)
-			}
+	// the explicit optional label is rejected in the strict mode...
+	_, err = pbparser.ParseFile("./resources/fields.proto", pbparser.WithStrictProto3Labels())
+	if err == nil || !strings.HasPrefix(err.Error(), "Explicit 'optional' labels are disallowed in the proto3 syntax") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+// TestFieldJSONName mirrors the edge cases of protoc's derivation of JSON names.
+func TestFieldJSONName(t *testing.T) {
+	var tests = []struct {
+		field    pbparser.FieldElement
+		expected string
+	}{
+		{field: pbparser.FieldElement{Name: "foo_bar"}, expected: "fooBar"},
+		{field: pbparser.FieldElement{Name: "_foo_bar"}, expected: "FooBar"},
+		{field: pbparser.FieldElement{Name: "foo_bar_"}, expected: "fooBar"},
+		{field: pbparser.FieldElement{Name: "foo__bar"}, expected: "fooBar"},
+		{field: pbparser.FieldElement{Name: "foo_1bar"}, expected: "foo1bar"},
+		{field: pbparser.FieldElement{Name: "foo_1_bar"}, expected: "foo1Bar"},
+		{field: pbparser.FieldElement{Name: "fooBar"}, expected: "fooBar"},
+		{field: pbparser.FieldElement{Name: "FooBar"}, expected: "FooBar"},
+		{field: pbparser.FieldElement{Name: "foo_Bar"}, expected: "fooBar"},
+		{
+			field: pbparser.FieldElement{
+				Name:    "foo_bar",
+				Options: []pbparser.OptionElement{{Name: "json_name", Value: "custom"}},
+			},
+			expected: "custom",
+		},
+		{
+			field: pbparser.FieldElement{
+				Name:    "foo_bar",
+				Options: []pbparser.OptionElement{{Name: "json_name", Value: "custom", IsParenthesized: true}},
+			},
+			expected: "fooBar",
+		},
+	}
+
+	for _, tt := range tests {
+		if actual := tt.field.JSONName(); actual != tt.expected {
+			t.Errorf("Expected JSON name of %v: %v, Actual: %v", tt.field.Name, tt.expected, actual)
 		}
+	}
+}
+
+// TestStrictFieldNumbers ensures that the field numbers are verified only when
+// asked to.
+func TestStrictFieldNumbers(t *testing.T) {
+	var tests = []struct {
+		file     string
+		code     pbparser.ValidationCode
+		errorstr string
+	}{
+		{
+			file:     "dup-field-tag.proto",
+			code:     pbparser.DuplicateFieldNumberCode,
+			errorstr: "Field number 1 has already been used in message dup.Outer.Inner by field 'name'",
+		},
+		{
+			file:     "field-in-reserved.proto",
+			code:     pbparser.UnavailableFieldNumberCode,
+			errorstr: "Field 'alias' in message dup.Outer uses an unavailable number. Reason:: Tag 3 is within the reserved range 2 to 4",
+		},
+	}
 
-		for _, en := range pf.Enums {
-			printEnum(&en, "")
+	for _, tt := range tests {
+		if _, err := pbparser.ParseFile(errResourceDir + tt.file); err != nil {
+			t.Errorf("File: %v, Unexpected error: %v", tt.file, err.Error())
+		}
+		_, err := pbparser.ParseFile(errResourceDir+tt.file, pbparser.WithStrictFieldNumbers())
+		ve, ok := err.(*pbparser.ValidationError)
+		if !ok || ve.Code != tt.code || ve.Error() != tt.errorstr {
+			t.Errorf("File: %v, Expected error: %v, Actual: %v", tt.file, tt.errorstr, err)
 		}
 	}
 }
 
-func printMessage(m *pbparser.MessageElement, prefix string) {
-	fmt.Println(prefix + "Message: " + m.Name)
-	fmt.Println(prefix + "QualifiedName: " + m.QualifiedName)
-	doc(m.Documentation, prefix)
-	options(m.Options, prefix)
-	fields(m.Fields, prefix+tab)
-	for _, oo := range m.OneOfs {
-		fmt.Println(prefix + tab + "OneOff: " + oo.Name)
-		doc(oo.Documentation, prefix+tab)
-		options(oo.Options, prefix+tab)
-		fields(oo.Fields, prefix+tab2)
+// TestAllowAliasWarning verifies that setting allow_alias in an enum without any aliases raises a warning.
+func TestAllowAliasWarning(t *testing.T) {
+	pf, err := pbparser.ParseFile("./resources/allow-alias.proto")
+	if err != nil {
+		t.Fatalf("%v", err.Error())
 	}
-	for _, xe := range m.Extensions {
-		fmt.Printf("%vExtensions:: Start: %v End: %v\n", prefix+tab, xe.Start, xe.End)
-		doc(xe.Documentation, prefix+tab)
+	expected := "Option allow_alias is set in enum alias.Priority but none of its values are aliases"
+	if len(pf.Warnings) != 1 || pf.Warnings[0] != expected {
+		t.Errorf("Expected warnings: [%v], Actual: %v", expected, pf.Warnings)
 	}
-	for _, rn := range m.ReservedNames {
-		fmt.Println(prefix + tab + "Reserved Name: " + rn)
+}
+
+// TestDuplicateTypeWarnings verifies that types defined in both the main file & an import
+// are reported as warnings when asked to.
+func TestDuplicateTypeWarnings(t *testing.T) {
+	pf, err := pbparser.ParseFile(errResourceDir+"dup-type-import.proto", pbparser.WithDuplicateTypeWarnings())
+	if err != nil {
+		t.Fatalf("%v", err.Error())
 	}
-	for _, rr := range m.ReservedRanges {
-		fmt.Printf("%vReserved Range:: Start: %v to End: %v\n", prefix+tab, rr.Start, rr.End)
-		doc(rr.Documentation, prefix+tab)
+	expected := "Type dup.Config is defined in both dup-type-dep.proto and the main file"
+	if len(pf.Warnings) != 1 || pf.Warnings[0] != expected {
+		t.Errorf("Expected warnings: [%v], Actual: %v", expected, pf.Warnings)
 	}
-	for _, en := range m.Enums {
-		printEnum(&en, prefix+tab)
+	if len(pf.Messages) != 1 {
+		t.Errorf("Expected the imported Config to be shadowed, Actual: %v", pf.Messages)
 	}
-	for _, ed := range m.ExtendDeclarations {
-		fmt.Println(prefix + "Extend: " + ed.Name)
-		fmt.Println(prefix + "QualifiedName: " + ed.QualifiedName)
-		doc(ed.Documentation, prefix)
-		fields(ed.Fields, prefix+tab)
+
+	pf, err = pbparser.ParseFile(errResourceDir+"dup-type-import2.proto", pbparser.WithDuplicateTypeWarnings())
+	if err != nil {
+		t.Fatalf("%v", err.Error())
 	}
-	for _, nestedMsg := range m.Messages {
-		printMessage(&nestedMsg, prefix+tab)
+	expected = "Type dup.Config is defined in both dup-type-dep.proto and dup-type-dep2.proto"
+	if len(pf.Warnings) != 1 || pf.Warnings[0] != expected {
+		t.Errorf("Expected warnings: [%v], Actual: %v", expected, pf.Warnings)
 	}
 }
 
-func printRPC(rpc *pbparser.RPCElement) {
-	fmt.Println(tab + "RPC: " + rpc.Name)
-	doc(rpc.Documentation, tab)
-	if rpc.RequestType.IsStream() {
-		fmt.Println(tab + "RequestType: stream " + rpc.RequestType.Name())
-	} else {
-		fmt.Println(tab + "RequestType: " + rpc.RequestType.Name())
+// TestCrossSyntaxEnumWarning verifies that a default value for a field of an enum of a proto3
+// dependency in a proto2 file raises a warning.
+func TestCrossSyntaxEnumWarning(t *testing.T) {
+	pf, err := pbparser.ParseFile("./resources/dep/proto2-dependent.proto")
+	if err != nil {
+		t.Fatalf("%v", err.Error())
 	}
-	if rpc.ResponseType.IsStream() {
-		fmt.Println(tab + "ResponseType: stream " + rpc.ResponseType.Name())
-	} else {
-		fmt.Println(tab + "ResponseType: " + rpc.ResponseType.Name())
+	expected := "Field 'color' in message paint.Paint uses the proto3 enum mixed.Color with a default value"
+	if len(pf.Warnings) != 1 || pf.Warnings[0] != expected {
+		t.Errorf("Expected warnings: [%v], Actual: %v", expected, pf.Warnings)
 	}
-	options(rpc.Options, tab)
 }
 
-func printEnum(en *pbparser.EnumElement, prefix string) {
-	fmt.Println(prefix + "Enum: " + en.Name)
-	fmt.Println(prefix + "QualifiedName: " + en.QualifiedName)
-	doc(en.Documentation, prefix)
-	options(en.Options, prefix)
-	for _, enc := range en.EnumConstants {
-		fmt.Printf("%vName: %v Tag: %v\n", prefix+tab, enc.Name, enc.Tag)
-		doc(enc.Documentation, prefix+tab)
-		options(enc.Options, prefix+tab2)
+// TestSyntax verifies that the syntax statement is parsed into the typed Syntax.
+func TestSyntax(t *testing.T) {
+	var tests = []struct {
+		file   string
+		syntax pbparser.Syntax
+		proto3 bool
+	}{
+		{file: "./resources/enum.proto", syntax: pbparser.SyntaxProto3, proto3: true},
+		{file: "./resources/service.proto", syntax: pbparser.SyntaxProto2, proto3: false},
 	}
-}
 
-func options(options []pbparser.OptionElement, tab string) {
-	for _, op := range options {
-		if op.IsParenthesized {
-			fmt.Printf("%vOption:: (%v) = %v\n", tab, op.Name, op.Value)
-		} else {
-			fmt.Printf("%vOption:: %v = %v\n", tab, op.Name, op.Value)
+	for _, tt := range tests {
+		pf, err := pbparser.ParseFile(tt.file)
+		if err != nil {
+			t.Fatalf("%v", err.Error())
+		}
+		if pf.Syntax != tt.syntax || pf.Syntax.String() != string(tt.syntax) {
+			t.Errorf("File: %v, Expected syntax: %v, Actual: %v", tt.file, tt.syntax, pf.Syntax)
+		}
+		if pf.IsProto3() != tt.proto3 || pf.IsProto2() == tt.proto3 {
+			t.Errorf("File: %v, Expected IsProto3(): %v, Actual: %v/%v", tt.file, tt.proto3, pf.IsProto3(), pf.IsProto2())
 		}
 	}
 }
 
-func fields(fields []pbparser.FieldElement, tab string) {
-	for _, f := range fields {
-		fmt.Println(tab + "Field: " + f.Name)
-		if f.Label != "" {
-			fmt.Println(tab + "Label: " + f.Label)
-		}
-		fmt.Printf("%vType: %v\n", tab, f.Type.Name())
-		fmt.Printf("%vTag: %v\n", tab, f.Tag)
-		doc(f.Documentation, tab)
-		options(f.Options, tab+tab)
+// TestParseFileLenient verifies that in the lenient parsing mode, declarations
+// which the parser does not understand are captured as raw declarations while
+// the rest of the file is still parsed.
+func TestParseFileLenient(t *testing.T) {
+	const file = "./resources/lenient/unknown-declarations.proto"
+
+	if _, err := pbparser.ParseFile(file); err == nil {
+		t.Errorf("File: %v, expected an error when not parsing leniently", file)
+	}
+
+	pf, err := pbparser.ParseFile(file, pbparser.WithLenientParsing())
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+
+	if len(pf.RawDeclarations) != 1 {
+		t.Fatalf("Expected 1 raw declaration at file level, found: %v", len(pf.RawDeclarations))
+	}
+	rd := pf.RawDeclarations[0]
+	expected := "future_feature \"shiny\" {\n  enabled: true;\n  note: \"contains a } brace and a ; semicolon\";\n}"
+	if rd.Text != expected {
+		t.Errorf("Expected raw text: %q, found: %q", expected, rd.Text)
+	}
+	if rd.Position.Line != 6 || rd.Position.Column != 1 {
+		t.Errorf("Expected raw declaration at 6:1, found: %v:%v", rd.Position.Line, rd.Position.Column)
+	}
+	if rd.Documentation.Leading != "A statement from a future version of the language" {
+		t.Errorf("Unexpected documentation for raw declaration: %q", rd.Documentation.Leading)
+	}
+
+	if len(pf.Messages) != 1 {
+		t.Fatalf("Expected 1 message, found: %v", len(pf.Messages))
+	}
+	me := pf.Messages[0]
+	if len(me.Fields) != 2 || me.Fields[0].Name != "name" || me.Fields[1].Name != "id" {
+		t.Errorf("Expected fields 'name' and 'id' to be parsed, found: %v", me.Fields)
+	}
+	var texts []string
+	for _, rd := range me.RawDeclarations {
+		texts = append(texts, rd.Text)
+	}
+	if len(texts) != 2 || texts[0] != "features.field_presence = EXPLICIT;" || texts[1] != "@annotation(value = \"x\");" {
+		t.Errorf("Unexpected raw declarations in message: %q", texts)
 	}
 }
 
-func doc(s string, tab string) {
-	if s != "" {
-		fmt.Println(tab + "Doc: " + s)
+// TestParseExtensionDeclarations verifies that the options specified on an
+// extensions statement, including message literal values, are parsed.
+func TestParseExtensionDeclarations(t *testing.T) {
+	pf, err := pbparser.ParseFile("./resources/extension-declarations.proto")
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+
+	xes := pf.Messages[0].Extensions
+	if len(xes) != 2 {
+		t.Fatalf("Expected 2 extensions, found: %v", len(xes))
+	}
+	if xes[0].Start != 4 || xes[0].End != 1000 || len(xes[0].Options) != 2 {
+		t.Fatalf("Unexpected extensions: %+v", xes[0])
+	}
+	if xes[0].Options[0].Name != "declaration" || !strings.Contains(xes[0].Options[0].Value, `full_name: ".extdecl.bar"`) {
+		t.Errorf("Unexpected declaration option: %+v", xes[0].Options[0])
+	}
+	if xes[0].Options[1].Name != "verification" || xes[0].Options[1].Value != "DECLARATION" {
+		t.Errorf("Unexpected verification option: %+v", xes[0].Options[1])
+	}
+	if xes[1].Start != 2000 || xes[1].End != 536870911 || len(xes[1].Options) != 0 {
+		t.Errorf("Unexpected extensions: %+v", xes[1])
 	}
 }
 
-func indent(i int) string {
-	s := " "
-	for j := 0; j < i; j++ {
-		s += " "
+// TestParseReservedMax verifies that 'max' stands for the largest field number as
+// the upper bound of a reserved range within a message.
+func TestParseReservedMax(t *testing.T) {
+	proto := `syntax = "proto3";
+package res;
+message Item {
+  string id = 1;
+  reserved 5, 1000 to max;
+}`
+	pf, err := pbparser.Parse(strings.NewReader(proto), nil)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	expected := pbparsertest.File("res").
+		Msg(pbparsertest.Msg("Item").
+			Field("id", pbparsertest.String, 1).
+			Reserved(5, 5).
+			Reserved(1000, 536870911)).
+		Build()
+	pbparsertest.AssertEqual(t, expected, pf)
+
+	_, err = pbparser.Parse(strings.NewReader(strings.Replace(proto, "to max", "to maximum", 1)), nil)
+	if err == nil || !strings.HasPrefix(err.Error(), "Invalid integer literal: 'maximum'") {
+		t.Errorf("Unexpected error: %v", err)
 	}
-	return s
 }
 
-// init the tabs...
-var (
-	tab  = indent(2)
-	tab2 = indent(4)
-)
+// TestParseExtensionRanges verifies that an extensions statement can declare several
+// comma separated ranges; the options of the statement applying to all of them.
+func TestParseExtensionRanges(t *testing.T) {
+	proto := `syntax = "proto2";
+package ext;
+message Item {
+  extensions 4, 20 to 30, 100 to max;
+  extensions 40,50 [verification = UNVERIFIED];
+}`
+	pf, err := pbparser.Parse(strings.NewReader(proto), nil)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	verification := []pbparser.OptionElement{{Name: "verification", Value: "UNVERIFIED"}}
+	expected := []pbparser.ExtensionsElement{
+		{Start: 4, End: 4},
+		{Start: 20, End: 30},
+		{Start: 100, End: 536870911},
+		{Start: 40, End: 40, Options: verification},
+		{Start: 50, End: 50, Options: verification},
+	}
+	if !reflect.DeepEqual(expected, pf.Messages[0].Extensions) {
+		t.Errorf("Expected: %+v, Actual: %+v", expected, pf.Messages[0].Extensions)
+	}
+
+	_, err = pbparser.Parse(strings.NewReader(strings.Replace(proto, "4, 20", "4, to", 1)), nil)
+	if err == nil || !strings.HasPrefix(err.Error(), "Invalid integer literal: 'to'") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+// TestParseComments verifies that comments are attached to the constructs
+// following the same rules as protoc.
+func TestParseComments(t *testing.T) {
+	pf, err := pbparser.ParseFile("./resources/comments.proto")
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+
+	var tests = []struct {
+		name     string
+		actual   pbparser.Documentation
+		expected pbparser.Documentation
+	}{
+		{name: "Foo", actual: pf.Messages[0].Documentation,
+			expected: pbparser.Documentation{Leading: "Leading comment for Foo.", Trailing: "Trailing comment for Foo."}},
+		{name: "a", actual: pf.Messages[0].Fields[0].Documentation,
+			expected: pbparser.Documentation{Trailing: "Trailing comment for a."}},
+		{name: "b", actual: pf.Messages[0].Fields[1].Documentation,
+			expected: pbparser.Documentation{Leading: "Leading comment for b."}},
+		{name: "c", actual: pf.Messages[0].Fields[2].Documentation,
+			expected: pbparser.Documentation{Trailing: "Trailing comment for c. Another line for c."}},
+		{name: "d", actual: pf.Messages[0].Fields[3].Documentation,
+			expected: pbparser.Documentation{Leading: "Leading comment for d. Another line for d."}},
+		{name: "e", actual: pf.Messages[0].Fields[4].Documentation,
+			expected: pbparser.Documentation{Trailing: "Block comment trailing e.",
+				Detached: []string{"Detached comment for e.", "Detached comment for e, paragraph 2."}}},
+		{name: "f", actual: pf.Messages[0].Fields[5].Documentation,
+			expected: pbparser.Documentation{Leading: "Block comment leading f."}},
+		{name: "Bar", actual: pf.Enums[0].Documentation,
+			expected: pbparser.Documentation{Leading: "Leading comment for Bar."}},
+		{name: "BAR_UNKNOWN", actual: pf.Enums[0].EnumConstants[0].Documentation,
+			expected: pbparser.Documentation{Trailing: "Trailing comment for BAR_UNKNOWN."}},
+		{name: "BAR_KNOWN", actual: pf.Enums[0].EnumConstants[1].Documentation,
+			expected: pbparser.Documentation{}},
+	}
+
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.actual, tt.expected) {
+			t.Errorf("Construct: %v, Expected: %#v, Actual: %#v", tt.name, tt.expected, tt.actual)
+		}
+	}
+}
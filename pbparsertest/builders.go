@@ -0,0 +1,374 @@
+package pbparsertest
+
+import (
+	"strings"
+
+	"github.com/tallstoat/pbparser"
+)
+
+// The scalar datatypes; for use with the builders.
+var (
+	Any      = scalar("any")
+	Bool     = scalar("bool")
+	Bytes    = scalar("bytes")
+	Double   = scalar("double")
+	Float    = scalar("float")
+	Fixed32  = scalar("fixed32")
+	Fixed64  = scalar("fixed64")
+	Int32    = scalar("int32")
+	Int64    = scalar("int64")
+	Sfixed32 = scalar("sfixed32")
+	Sfixed64 = scalar("sfixed64")
+	Sint32   = scalar("sint32")
+	Sint64   = scalar("sint64")
+	String   = scalar("string")
+	Uint32   = scalar("uint32")
+	Uint64   = scalar("uint64")
+)
+
+func scalar(name string) pbparser.ScalarDataType {
+	sdt, err := pbparser.NewScalarDataType(name)
+	if err != nil {
+		panic(err)
+	}
+	return sdt
+}
+
+// Named returns the NamedDataType for the given message/enum name. It panics if
+// the name is not valid for a NamedDataType.
+func Named(name string) pbparser.NamedDataType {
+	ndt, err := pbparser.NewNamedDataType(name)
+	if err != nil {
+		panic(err)
+	}
+	return ndt
+}
+
+// Stream returns the NamedDataType for the given message name preceded by the
+// stream keyword; for use as the request or response type of a rpc.
+func Stream(name string) pbparser.NamedDataType {
+	var ndt pbparser.NamedDataType
+	if err := ndt.UnmarshalText([]byte("stream " + name)); err != nil {
+		panic(err)
+	}
+	return ndt
+}
+
+// Map returns the MapDataType for the given key and value datatypes. It panics if
+// the datatypes are not valid for a map.
+func Map(keyType pbparser.DataType, valueType pbparser.DataType) pbparser.MapDataType {
+	mdt, err := pbparser.NewMapDataType(keyType, valueType)
+	if err != nil {
+		panic(err)
+	}
+	return mdt
+}
+
+// Opt returns an option of the given name and value. The name of a custom option
+// is to be enclosed in parenthesis e.g. Opt("(my.opt)", "1"); optionally followed
+// by the path of a field within it e.g. Opt("(validate.rules).string.min_len", "1").
+func Opt(name string, value string) pbparser.OptionElement {
+	if strings.HasPrefix(name, "(") {
+		if i := strings.Index(name, ")"); i > 0 {
+			return pbparser.OptionElement{Name: name[1:i], Value: value, IsParenthesized: true,
+				FieldPath: strings.TrimPrefix(name[i+1:], ".")}
+		}
+	}
+	return pbparser.OptionElement{Name: name, Value: value}
+}
+
+// F returns a field (without a label) of the given name, datatype and tag; for use
+// with OneOf().
+func F(name string, dt pbparser.DataType, tag int, options ...pbparser.OptionElement) pbparser.FieldElement {
+	return pbparser.FieldElement{Name: name, Type: dt, Tag: tag, Options: options}
+}
+
+// FileBuilder builds a ProtoFile; the qualified names of its elements are filled
+// in by Build() the same way the parser does.
+type FileBuilder struct {
+	pf pbparser.ProtoFile
+}
+
+// File returns a FileBuilder for a proto3 file of the given package.
+func File(pkg string) *FileBuilder {
+	return &FileBuilder{pf: pbparser.ProtoFile{PackageName: pkg, Syntax: pbparser.SyntaxProto3}}
+}
+
+// Syntax sets the syntax of the file.
+func (b *FileBuilder) Syntax(s pbparser.Syntax) *FileBuilder {
+	b.pf.Syntax = s
+	return b
+}
+
+// Import adds the given imports.
+func (b *FileBuilder) Import(deps ...string) *FileBuilder {
+	b.pf.Dependencies = append(b.pf.Dependencies, deps...)
+	return b
+}
+
+// PublicImport adds the given public imports.
+func (b *FileBuilder) PublicImport(deps ...string) *FileBuilder {
+	b.pf.PublicDependencies = append(b.pf.PublicDependencies, deps...)
+	return b
+}
+
+// WeakImport adds the given weak imports.
+func (b *FileBuilder) WeakImport(deps ...string) *FileBuilder {
+	b.pf.WeakDependencies = append(b.pf.WeakDependencies, deps...)
+	return b
+}
+
+// Option adds a file option.
+func (b *FileBuilder) Option(name string, value string) *FileBuilder {
+	b.pf.Options = append(b.pf.Options, Opt(name, value))
+	return b
+}
+
+// Msg adds the message built by the given MessageBuilder.
+func (b *FileBuilder) Msg(mb *MessageBuilder) *FileBuilder {
+	b.pf.Messages = append(b.pf.Messages, mb.Build())
+	return b
+}
+
+// Enum adds the enum built by the given EnumBuilder.
+func (b *FileBuilder) Enum(eb *EnumBuilder) *FileBuilder {
+	b.pf.Enums = append(b.pf.Enums, eb.Build())
+	return b
+}
+
+// Service adds the service built by the given ServiceBuilder.
+func (b *FileBuilder) Service(sb *ServiceBuilder) *FileBuilder {
+	b.pf.Services = append(b.pf.Services, sb.Build())
+	return b
+}
+
+// Build returns the ProtoFile.
+func (b *FileBuilder) Build() pbparser.ProtoFile {
+	pf := b.pf
+	prefix := ""
+	if pf.PackageName != "" {
+		prefix = pf.PackageName + "."
+	}
+	pf.Messages = qualifyMessages(prefix, pf.Messages)
+	pf.Enums = qualifyEnums(prefix, pf.Enums)
+	if pf.Services != nil {
+		pf.Services = append([]pbparser.ServiceElement{}, pf.Services...)
+		for i := range pf.Services {
+			pf.Services[i].QualifiedName = prefix + pf.Services[i].Name
+		}
+	}
+	return pf
+}
+
+func qualifyMessages(prefix string, msgs []pbparser.MessageElement) []pbparser.MessageElement {
+	if msgs == nil {
+		return nil
+	}
+	l := append([]pbparser.MessageElement{}, msgs...)
+	for i := range l {
+		l[i].QualifiedName = prefix + l[i].Name
+		l[i].Messages = qualifyMessages(l[i].QualifiedName+".", l[i].Messages)
+		l[i].Enums = qualifyEnums(l[i].QualifiedName+".", l[i].Enums)
+	}
+	return l
+}
+
+func qualifyEnums(prefix string, enums []pbparser.EnumElement) []pbparser.EnumElement {
+	if enums == nil {
+		return nil
+	}
+	l := append([]pbparser.EnumElement{}, enums...)
+	for i := range l {
+		l[i].QualifiedName = prefix + l[i].Name
+	}
+	return l
+}
+
+// MessageBuilder builds a MessageElement; the ordinals of its declarations are
+// assigned in the order in which they are added, as the parser does.
+type MessageBuilder struct {
+	me      pbparser.MessageElement
+	ordinal int
+}
+
+// Msg returns a MessageBuilder for a message of the given name.
+func Msg(name string) *MessageBuilder {
+	return &MessageBuilder{me: pbparser.MessageElement{Name: name}}
+}
+
+func (b *MessageBuilder) nextOrdinal() int {
+	b.ordinal++
+	return b.ordinal
+}
+
+// Doc sets the leading comment of the message.
+func (b *MessageBuilder) Doc(doc string) *MessageBuilder {
+	b.me.Documentation.Leading = doc
+	return b
+}
+
+// Option adds a message option.
+func (b *MessageBuilder) Option(name string, value string) *MessageBuilder {
+	b.me.Options = append(b.me.Options, Opt(name, value))
+	return b
+}
+
+// Field adds a field (without a label).
+func (b *MessageBuilder) Field(name string, dt pbparser.DataType, tag int, options ...pbparser.OptionElement) *MessageBuilder {
+	return b.field(pbparser.LabelNone, name, dt, tag, options)
+}
+
+// Optional adds a field with the optional label.
+func (b *MessageBuilder) Optional(name string, dt pbparser.DataType, tag int, options ...pbparser.OptionElement) *MessageBuilder {
+	return b.field(pbparser.LabelOptional, name, dt, tag, options)
+}
+
+// Required adds a field with the required label.
+func (b *MessageBuilder) Required(name string, dt pbparser.DataType, tag int, options ...pbparser.OptionElement) *MessageBuilder {
+	return b.field(pbparser.LabelRequired, name, dt, tag, options)
+}
+
+// Repeated adds a field with the repeated label.
+func (b *MessageBuilder) Repeated(name string, dt pbparser.DataType, tag int, options ...pbparser.OptionElement) *MessageBuilder {
+	return b.field(pbparser.LabelRepeated, name, dt, tag, options)
+}
+
+func (b *MessageBuilder) field(label string, name string, dt pbparser.DataType, tag int, options []pbparser.OptionElement) *MessageBuilder {
+	fe := F(name, dt, tag, options...)
+	fe.Label = label
+	fe.Ordinal = b.nextOrdinal()
+	b.me.Fields = append(b.me.Fields, fe)
+	return b
+}
+
+// OneOf adds a oneof of the given name with the given fields.
+func (b *MessageBuilder) OneOf(name string, fields ...pbparser.FieldElement) *MessageBuilder {
+	oe := pbparser.OneOfElement{Name: name, Ordinal: b.nextOrdinal()}
+	for _, fe := range fields {
+		fe.OneOf = name
+		oe.Fields = append(oe.Fields, fe)
+	}
+	b.me.OneOfs = append(b.me.OneOfs, oe)
+	return b
+}
+
+// Enum adds the nested enum built by the given EnumBuilder.
+func (b *MessageBuilder) Enum(eb *EnumBuilder) *MessageBuilder {
+	ee := eb.Build()
+	ee.Ordinal = b.nextOrdinal()
+	b.me.Enums = append(b.me.Enums, ee)
+	return b
+}
+
+// Msg adds the nested message built by the given MessageBuilder.
+func (b *MessageBuilder) Msg(mb *MessageBuilder) *MessageBuilder {
+	me := mb.Build()
+	me.Ordinal = b.nextOrdinal()
+	b.me.Messages = append(b.me.Messages, me)
+	return b
+}
+
+// Group adds a (proto2) group of the given label & tag whose body is built by the
+// given MessageBuilder; as the parser does, the group is added as a field of the
+// type of the nested message.
+func (b *MessageBuilder) Group(label string, tag int, mb *MessageBuilder, options ...pbparser.OptionElement) *MessageBuilder {
+	b.field(label, strings.ToLower(mb.me.Name), Named(mb.me.Name), tag, options)
+	b.me.Fields[len(b.me.Fields)-1].Group = true
+	return b.Msg(mb)
+}
+
+// Reserved adds a reserved range of field numbers; end being inclusive.
+func (b *MessageBuilder) Reserved(start int, end int) *MessageBuilder {
+	b.me.ReservedRanges = append(b.me.ReservedRanges, pbparser.ReservedRangeElement{Start: start, End: end})
+	return b
+}
+
+// ReservedNames adds the given reserved field names.
+func (b *MessageBuilder) ReservedNames(names ...string) *MessageBuilder {
+	b.me.ReservedNames = append(b.me.ReservedNames, names...)
+	return b
+}
+
+// Build returns the MessageElement; the qualified names of it & its nested
+// elements are filled in when it is added to a FileBuilder.
+func (b *MessageBuilder) Build() pbparser.MessageElement {
+	return b.me
+}
+
+// EnumBuilder builds an EnumElement.
+type EnumBuilder struct {
+	ee pbparser.EnumElement
+}
+
+// Enum returns an EnumBuilder for an enum of the given name.
+func Enum(name string) *EnumBuilder {
+	return &EnumBuilder{ee: pbparser.EnumElement{Name: name}}
+}
+
+// Doc sets the leading comment of the enum.
+func (b *EnumBuilder) Doc(doc string) *EnumBuilder {
+	b.ee.Documentation.Leading = doc
+	return b
+}
+
+// Option adds an enum option.
+func (b *EnumBuilder) Option(name string, value string) *EnumBuilder {
+	b.ee.Options = append(b.ee.Options, Opt(name, value))
+	return b
+}
+
+// Const adds an enum constant.
+func (b *EnumBuilder) Const(name string, tag int, options ...pbparser.OptionElement) *EnumBuilder {
+	b.ee.EnumConstants = append(b.ee.EnumConstants, pbparser.EnumConstantElement{Name: name, Tag: tag, Options: options})
+	return b
+}
+
+// Reserved adds a reserved range of values; end being inclusive.
+func (b *EnumBuilder) Reserved(start int, end int) *EnumBuilder {
+	b.ee.ReservedRanges = append(b.ee.ReservedRanges, pbparser.ReservedRangeElement{Start: start, End: end})
+	return b
+}
+
+// ReservedNames adds the given reserved names.
+func (b *EnumBuilder) ReservedNames(names ...string) *EnumBuilder {
+	b.ee.ReservedNames = append(b.ee.ReservedNames, names...)
+	return b
+}
+
+// Build returns the EnumElement.
+func (b *EnumBuilder) Build() pbparser.EnumElement {
+	return b.ee
+}
+
+// ServiceBuilder builds a ServiceElement.
+type ServiceBuilder struct {
+	se pbparser.ServiceElement
+}
+
+// Service returns a ServiceBuilder for a service of the given name.
+func Service(name string) *ServiceBuilder {
+	return &ServiceBuilder{se: pbparser.ServiceElement{Name: name}}
+}
+
+// Doc sets the leading comment of the service.
+func (b *ServiceBuilder) Doc(doc string) *ServiceBuilder {
+	b.se.Documentation.Leading = doc
+	return b
+}
+
+// Option adds a service option.
+func (b *ServiceBuilder) Option(name string, value string) *ServiceBuilder {
+	b.se.Options = append(b.se.Options, Opt(name, value))
+	return b
+}
+
+// RPC adds a rpc of the given request and response types.
+func (b *ServiceBuilder) RPC(name string, request pbparser.NamedDataType, response pbparser.NamedDataType, options ...pbparser.OptionElement) *ServiceBuilder {
+	b.se.RPCs = append(b.se.RPCs, pbparser.RPCElement{Name: name, RequestType: request, ResponseType: response, Options: options})
+	return b
+}
+
+// Build returns the ServiceElement.
+func (b *ServiceBuilder) Build() pbparser.ServiceElement {
+	return b.se
+}
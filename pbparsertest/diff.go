@@ -0,0 +1,135 @@
+package pbparsertest
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/tallstoat/pbparser"
+)
+
+// entry is a single value of the model along with its path e.g.
+// Messages[Foo].Fields[id].Tag = 1
+type entry struct {
+	path  string
+	value string
+}
+
+// Dump returns a textual dump of the given ProtoFile; one line per (non zero) value
+// of the model in the form path = value. The elements are identified in the paths
+// by their names (or by their index if they have none) so that the dumps of two
+// models can be compared element by element. The dump is meant for golden files.
+func Dump(pf pbparser.ProtoFile) string {
+	var buf strings.Builder
+	for _, e := range flatten(pf) {
+		buf.WriteString(e.path + " = " + e.value + "\n")
+	}
+	return buf.String()
+}
+
+// Diff returns the differences between the expected and the actual ProtoFile; one
+// per value of the model which is missing, unexpected or differs. No differences
+// are returned if the models are equal.
+func Diff(expected pbparser.ProtoFile, actual pbparser.ProtoFile) []string {
+	return diffEntries(flatten(expected), flatten(actual))
+}
+
+// AssertEqual reports an error on t for each difference between the expected and
+// the actual ProtoFile.
+func AssertEqual(t testing.TB, expected pbparser.ProtoFile, actual pbparser.ProtoFile) {
+	t.Helper()
+	for _, d := range Diff(expected, actual) {
+		t.Errorf("%v", d)
+	}
+}
+
+func diffEntries(expected []entry, actual []entry) []string {
+	values := make(map[string]string, len(actual))
+	for _, e := range actual {
+		values[e.path] = e.value
+	}
+	seen := make(map[string]bool, len(expected))
+
+	var diffs []string
+	for _, e := range expected {
+		seen[e.path] = true
+		v, found := values[e.path]
+		if !found {
+			diffs = append(diffs, fmt.Sprintf("%v: Expected: %v, Actual: <missing>", e.path, e.value))
+		} else if v != e.value {
+			diffs = append(diffs, fmt.Sprintf("%v: Expected: %v, Actual: %v", e.path, e.value, v))
+		}
+	}
+	for _, e := range actual {
+		if !seen[e.path] {
+			diffs = append(diffs, fmt.Sprintf("%v: Unexpected: %v", e.path, e.value))
+		}
+	}
+	return diffs
+}
+
+func flatten(pf pbparser.ProtoFile) []entry {
+	var l []entry
+	flattenValue(reflect.ValueOf(pf), "", &l)
+	return l
+}
+
+func flattenValue(v reflect.Value, path string, l *[]entry) {
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).PkgPath != "" {
+				continue
+			}
+			p := t.Field(i).Name
+			if path != "" {
+				p = path + "." + p
+			}
+			flattenValue(v.Field(i), p, l)
+		}
+	case reflect.Slice:
+		counts := make(map[string]int)
+		for i := 0; i < v.Len(); i++ {
+			counts[elementName(v.Index(i))]++
+		}
+		for i := 0; i < v.Len(); i++ {
+			key := elementName(v.Index(i))
+			if key == "" || counts[key] > 1 {
+				key = strconv.Itoa(i)
+			}
+			flattenValue(v.Index(i), path+"["+key+"]", l)
+		}
+	case reflect.Interface:
+		if !v.IsNil() {
+			*l = append(*l, entry{path, fmt.Sprint(v.Interface())})
+		}
+	case reflect.String:
+		if v.Len() > 0 {
+			*l = append(*l, entry{path, strconv.Quote(v.String())})
+		}
+	case reflect.Int:
+		if v.Int() != 0 {
+			*l = append(*l, entry{path, strconv.FormatInt(v.Int(), 10)})
+		}
+	case reflect.Bool:
+		if v.Bool() {
+			*l = append(*l, entry{path, "true"})
+		}
+	}
+}
+
+// elementName returns the name of the given element of a slice; the elements are
+// identified by their names where those are unique within the slice and by their
+// index otherwise.
+func elementName(e reflect.Value) string {
+	if e.Kind() != reflect.Struct {
+		return ""
+	}
+	if f := e.FieldByName("Name"); f.IsValid() && f.Kind() == reflect.String {
+		return f.String()
+	}
+	return ""
+}
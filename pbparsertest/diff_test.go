@@ -0,0 +1,91 @@
+package pbparsertest_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tallstoat/pbparser"
+	"github.com/tallstoat/pbparser/pbparsertest"
+)
+
+func buildShop(idType pbparser.DataType) pbparser.ProtoFile {
+	return pbparsertest.File("shop").
+		Msg(pbparsertest.Msg("Item").
+			Field("id", idType, 1).
+			Msg(pbparsertest.Msg("Part").Field("name", pbparsertest.String, 1))).
+		Enum(pbparsertest.Enum("State").Const("STATE_UNKNOWN", 0)).
+		Build()
+}
+
+func TestBuildQualifiesNames(t *testing.T) {
+	pf := buildShop(pbparsertest.Int32)
+	var tests = []struct {
+		actual   string
+		expected string
+	}{
+		{actual: pf.Messages[0].QualifiedName, expected: "shop.Item"},
+		{actual: pf.Messages[0].Messages[0].QualifiedName, expected: "shop.Item.Part"},
+		{actual: pf.Enums[0].QualifiedName, expected: "shop.State"},
+	}
+	for _, tt := range tests {
+		if tt.actual != tt.expected {
+			t.Errorf("Expected: %v, Actual: %v", tt.expected, tt.actual)
+		}
+	}
+	if pf.Messages[0].Fields[0].Ordinal != 1 || pf.Messages[0].Messages[0].Ordinal != 2 {
+		t.Errorf("Expected the ordinals in the order of declaration, Actual: %v, %v",
+			pf.Messages[0].Fields[0].Ordinal, pf.Messages[0].Messages[0].Ordinal)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	expected := buildShop(pbparsertest.Int32)
+	if diffs := pbparsertest.Diff(expected, buildShop(pbparsertest.Int32)); len(diffs) != 0 {
+		t.Errorf("Expected no differences, Actual: %v", diffs)
+	}
+
+	actual := buildShop(pbparsertest.Int64)
+	actual.Enums = nil
+	actual.Syntax = pbparser.SyntaxProto2
+	actual.Options = append(actual.Options, pbparsertest.Opt("(shop.tag)", "x"))
+
+	want := []string{
+		`Syntax: Expected: "proto3", Actual: "proto2"`,
+		`Enums[State].Name: Expected: "State", Actual: <missing>`,
+		`Enums[State].QualifiedName: Expected: "shop.State", Actual: <missing>`,
+		`Enums[State].EnumConstants[STATE_UNKNOWN].Name: Expected: "STATE_UNKNOWN", Actual: <missing>`,
+		`Messages[Item].Fields[id].Type: Expected: int32, Actual: int64`,
+		`Options[shop.tag].Name: Unexpected: "shop.tag"`,
+		`Options[shop.tag].Value: Unexpected: "x"`,
+		`Options[shop.tag].IsParenthesized: Unexpected: true`,
+	}
+	if diffs := pbparsertest.Diff(expected, actual); !reflect.DeepEqual(want, diffs) {
+		t.Errorf("Expected:\n%v\nActual:\n%v", strings.Join(want, "\n"), strings.Join(diffs, "\n"))
+	}
+}
+
+func TestDump(t *testing.T) {
+	pf := pbparsertest.File("shop").
+		Msg(pbparsertest.Msg("Item").
+			Repeated("ids", pbparsertest.Int32, 1).
+			Field("ids", pbparsertest.Int64, 2)).
+		Build()
+	expected := `PackageName = "shop"
+Syntax = "proto3"
+Messages[Item].Name = "Item"
+Messages[Item].QualifiedName = "shop.Item"
+Messages[Item].Fields[0].Name = "ids"
+Messages[Item].Fields[0].Label = "repeated"
+Messages[Item].Fields[0].Type = int32
+Messages[Item].Fields[0].Tag = 1
+Messages[Item].Fields[0].Ordinal = 1
+Messages[Item].Fields[1].Name = "ids"
+Messages[Item].Fields[1].Type = int64
+Messages[Item].Fields[1].Tag = 2
+Messages[Item].Fields[1].Ordinal = 2
+`
+	if actual := pbparsertest.Dump(pf); actual != expected {
+		t.Errorf("Expected:\n%v\nActual:\n%v", expected, actual)
+	}
+}
@@ -0,0 +1,20 @@
+/*
+Package pbparsertest provides helpers for tests asserting on the models produced by
+the pbparser package.
+
+It provides fluent builders for the expected models e.g.
+
+	pf := pbparsertest.File("shop").
+		Msg(pbparsertest.Msg("Item").
+			Field("id", pbparsertest.Int32, 1).
+			OneOf("price", pbparsertest.F("cents", pbparsertest.Int64, 2))).
+		Build()
+
+an AssertEqual() which reports the differences between two models element by
+element and golden file helpers for both model dumps and generated output; the
+golden files being rewritten when the tests are run with the PBPARSER_UPDATE_GOLDEN
+environment variable set e.g.
+
+	PBPARSER_UPDATE_GOLDEN=1 go test ./...
+*/
+package pbparsertest
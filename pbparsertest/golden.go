@@ -0,0 +1,72 @@
+package pbparsertest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/tallstoat/pbparser"
+)
+
+// UpdateGoldenEnv is the environment variable which, when set to a non-empty value,
+// makes the golden file helpers (re)write the golden files instead of comparing.
+const UpdateGoldenEnv = "PBPARSER_UPDATE_GOLDEN"
+
+func updateGolden() bool {
+	return os.Getenv(UpdateGoldenEnv) != ""
+}
+
+// AssertGolden reports an error on t if the given output differs from the contents
+// of the golden file. If the UpdateGoldenEnv environment variable is set, the
+// golden file is (re)written with the output instead.
+func AssertGolden(t testing.TB, golden string, actual []byte) {
+	t.Helper()
+	if updateGolden() {
+		if err := ioutil.WriteFile(golden, actual, 0644); err != nil {
+			t.Fatalf("%v", err.Error())
+		}
+		return
+	}
+	expected, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	if !bytes.Equal(actual, expected) {
+		t.Errorf("Output differs from golden file %v; Actual:\n%s", golden, actual)
+	}
+}
+
+// AssertGoldenModel reports an error on t for each difference between the given
+// ProtoFile and the model dumped (see Dump()) in the golden file. If the
+// UpdateGoldenEnv environment variable is set, the golden file is (re)written
+// with the dump instead.
+func AssertGoldenModel(t testing.TB, golden string, pf pbparser.ProtoFile) {
+	t.Helper()
+	actual := Dump(pf)
+	if updateGolden() {
+		if err := ioutil.WriteFile(golden, []byte(actual), 0644); err != nil {
+			t.Fatalf("%v", err.Error())
+		}
+		return
+	}
+	expected, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	for _, d := range diffEntries(parseDump(string(expected)), flatten(pf)) {
+		t.Errorf("%v: %v", golden, d)
+	}
+}
+
+// parseDump parses the entries back out of a dump produced by Dump().
+func parseDump(s string) []entry {
+	var l []entry
+	for _, line := range strings.Split(s, "\n") {
+		if i := strings.Index(line, " = "); i >= 0 {
+			l = append(l, entry{line[:i], line[i+len(" = "):]})
+		}
+	}
+	return l
+}
@@ -0,0 +1,36 @@
+package pbparser
+
+import (
+	"bufio"
+	"io"
+	"sync"
+)
+
+// the pool of parsers (along with their readers & scratch buffers) which are
+// reused across the parse calls to reduce the allocations...
+var parserPool = sync.Pool{
+	New: func() interface{} {
+		return &parser{br: bufio.NewReader(nil), loc: &location{}}
+	},
+}
+
+// newParser returns a parser from the pool; with all of its per-call state
+// reset, which reads from the given reader.
+func newParser(r io.Reader, opts parseOptions) *parser {
+	p := parserPool.Get().(*parser)
+	br, loc, buf := p.br, p.loc, p.buf
+	br.Reset(r)
+	*loc = location{line: 1, column: 0}
+	buf.Reset()
+	*p = parser{br: br, loc: loc, buf: buf, opts: opts}
+	return p
+}
+
+// release returns the parser to the pool. The parser must not be used afterwards.
+func (p *parser) release() {
+	// do not hold on to the reader (or anything else) while pooled...
+	p.br.Reset(nil)
+	p.opts = parseOptions{}
+	p.pendingDoc = nil
+	parserPool.Put(p)
+}
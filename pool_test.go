@@ -0,0 +1,81 @@
+package pbparser_test
+
+import (
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/tallstoat/pbparser"
+)
+
+// TestParseConcurrently parses a set of proto files from many goroutines at
+// once (the parsers being pooled) and compares the results with the ones of
+// parsing the files sequentially. Run with -race to detect any shared state.
+func TestParseConcurrently(t *testing.T) {
+	files := []string{
+		"./resources/descriptor.proto",
+		"./resources/enum.proto",
+		"./resources/comments.proto",
+		"./resources/comment-owners.proto",
+	}
+	var contents []string
+	var expected []pbparser.ProtoFile
+	for _, file := range files {
+		raw, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatalf("%v", err.Error())
+		}
+		pf, err := pbparser.Parse(strings.NewReader(string(raw)), nil)
+		if err != nil {
+			t.Fatalf("%v", err.Error())
+		}
+		contents = append(contents, string(raw))
+		expected = append(expected, pf)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan string, 64)
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 25; i++ {
+				n := (g + i) % len(files)
+				pf, err := pbparser.Parse(strings.NewReader(contents[n]), nil)
+				if err != nil {
+					errs <- err.Error()
+					return
+				}
+				if !reflect.DeepEqual(pf, expected[n]) {
+					errs <- "Unexpected result of parsing " + files[n]
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// BenchmarkParse benchmarks the Parse() API over the content of a given .proto
+// file held in memory; so as to measure the allocations of the parser itself.
+func BenchmarkParse(b *testing.B) {
+	b.ReportAllocs()
+	raw, err := ioutil.ReadFile("./resources/descriptor.proto")
+	if err != nil {
+		b.Fatalf("%v", err.Error())
+	}
+	content := string(raw)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := pbparser.Parse(strings.NewReader(content), nil); err != nil {
+			b.Fatalf("%v", err.Error())
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package pbparser
+
+import "strings"
+
+// typeRef describes a reference to a message/enum made by a field, a rpc or an
+// extend declaration. It allows for the referenced name to be rewritten.
+type typeRef struct {
+	scope string // qualified name of the scope within which the name is to be resolved
+	owner string // qualified name of the message/service making the reference
+	via   string // name of the field/rpc making the reference
+	name  string
+	set   func(name string)
+}
+
+// walkFileRefs visits all the references to messages/enums within the given
+// proto file; those in fields (including map values), rpcs and extend declarations
+// of messages (howsoever deep) as well as of the file itself.
+func walkFileRefs(pf *ProtoFile, visit func(typeRef)) {
+	for i := range pf.Messages {
+		walkMessageRefs(&pf.Messages[i], visit)
+	}
+	for i := range pf.Services {
+		walkServiceRefs(&pf.Services[i], visit)
+	}
+	walkExtendRefs(pf.PackageName, pf.PackageName, pf.ExtendDeclarations, visit)
+}
+
+// walkMessageRefs visits all the references to messages/enums within the given
+// message and its nested messages (howsoever deep).
+func walkMessageRefs(me *MessageElement, visit func(typeRef)) {
+	walkFieldRefs(me.QualifiedName, me.QualifiedName, me.Fields, visit)
+	for i := range me.OneOfs {
+		walkFieldRefs(me.QualifiedName, me.QualifiedName, me.OneOfs[i].Fields, visit)
+	}
+	walkExtendRefs(me.QualifiedName, me.QualifiedName, me.ExtendDeclarations, visit)
+	for i := range me.Messages {
+		walkMessageRefs(&me.Messages[i], visit)
+	}
+}
+
+func walkServiceRefs(se *ServiceElement, visit func(typeRef)) {
+	for i := range se.RPCs {
+		rpc := &se.RPCs[i]
+		visit(typeRef{scope: se.QualifiedName, owner: se.QualifiedName, via: rpc.Name, name: rpc.RequestType.name,
+			set: func(name string) { rpc.RequestType.name = name }})
+		visit(typeRef{scope: se.QualifiedName, owner: se.QualifiedName, via: rpc.Name, name: rpc.ResponseType.name,
+			set: func(name string) { rpc.ResponseType.name = name }})
+	}
+}
+
+func walkExtendRefs(scope string, owner string, extends []ExtendElement, visit func(typeRef)) {
+	for i := range extends {
+		ee := &extends[i]
+		visit(typeRef{scope: scope, owner: owner, via: "extend", name: ee.Name,
+			set: func(name string) {
+				ee.Name, ee.QualifiedName = name, name
+				if !strings.Contains(name, ".") && scope != "" {
+					ee.QualifiedName = scope + "." + name
+				}
+			}})
+		walkFieldRefs(scope, owner, ee.Fields, visit)
+	}
+}
+
+func walkFieldRefs(scope string, owner string, fields []FieldElement, visit func(typeRef)) {
+	for i := range fields {
+		f := &fields[i]
+		switch t := f.Type.(type) {
+		case NamedDataType:
+			visit(typeRef{scope: scope, owner: owner, via: f.Name, name: t.name,
+				set: func(name string) { f.Type = NamedDataType{name: name} }})
+		case MapDataType:
+			if ndt, ok := t.valueType.(NamedDataType); ok {
+				visit(typeRef{scope: scope, owner: owner, via: f.Name, name: ndt.name,
+					set: func(name string) { f.Type = MapDataType{keyType: t.keyType, valueType: NamedDataType{name: name}} }})
+			}
+		}
+	}
+}
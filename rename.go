@@ -0,0 +1,213 @@
+package pbparser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// RenameType renames the message or enum with the given qualified name (howsoever
+// deep it is nested) to the given new name, updating the qualified names of all the
+// types nested within it. Every reference to the renamed type (and to the types
+// nested within it) made by fields, map values, oneof members, rpcs and extend
+// declarations in the proto file is rewritten; retaining the form of the reference
+// (bare, partially qualified, fully qualified or with a leading dot) unless that
+// would make it resolve to some other type, in which case it is fully qualified.
+// References to other types which would otherwise get shadowed by the new name are
+// fully qualified as well. The number of references rewritten is returned.
+//
+// An Error is returned if there is no such type, if the new name is not a valid
+// name or if it is already used by a sibling of the type.
+func (pf *ProtoFile) RenameType(oldQualifiedName, newName string) (int, error) {
+	if !isGoIdentifier(newName) {
+		return 0, fmt.Errorf("'%v' is not a valid name for a message or enum", newName)
+	}
+
+	// resolve all the references before renaming...
+	type resolvedRef struct {
+		ref   typeRef
+		qname string
+	}
+	var refs []resolvedRef
+	before := typeNames(pf)
+	walkFileRefs(pf, func(ref typeRef) {
+		if qname, found := resolveTypeName(ref.scope, ref.name, before.has); found {
+			refs = append(refs, resolvedRef{ref: ref, qname: qname})
+		}
+	})
+
+	prefix, found, err := renameDecl(&pf.Messages, &pf.Enums, qualify(pf.PackageName, ""), oldQualifiedName, newName, pf.Services)
+	if err != nil {
+		return 0, err
+	}
+	if !found {
+		return 0, fmt.Errorf("Message or enum %v is not defined in package %v", oldQualifiedName, pf.PackageName)
+	}
+	newQualifiedName := prefix + newName
+	renamed := func(qname string) string {
+		if qname == oldQualifiedName || strings.HasPrefix(qname, oldQualifiedName+".") {
+			return newQualifiedName + qname[len(oldQualifiedName):]
+		}
+		return qname
+	}
+
+	count := 0
+	after := typeNames(pf)
+	for _, r := range refs {
+		target := renamed(r.qname)
+		scope := renamed(r.ref.scope)
+		name := r.ref.name
+		if target != r.qname {
+			name = renameInRef(name, r.qname, oldQualifiedName, newName)
+		}
+		// make sure the reference (still) resolves to the intended type...
+		if qname, found := resolveTypeName(scope, strings.TrimPrefix(name, "."), after.has); !strings.HasPrefix(name, ".") && (!found || qname != target) {
+			name = target
+		}
+		if name != r.ref.name {
+			r.ref.set(name)
+			count++
+		}
+	}
+	return count, nil
+}
+
+// renameDecl finds the message/enum with the given qualified name among the given
+// messages & enums (and the ones nested within them) and renames it. The prefix of
+// the qualified name of the type is returned.
+func renameDecl(msgs *[]MessageElement, enums *[]EnumElement, prefix string, qname string, newName string, services []ServiceElement) (string, bool, error) {
+	checkSiblings := func() error {
+		if err := checkTypeName(newName, *msgs, *enums); err != nil {
+			return err
+		}
+		for _, se := range services {
+			if se.Name == newName {
+				return errors.New("Duplicate name " + newName)
+			}
+		}
+		return nil
+	}
+
+	for i := range *enums {
+		ee := &(*enums)[i]
+		if ee.QualifiedName == qname {
+			if err := checkSiblings(); err != nil {
+				return "", true, err
+			}
+			ee.Name = newName
+			ee.QualifiedName = prefix + newName
+			return prefix, true, nil
+		}
+	}
+	for i := range *msgs {
+		me := &(*msgs)[i]
+		if me.QualifiedName == qname {
+			if err := checkSiblings(); err != nil {
+				return "", true, err
+			}
+			me.Name = newName
+			qualifyMessage(me, strings.TrimSuffix(prefix, "."))
+			return prefix, true, nil
+		}
+		if strings.HasPrefix(qname, me.QualifiedName+".") {
+			return renameDecl(&me.Messages, &me.Enums, me.QualifiedName+".", qname, newName, nil)
+		}
+	}
+	return "", false, nil
+}
+
+// renameInRef replaces the component of the given reference (resolved to the given
+// qualified name) which corresponds to the renamed type, if the reference spells it.
+func renameInRef(ref string, qname string, oldQualifiedName string, newName string) string {
+	dot := strings.HasPrefix(ref, ".")
+	parts := strings.Split(strings.TrimPrefix(ref, "."), ".")
+	qparts := strings.Split(qname, ".")
+	i := len(strings.Split(oldQualifiedName, ".")) - 1 - (len(qparts) - len(parts))
+	if i < 0 {
+		return ref
+	}
+	parts[i] = newName
+	if dot {
+		return "." + strings.Join(parts, ".")
+	}
+	return strings.Join(parts, ".")
+}
+
+// typeNameSet is a set of qualified names of the messages/enums in a proto file.
+type typeNameSet map[string]bool
+
+func (s typeNameSet) has(qname string) bool {
+	return s[qname]
+}
+
+func typeNames(pf *ProtoFile) typeNameSet {
+	s := make(typeNameSet)
+	var add func(msgs []MessageElement, enums []EnumElement)
+	add = func(msgs []MessageElement, enums []EnumElement) {
+		for _, ee := range enums {
+			s[ee.QualifiedName] = true
+		}
+		for _, me := range msgs {
+			s[me.QualifiedName] = true
+			add(me.Messages, me.Enums)
+		}
+	}
+	add(pf.Messages, pf.Enums)
+	return s
+}
+
+// RenameField renames the field (including a field within a oneof) with the given
+// name to the given new name. To keep the renaming compatible, the old name is
+// added to the reserved names of the message and unless the field already has a
+// json_name option, one is added to retain the old name of the field in JSON.
+//
+// An Error is returned if there is no such field or if the new name is reserved
+// or already used by another field.
+func (me *MessageElement) RenameField(oldName, newName string) error {
+	if !isGoIdentifier(newName) {
+		return fmt.Errorf("'%v' is not a valid name for a field", newName)
+	}
+	for _, name := range me.ReservedNames {
+		if name == newName {
+			msg := fmt.Sprintf("Field name '%v' is reserved in message %v", newName, me.QualifiedName)
+			return errors.New(msg)
+		}
+	}
+
+	var field *FieldElement
+	for _, f := range me.allFields() {
+		if f.Name == newName {
+			msg := fmt.Sprintf("Duplicate name '%v' for a field in message %v", newName, me.QualifiedName)
+			return errors.New(msg)
+		}
+	}
+	for i := range me.Fields {
+		if me.Fields[i].Name == oldName {
+			field = &me.Fields[i]
+		}
+	}
+	for i := range me.OneOfs {
+		for j := range me.OneOfs[i].Fields {
+			if me.OneOfs[i].Fields[j].Name == oldName {
+				field = &me.OneOfs[i].Fields[j]
+			}
+		}
+	}
+	if field == nil {
+		msg := fmt.Sprintf("Field '%v' not found in message %v", oldName, me.QualifiedName)
+		return errors.New(msg)
+	}
+
+	field.Name = newName
+	hasJSONName := false
+	for _, op := range field.Options {
+		if op.Name == "json_name" && !op.IsParenthesized {
+			hasJSONName = true
+		}
+	}
+	if !hasJSONName && jsonName(oldName) != jsonName(newName) {
+		field.Options = append(field.Options, OptionElement{Name: "json_name", Value: jsonName(oldName)})
+	}
+	me.ReservedNames = append(me.ReservedNames, oldName)
+	return nil
+}
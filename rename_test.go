@@ -0,0 +1,174 @@
+package pbparser
+
+import (
+	"strings"
+	"testing"
+)
+
+const renameProto = `
+syntax = "proto2";
+package shop.v1;
+
+message Item {
+  optional string id = 1;
+  message Part {
+    optional string name = 1;
+  }
+  repeated Part parts = 2;
+  optional Item.Part main = 3;
+  optional Product product = 4;
+  extensions 100 to 199;
+}
+
+message Order {
+  repeated Item items = 1;
+  optional Item first = 2;
+  optional shop.v1.Item.Part part = 3;
+  map<string, Item> by_id = 4;
+  oneof pick {
+    Item picked = 5;
+  }
+  optional Product product = 6;
+}
+
+message Product {
+  optional string sku = 1;
+}
+
+extend Item {
+  optional string note = 100;
+}
+
+service Shop {
+  rpc Get(Order) returns (Item) {}
+}
+`
+
+func TestRenameType(t *testing.T) {
+	pf, err := Parse(strings.NewReader(renameProto), nil)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+
+	count, err := pf.RenameType("shop.v1.Item", "Product2")
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	if count != 8 {
+		t.Errorf("Expected 8 references to be updated, Actual: %v", count)
+	}
+
+	item := pf.Messages[0]
+	order := pf.Messages[1]
+	var tests = []struct {
+		actual   string
+		expected string
+	}{
+		{actual: item.QualifiedName, expected: "shop.v1.Product2"},
+		{actual: item.Messages[0].QualifiedName, expected: "shop.v1.Product2.Part"},
+		{actual: item.Fields[1].Type.Name(), expected: "Part"},
+		{actual: item.Fields[2].Type.Name(), expected: "Product2.Part"},
+		{actual: order.Fields[0].Type.Name(), expected: "Product2"},
+		{actual: order.Fields[1].Type.Name(), expected: "Product2"},
+		{actual: order.Fields[2].Type.Name(), expected: "shop.v1.Product2.Part"},
+		{actual: order.Fields[3].Type.Name(), expected: "map<string, Product2>"},
+		{actual: order.OneOfs[0].Fields[0].Type.Name(), expected: "Product2"},
+		{actual: pf.ExtendDeclarations[0].QualifiedName, expected: "shop.v1.Product2"},
+		{actual: pf.Services[0].RPCs[0].ResponseType.Name(), expected: "Product2"},
+	}
+	for _, tt := range tests {
+		if tt.actual != tt.expected {
+			t.Errorf("Expected: %v, Actual: %v", tt.expected, tt.actual)
+		}
+	}
+	if err := verify(&pf, nil, parseOptions{}); err != nil {
+		t.Errorf("Expected renamed proto file to pass verification, Actual: %v", err.Error())
+	}
+
+	// renaming a nested type such that it shadows another type...
+	pf, _ = Parse(strings.NewReader(renameProto), nil)
+	if _, err := pf.RenameType("shop.v1.Item.Part", "Product"); err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	if actual := pf.Messages[0].Fields[1].Type.Name(); actual != "Product" {
+		t.Errorf("Expected: Product, Actual: %v", actual)
+	}
+	if actual := pf.Messages[0].Fields[3].Type.Name(); actual != "shop.v1.Product" {
+		t.Errorf("Expected the shadowed reference to be fully qualified: shop.v1.Product, Actual: %v", actual)
+	}
+	if actual := pf.Messages[1].Fields[4].Type.Name(); actual != "Product" {
+		t.Errorf("Expected: Product, Actual: %v", actual)
+	}
+	if err := verify(&pf, nil, parseOptions{}); err != nil {
+		t.Errorf("Expected renamed proto file to pass verification, Actual: %v", err.Error())
+	}
+
+	for _, tt := range []struct {
+		old, new, errorstr string
+	}{
+		{old: "shop.v1.Nope", new: "Foo", errorstr: "Message or enum shop.v1.Nope is not defined"},
+		{old: "shop.v1.Item", new: "Order", errorstr: "Duplicate name Order"},
+		{old: "shop.v1.Item", new: "Shop", errorstr: "Duplicate name Shop"},
+		{old: "shop.v1.Item", new: "9lives", errorstr: "is not a valid name"},
+	} {
+		if _, err := pf.RenameType(tt.old, tt.new); err == nil || !strings.Contains(err.Error(), tt.errorstr) {
+			t.Errorf("Expected error containing %q, Actual: %v", tt.errorstr, err)
+		}
+	}
+}
+
+func TestRenameInRef(t *testing.T) {
+	var tests = []struct {
+		ref      string
+		qname    string
+		expected string
+	}{
+		{ref: "Item", qname: "shop.v1.Item", expected: "Product"},
+		{ref: "v1.Item", qname: "shop.v1.Item", expected: "v1.Product"},
+		{ref: ".shop.v1.Item", qname: "shop.v1.Item", expected: ".shop.v1.Product"},
+		{ref: ".shop.v1.Item.Part", qname: "shop.v1.Item.Part", expected: ".shop.v1.Product.Part"},
+		{ref: "Part", qname: "shop.v1.Item.Part", expected: "Part"},
+	}
+	for _, tt := range tests {
+		if actual := renameInRef(tt.ref, tt.qname, "shop.v1.Item", "Product"); actual != tt.expected {
+			t.Errorf("Ref: %v, Expected: %v, Actual: %v", tt.ref, tt.expected, actual)
+		}
+	}
+}
+
+func TestRenameField(t *testing.T) {
+	pf, err := Parse(strings.NewReader(renameProto), nil)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	order := &pf.Messages[1]
+
+	if err := order.RenameField("by_id", "items_by_id"); err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	f := order.Fields[3]
+	if f.Name != "items_by_id" || len(f.Options) != 1 || f.Options[0].Name != "json_name" || f.Options[0].Value != "byId" {
+		t.Errorf("Expected field items_by_id with json_name byId, Actual: %v", f)
+	}
+	if err := order.RenameField("picked", "chosen"); err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	if actual := strings.Join(order.ReservedNames, ","); actual != "by_id,picked" {
+		t.Errorf("Expected reserved names: by_id,picked, Actual: %v", actual)
+	}
+	if err := verify(&pf, nil, parseOptions{}); err != nil {
+		t.Errorf("Expected proto file to pass verification, Actual: %v", err.Error())
+	}
+
+	for _, tt := range []struct {
+		old, new, errorstr string
+	}{
+		{old: "nope", new: "foo", errorstr: "Field 'nope' not found"},
+		{old: "items", new: "first", errorstr: "Duplicate name 'first'"},
+		{old: "items", new: "by_id", errorstr: "Field name 'by_id' is reserved"},
+	} {
+		if err := order.RenameField(tt.old, tt.new); err == nil || !strings.Contains(err.Error(), tt.errorstr) {
+			t.Errorf("Expected error containing %q, Actual: %v", tt.errorstr, err)
+		}
+	}
+}
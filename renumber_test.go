@@ -0,0 +1,147 @@
+package pbparser_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tallstoat/pbparser"
+)
+
+const renumberProto = `
+syntax = "proto2";
+package renumber;
+
+message Sparse {
+  optional int32 a = 3;
+  reserved 2, 5;
+  oneof choice {
+    string b = 7;
+    string c = 9;
+  }
+  optional int32 d = 12;
+  extensions 100 to 199;
+}
+`
+
+func parseRenumberProto(t *testing.T) *pbparser.MessageElement {
+	pf, err := pbparser.Parse(strings.NewReader(renumberProto), nil, pbparser.WithStrictFieldNumbers())
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	return &pf.Messages[0]
+}
+
+func tagsByName(me *pbparser.MessageElement) map[string]int {
+	m := make(map[string]int)
+	for _, f := range me.Fields {
+		m[f.Name] = f.Tag
+	}
+	for _, oo := range me.OneOfs {
+		for _, f := range oo.Fields {
+			m[f.Name] = f.Tag
+		}
+	}
+	return m
+}
+
+// checkTags returns an Error if the number of any field of the given message is not
+// available to it i.e. if it is used by another field or is not a valid number.
+func checkTags(me *pbparser.MessageElement) error {
+	for name, tag := range tagsByName(me) {
+		others := *me
+		others.Fields = nil
+		others.OneOfs = nil
+		for _, f := range me.Fields {
+			if f.Name != name {
+				others.Fields = append(others.Fields, f)
+			}
+		}
+		for _, oo := range me.OneOfs {
+			for _, f := range oo.Fields {
+				if f.Name != name {
+					others.Fields = append(others.Fields, f)
+				}
+			}
+		}
+		if available, reason := others.IsTagAvailable(tag); !available {
+			return errors.New(reason)
+		}
+	}
+	return nil
+}
+
+func TestRenumberFields(t *testing.T) {
+	var tests = []struct {
+		mapping  map[int]int
+		applied  map[int]int
+		tags     map[string]int
+		errorstr string
+	}{
+		{
+			mapping: map[int]int{3: 1, 7: 3, 12: 12},
+			applied: map[int]int{3: 1, 7: 3},
+			tags:    map[string]int{"a": 1, "b": 3, "c": 9, "d": 12},
+		},
+		{
+			mapping: map[int]int{3: 9, 9: 3},
+			applied: map[int]int{3: 9, 9: 3},
+			tags:    map[string]int{"a": 9, "b": 7, "c": 3, "d": 12},
+		},
+		{mapping: map[int]int{4: 1}, errorstr: "Tag 4 is not used by any field"},
+		{mapping: map[int]int{3: 7}, errorstr: "would also be used by field"},
+		{mapping: map[int]int{3: 5}, errorstr: "within the reserved range 5 to 5"},
+		{mapping: map[int]int{12: 150}, errorstr: "within the extensions range 100 to 199"},
+		{mapping: map[int]int{12: 19000}, errorstr: "reserved for the protocol buffers implementation"},
+		{mapping: map[int]int{3: 1, 12: 0}, errorstr: "out of the valid range"},
+	}
+
+	for i, tt := range tests {
+		me := parseRenumberProto(t)
+		before := tagsByName(me)
+		applied, err := me.RenumberFields(tt.mapping)
+		if tt.errorstr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.errorstr) {
+				t.Errorf("Test %v: Expected error containing %q, Actual: %v", i, tt.errorstr, err)
+			}
+			if after := tagsByName(me); !reflect.DeepEqual(before, after) {
+				t.Errorf("Test %v: Expected message to be untouched, Actual: %v", i, after)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %v: %v", i, err.Error())
+			continue
+		}
+		if !reflect.DeepEqual(applied, tt.applied) {
+			t.Errorf("Test %v: Expected applied: %v, Actual: %v", i, tt.applied, applied)
+		}
+		if after := tagsByName(me); !reflect.DeepEqual(after, tt.tags) {
+			t.Errorf("Test %v: Expected tags: %v, Actual: %v", i, tt.tags, after)
+		}
+		if err := checkTags(me); err != nil {
+			t.Errorf("Test %v: %v", i, err.Error())
+		}
+	}
+}
+
+func TestCompact(t *testing.T) {
+	me := parseRenumberProto(t)
+	applied, err := me.Compact()
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+
+	expected := map[int]int{3: 1, 7: 3, 9: 4, 12: 6}
+	if !reflect.DeepEqual(applied, expected) {
+		t.Errorf("Expected applied: %v, Actual: %v", expected, applied)
+	}
+	tags := map[string]int{"a": 1, "b": 3, "c": 4, "d": 6}
+	if actual := tagsByName(me); !reflect.DeepEqual(actual, tags) {
+		t.Errorf("Expected tags: %v, Actual: %v", tags, actual)
+	}
+	if err := checkTags(me); err != nil {
+		t.Errorf("%v", err.Error())
+	}
+}
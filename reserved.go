@@ -0,0 +1,189 @@
+package pbparser
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Violation is a datastructure which describes a problem found while checking
+// a proto file (or a change to it).
+type Violation struct {
+	// Element is the qualified name of the element the violation is about.
+	Element string
+	// Reason describes the violation.
+	Reason string
+}
+
+// VerifyReservedOnRemoval compares the given old & new versions of a proto file and
+// returns the violations which allow for a number (or name) which was in use in the
+// old version to be reused in future; corrupting the data serialized using the old
+// version. The messages & enums (howsoever deep they are nested) are matched by their
+// qualified names; the ones which are missing in the new version are not checked.
+//
+// A violation is reported for every field (including a field within a oneof) which is
+// removed without its number being reserved in the new version of the message, or
+// without its name being reserved (unless the name is used by another field), and
+// likewise for every enum constant which is removed without its value (unless it is
+// used by another constant) or its name being reserved in the new version of the
+// enum. A violation is reported as well for every number or name which was reserved
+// in the old version of a message or an enum but is not reserved anymore.
+func VerifyReservedOnRemoval(old, new ProtoFile) []Violation {
+	var vs []Violation
+	verifyReservedInEnums(old.Enums, new.Enums, &vs)
+	verifyReservedInMessages(old.Messages, new.Messages, &vs)
+	return vs
+}
+
+func verifyReservedInMessages(old, new []MessageElement, vs *[]Violation) {
+	for i := range old {
+		for j := range new {
+			if old[i].QualifiedName == new[j].QualifiedName {
+				verifyReservedInMessage(&old[i], &new[j], vs)
+				verifyReservedInEnums(old[i].Enums, new[j].Enums, vs)
+				verifyReservedInMessages(old[i].Messages, new[j].Messages, vs)
+			}
+		}
+	}
+}
+
+func verifyReservedInMessage(old, new *MessageElement, vs *[]Violation) {
+	add := func(element string, format string, args ...interface{}) {
+		*vs = append(*vs, Violation{Element: element, Reason: fmt.Sprintf(format, args...)})
+	}
+
+	tags := make(map[int]bool)
+	names := make(map[string]bool)
+	for _, f := range new.allFields() {
+		tags[f.Tag] = true
+		names[f.Name] = true
+	}
+	for _, name := range new.ReservedNames {
+		names[name] = true
+	}
+	for _, f := range old.allFields() {
+		if tags[f.Tag] {
+			continue
+		}
+		element := new.QualifiedName + "." + f.Name
+		if !isTagReserved(new.ReservedRanges, f.Tag) {
+			add(element, "Field '%v' is removed from message %v without reserving its number %v", f.Name, new.QualifiedName, f.Tag)
+		}
+		if !names[f.Name] {
+			add(element, "Field '%v' is removed from message %v without reserving its name", f.Name, new.QualifiedName)
+		}
+	}
+
+	for _, rr := range old.ReservedRanges {
+		for _, gap := range unreservedWithin(new.ReservedRanges, rr.Start, rr.End) {
+			if gap[0] == gap[1] {
+				add(new.QualifiedName, "Number %v is no longer reserved in message %v", gap[0], new.QualifiedName)
+			} else {
+				add(new.QualifiedName, "Numbers %v to %v are no longer reserved in message %v", gap[0], gap[1], new.QualifiedName)
+			}
+		}
+	}
+	reserved := make(map[string]bool)
+	for _, name := range new.ReservedNames {
+		reserved[name] = true
+	}
+	for _, name := range old.ReservedNames {
+		if !reserved[name] {
+			add(new.QualifiedName, "Name '%v' is no longer reserved in message %v", name, new.QualifiedName)
+		}
+	}
+}
+
+func verifyReservedInEnums(old, new []EnumElement, vs *[]Violation) {
+	for i := range old {
+		for j := range new {
+			if old[i].QualifiedName == new[j].QualifiedName {
+				verifyReservedInEnum(&old[i], &new[j], vs)
+			}
+		}
+	}
+}
+
+func verifyReservedInEnum(old, new *EnumElement, vs *[]Violation) {
+	add := func(element string, format string, args ...interface{}) {
+		*vs = append(*vs, Violation{Element: element, Reason: fmt.Sprintf(format, args...)})
+	}
+
+	values := make(map[int]bool)
+	names := make(map[string]bool)
+	for _, c := range new.EnumConstants {
+		values[c.Tag] = true
+		names[c.Name] = true
+	}
+	for _, name := range new.ReservedNames {
+		names[name] = true
+	}
+	for _, c := range old.EnumConstants {
+		if values[c.Tag] {
+			continue
+		}
+		element := new.QualifiedName + "." + c.Name
+		if !isTagReserved(new.ReservedRanges, c.Tag) {
+			add(element, "Enum constant %v is removed from enum %v leaving its value %v unused", c.Name, new.QualifiedName, c.Tag)
+		}
+		if !names[c.Name] {
+			add(element, "Enum constant %v is removed from enum %v without reserving its name", c.Name, new.QualifiedName)
+		}
+	}
+
+	for _, rr := range old.ReservedRanges {
+		for _, gap := range unreservedWithin(new.ReservedRanges, rr.Start, rr.End) {
+			if gap[0] == gap[1] {
+				add(new.QualifiedName, "Value %v is no longer reserved in enum %v", gap[0], new.QualifiedName)
+			} else {
+				add(new.QualifiedName, "Values %v to %v are no longer reserved in enum %v", gap[0], gap[1], new.QualifiedName)
+			}
+		}
+	}
+	reserved := make(map[string]bool)
+	for _, name := range new.ReservedNames {
+		reserved[name] = true
+	}
+	for _, name := range old.ReservedNames {
+		if !reserved[name] {
+			add(new.QualifiedName, "Name '%v' is no longer reserved in enum %v", name, new.QualifiedName)
+		}
+	}
+}
+
+func isTagReserved(ranges []ReservedRangeElement, tag int) bool {
+	for _, rr := range ranges {
+		if tag >= rr.Start && tag <= rr.End {
+			return true
+		}
+	}
+	return false
+}
+
+// unreservedWithin returns the sub-ranges of the given range of numbers which are
+// not covered by the given reserved ranges.
+func unreservedWithin(ranges []ReservedRangeElement, start, end int) [][2]int {
+	sorted := append([]ReservedRangeElement{}, ranges...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	var gaps [][2]int
+	next := start
+	for _, rr := range sorted {
+		if next > end {
+			break
+		}
+		if rr.End < next {
+			continue
+		}
+		if rr.Start > end {
+			break
+		}
+		if rr.Start > next {
+			gaps = append(gaps, [2]int{next, rr.Start - 1})
+		}
+		next = rr.End + 1
+	}
+	if next <= end {
+		gaps = append(gaps, [2]int{next, end})
+	}
+	return gaps
+}
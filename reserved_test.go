@@ -0,0 +1,123 @@
+package pbparser_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tallstoat/pbparser"
+)
+
+const reservedOldProto = `
+syntax = "proto3";
+package inventory;
+
+message Item {
+  string id = 1;
+  string name = 2;
+  int32 count = 3;
+  oneof source {
+    string vendor = 4;
+    string warehouse = 5;
+  }
+  string sku = 6;
+  reserved 10 to 20, 30;
+  reserved "legacy", "old_name";
+
+  message Tag {
+    string key = 1;
+    string value = 2;
+  }
+}
+
+enum State {
+  STATE_UNKNOWN = 0;
+  STATE_ACTIVE = 1;
+  STATE_RETIRED = 2;
+}
+
+enum Kind {
+  KIND_UNKNOWN = 0;
+  KIND_BOOK = 1;
+  KIND_FILM = 2;
+  reserved 5 to 7;
+  reserved "KIND_GAME";
+}
+
+message Removed {
+  string id = 1;
+}
+`
+
+const reservedNewProto = `
+syntax = "proto3";
+package inventory;
+
+message Item {
+  string id = 1;
+  string title = 2;
+  oneof source {
+    string vendor = 4;
+  }
+  string code = 7;
+  reserved 3, 10 to 14, 16 to 20;
+  reserved "count", "legacy";
+
+  message Tag {
+    string key = 1;
+  }
+}
+
+enum State {
+  STATE_UNKNOWN = 0;
+  STATE_ENABLED = 1;
+}
+
+enum Kind {
+  KIND_UNKNOWN = 0;
+  reserved 1, 2, 5, 7;
+  reserved "KIND_BOOK";
+}
+`
+
+func TestVerifyReservedOnRemoval(t *testing.T) {
+	oldpf, err := pbparser.Parse(strings.NewReader(reservedOldProto), nil)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	newpf, err := pbparser.Parse(strings.NewReader(reservedNewProto), nil)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+
+	expected := []pbparser.Violation{
+		{Element: "inventory.State.STATE_RETIRED", Reason: "Enum constant STATE_RETIRED is removed from enum inventory.State leaving its value 2 unused"},
+		{Element: "inventory.State.STATE_RETIRED", Reason: "Enum constant STATE_RETIRED is removed from enum inventory.State without reserving its name"},
+		{Element: "inventory.Kind.KIND_FILM", Reason: "Enum constant KIND_FILM is removed from enum inventory.Kind without reserving its name"},
+		{Element: "inventory.Kind", Reason: "Value 6 is no longer reserved in enum inventory.Kind"},
+		{Element: "inventory.Kind", Reason: "Name 'KIND_GAME' is no longer reserved in enum inventory.Kind"},
+		{Element: "inventory.Item.sku", Reason: "Field 'sku' is removed from message inventory.Item without reserving its number 6"},
+		{Element: "inventory.Item.sku", Reason: "Field 'sku' is removed from message inventory.Item without reserving its name"},
+		{Element: "inventory.Item.warehouse", Reason: "Field 'warehouse' is removed from message inventory.Item without reserving its number 5"},
+		{Element: "inventory.Item.warehouse", Reason: "Field 'warehouse' is removed from message inventory.Item without reserving its name"},
+		{Element: "inventory.Item", Reason: "Number 15 is no longer reserved in message inventory.Item"},
+		{Element: "inventory.Item", Reason: "Number 30 is no longer reserved in message inventory.Item"},
+		{Element: "inventory.Item", Reason: "Name 'old_name' is no longer reserved in message inventory.Item"},
+		{Element: "inventory.Item.Tag.value", Reason: "Field 'value' is removed from message inventory.Item.Tag without reserving its number 2"},
+		{Element: "inventory.Item.Tag.value", Reason: "Field 'value' is removed from message inventory.Item.Tag without reserving its name"},
+	}
+
+	actual := pbparser.VerifyReservedOnRemoval(oldpf, newpf)
+	if len(actual) != len(expected) {
+		t.Fatalf("Expected %v violations, Actual: %v", len(expected), actual)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("Expected: %v, Actual: %v", expected[i], actual[i])
+		}
+	}
+
+	// no violations when comparing a file with itself...
+	if vs := pbparser.VerifyReservedOnRemoval(oldpf, oldpf); len(vs) != 0 {
+		t.Errorf("Expected no violations, Actual: %v", vs)
+	}
+}
@@ -0,0 +1,170 @@
+package pbparser
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ExtractSubset returns a copy of the given proto file retaining only the named
+// root messages/enums/services and everything they reference (transitively) i.e.
+// the types of fields (including map values & oneof members) and the request &
+// response types of rpcs. The roots can be named relative to the package of the
+// proto file or be fully qualified.
+//
+// Types are retained along with their whole declaration i.e. nested types, reserved
+// statements, options etc. are preserved and referencing a nested type retains the
+// top-level type enclosing it. Extend declarations are retained only if the type
+// they extend is retained. The imports are pruned down to the ones which declare
+// the referenced types; this relies on the given proto file having been produced
+// by the Parse() or the ParseFile() api.
+//
+// An Error naming the root or the reference which couldn't be resolved is returned
+// if any of them can't be resolved.
+func ExtractSubset(pf *ProtoFile, roots []string) (ProtoFile, error) {
+	s := subsetExtractor{
+		pf:       pf,
+		tops:     make(map[string]string),
+		retained: make(map[string]bool),
+		imports:  make(map[string]bool),
+	}
+	s.index(pf.Messages, pf.Enums, "")
+	for _, se := range pf.Services {
+		s.tops[se.QualifiedName] = se.QualifiedName
+	}
+
+	for _, root := range roots {
+		qname, found := resolveTypeName(pf.PackageName, root, s.defined)
+		if !found {
+			return ProtoFile{}, fmt.Errorf("Root %v is not defined in package %v", root, pf.PackageName)
+		}
+		if err := s.retain(qname); err != nil {
+			return ProtoFile{}, err
+		}
+	}
+	// the extend declarations of retained types are retained too...
+	var extends []ExtendElement
+	for _, ee := range pf.ExtendDeclarations {
+		qname, found := resolveTypeName(pf.PackageName, ee.Name, s.defined)
+		if !found || !s.retained[s.tops[qname]] {
+			continue
+		}
+		var err error
+		walkExtendRefs(pf.PackageName, pf.PackageName, []ExtendElement{ee}, func(ref typeRef) {
+			if err == nil {
+				err = s.follow(ref)
+			}
+		})
+		if err != nil {
+			return ProtoFile{}, err
+		}
+		extends = append(extends, ee)
+	}
+
+	spf := *pf
+	spf.Messages, spf.Enums, spf.Services = nil, nil, nil
+	for _, me := range pf.Messages {
+		if s.retained[me.QualifiedName] {
+			spf.Messages = append(spf.Messages, me)
+		}
+	}
+	for _, ee := range pf.Enums {
+		if s.retained[ee.QualifiedName] {
+			spf.Enums = append(spf.Enums, ee)
+		}
+	}
+	for _, se := range pf.Services {
+		if s.retained[se.QualifiedName] {
+			spf.Services = append(spf.Services, se)
+		}
+	}
+	spf.ExtendDeclarations = extends
+	spf.Dependencies = s.prune(pf.Dependencies)
+	spf.PublicDependencies = s.prune(pf.PublicDependencies)
+	spf.WeakDependencies = s.prune(pf.WeakDependencies)
+	return spf, nil
+}
+
+type subsetExtractor struct {
+	pf       *ProtoFile
+	tops     map[string]string // qualified name of a type -> qualified name of the top-level type enclosing it (or itself)
+	retained map[string]bool   // qualified names of the retained top-level types & services
+	imports  map[string]bool
+}
+
+func (s *subsetExtractor) index(msgs []MessageElement, enums []EnumElement, top string) {
+	for _, ee := range enums {
+		s.tops[ee.QualifiedName] = topOr(top, ee.QualifiedName)
+	}
+	for _, me := range msgs {
+		s.tops[me.QualifiedName] = topOr(top, me.QualifiedName)
+		s.index(me.Messages, me.Enums, s.tops[me.QualifiedName])
+	}
+}
+
+func topOr(top string, qname string) string {
+	if top != "" {
+		return top
+	}
+	return qname
+}
+
+func (s *subsetExtractor) defined(qname string) bool {
+	_, found := s.tops[qname]
+	return found
+}
+
+// retain retains the top-level type enclosing the given type (or service) and
+// everything it references.
+func (s *subsetExtractor) retain(qname string) error {
+	top := s.tops[qname]
+	if s.retained[top] {
+		return nil
+	}
+	s.retained[top] = true
+
+	var err error
+	visit := func(ref typeRef) {
+		if err == nil {
+			err = s.follow(ref)
+		}
+	}
+	for i := range s.pf.Messages {
+		if s.pf.Messages[i].QualifiedName == top {
+			walkMessageRefs(&s.pf.Messages[i], visit)
+		}
+	}
+	for i := range s.pf.Services {
+		if s.pf.Services[i].QualifiedName == top {
+			walkServiceRefs(&s.pf.Services[i], visit)
+		}
+	}
+	return err
+}
+
+// follow retains what the given reference refers to; be it a type in the proto
+// file itself or the import declaring it.
+func (s *subsetExtractor) follow(ref typeRef) error {
+	if qname, found := resolveTypeName(ref.scope, ref.name, s.defined); found {
+		return s.retain(qname)
+	}
+	qname, found := resolveTypeName(ref.scope, ref.name, func(n string) bool {
+		_, found := s.pf.importedTypes[n]
+		return found
+	})
+	if !found {
+		msg := fmt.Sprintf("Unable to resolve type %v referenced via %v in %v", ref.name, ref.via, ref.owner)
+		return errors.New(msg)
+	}
+	s.imports[s.pf.importedTypes[qname]] = true
+	return nil
+}
+
+func (s *subsetExtractor) prune(imports []string) []string {
+	var l []string
+	for _, d := range imports {
+		if s.imports[d] {
+			l = append(l, d)
+		}
+	}
+	return l
+}
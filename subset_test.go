@@ -0,0 +1,89 @@
+package pbparser_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tallstoat/pbparser"
+)
+
+func TestExtractSubset(t *testing.T) {
+	pf, err := pbparser.ParseFile("./resources/service.proto")
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+
+	var tests = []struct {
+		roots    []string
+		types    string
+		imports  string
+		errorstr string
+	}{
+		{
+			roots: []string{"TaskUpdateOptions"},
+			types: "logtask.TaskId logtask.Task logtask.TaskUpdateOptions",
+		},
+		{
+			roots:   []string{"logtask.SearchResponse", "ReturnStatus"},
+			types:   "logtask.ReturnStatus logtask.SearchResponse",
+			imports: "internal/publicx.proto",
+		},
+		{
+			roots:   []string{"LogTask"},
+			types:   "logtask.TaskId logtask.Task logtask.TaskList logtask.TaskListOptions logtask.TaskUpdateOptions logtask.ReturnStatus logtask.SearchResponse logtask.Outer logtask.LogTask",
+			imports: "internal/ext/privatex.proto internal/publicx.proto",
+		},
+		{roots: []string{"Order"}, errorstr: "Root Order is not defined in package logtask"},
+	}
+
+	for _, tt := range tests {
+		spf, err := pbparser.ExtractSubset(&pf, tt.roots)
+		if tt.errorstr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.errorstr) {
+				t.Errorf("Roots: %v, Expected error containing %q, Actual: %v", tt.roots, tt.errorstr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Roots: %v, %v", tt.roots, err.Error())
+			continue
+		}
+
+		var types []string
+		for _, me := range spf.Messages {
+			types = append(types, me.QualifiedName)
+		}
+		for _, en := range spf.Enums {
+			types = append(types, en.QualifiedName)
+		}
+		for _, se := range spf.Services {
+			types = append(types, se.QualifiedName)
+		}
+		if actual := strings.Join(types, " "); actual != tt.types {
+			t.Errorf("Roots: %v, Expected types: %v, Actual: %v", tt.roots, tt.types, actual)
+		}
+		imports := strings.Join(append(spf.Dependencies, spf.PublicDependencies...), " ")
+		if imports != tt.imports {
+			t.Errorf("Roots: %v, Expected imports: %v, Actual: %v", tt.roots, tt.imports, imports)
+		}
+	}
+
+	// reserved statements & extend declarations of retained messages are preserved...
+	spf, err := pbparser.ExtractSubset(&pf, []string{"Task", "TaskUpdateOptions"})
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	if len(spf.Messages[2].ReservedRanges) != 3 || len(spf.ExtendDeclarations) != 1 {
+		t.Errorf("Expected reserved ranges & extend declarations to be preserved, Actual: %v", spf)
+	}
+
+	// dangling references are reported...
+	nope, _ := pbparser.NewNamedDataType("Nope")
+	if err := pf.Messages[0].AddField(pbparser.FieldElement{Name: "nope", Type: nope, Tag: 4}); err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	if _, err := pbparser.ExtractSubset(&pf, []string{"TaskId"}); err == nil ||
+		!strings.Contains(err.Error(), "Unable to resolve type Nope referenced via nope in logtask.TaskId") {
+		t.Errorf("Expected error on dangling reference, Actual: %v", err)
+	}
+}
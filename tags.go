@@ -0,0 +1,202 @@
+package pbparser
+
+import (
+	"fmt"
+	"sort"
+)
+
+// The range of valid field numbers along with the range which is
+// reserved for the protocol buffers implementation.
+const (
+	minFieldNumber               = 1
+	maxFieldNumber               = 536870911
+	firstReservedImplFieldNumber = 19000
+	lastReservedImplFieldNumber  = 19999
+)
+
+// The largest value of an enum constant; which is what 'max' stands for in the
+// reserved ranges of enums.
+const maxEnumValue = 2147483647
+
+// NextFreeTag returns the next field number which can be safely used for a
+// new field in the message. The returned number is greater than the numbers of
+// all the existing fields (including fields within oneofs) and does not fall
+// within any reserved range, any extensions range or the range 19000 to 19999
+// which is reserved for the protocol buffers implementation.
+//
+// If no such field number is available, 0 is returned.
+func (me *MessageElement) NextFreeTag() int {
+	tag := minFieldNumber
+	for _, f := range me.allFields() {
+		if f.Tag >= tag {
+			tag = f.Tag + 1
+		}
+	}
+	for tag <= maxFieldNumber {
+		end := me.unavailableUntil(tag, true)
+		if end < tag {
+			return tag
+		}
+		tag = end + 1
+	}
+	return 0
+}
+
+// IsTagAvailable checks whether the given field number can be used for a new
+// field in the message. If the field number can not be used, the reason for
+// it is returned as well.
+func (me *MessageElement) IsTagAvailable(tag int) (bool, string) {
+	for _, f := range me.allFields() {
+		if f.Tag == tag {
+			return false, fmt.Sprintf("Tag %v is already used by field '%v'", tag, f.Name)
+		}
+	}
+	if reason := me.checkTagRanges(tag); reason != "" {
+		return false, reason
+	}
+	return true, ""
+}
+
+// RenumberFields applies the given mapping of old to new field numbers to the
+// fields of the message (including fields within oneofs). Fields whose numbers
+// are not in the mapping retain their numbers.
+//
+// The mapping is refused as a whole, and the message is left untouched, if any
+// old number does not belong to a field, if the resulting field numbers collide
+// or if any new number is not a valid field number or falls within a reserved
+// or extensions range. Otherwise, the mapping which was actually applied (i.e.
+// only the numbers which changed) is returned.
+func (me *MessageElement) RenumberFields(mapping map[int]int) (map[int]int, error) {
+	tags := make(map[int]bool)
+	for _, f := range me.allFields() {
+		tags[f.Tag] = true
+	}
+	for old := range mapping {
+		if !tags[old] {
+			return nil, fmt.Errorf("Tag %v is not used by any field in message %v", old, me.Name)
+		}
+	}
+
+	// figure out & check the new numbers before touching anything...
+	applied := make(map[int]int)
+	used := make(map[int]string)
+	for _, f := range me.allFields() {
+		tag := f.Tag
+		if newTag, found := mapping[f.Tag]; found {
+			tag = newTag
+			if tag != f.Tag {
+				applied[f.Tag] = tag
+				if reason := me.checkTagRanges(tag); reason != "" {
+					return nil, fmt.Errorf("Unable to renumber field '%v' in message %v. Reason:: %v", f.Name, me.Name, reason)
+				}
+			}
+		}
+		if other, found := used[tag]; found {
+			return nil, fmt.Errorf("Unable to renumber field '%v' in message %v as tag %v would also be used by field '%v'",
+				f.Name, me.Name, tag, other)
+		}
+		used[tag] = f.Name
+	}
+
+	// all good; apply the new numbers...
+	for i := range me.Fields {
+		if newTag, found := applied[me.Fields[i].Tag]; found {
+			me.Fields[i].Tag = newTag
+		}
+	}
+	for i := range me.OneOfs {
+		for j := range me.OneOfs[i].Fields {
+			if newTag, found := applied[me.OneOfs[i].Fields[j].Tag]; found {
+				me.OneOfs[i].Fields[j].Tag = newTag
+			}
+		}
+	}
+	return applied, nil
+}
+
+// Compact renumbers the fields of the message (including fields within oneofs)
+// so that they use the lowest available field numbers, preserving their relative
+// order by number. Reserved ranges, extensions ranges and the range reserved for
+// the protocol buffers implementation are skipped over. The mapping of old to
+// new field numbers which was applied is returned.
+func (me *MessageElement) Compact() (map[int]int, error) {
+	var tags []int
+	for _, f := range me.allFields() {
+		tags = append(tags, f.Tag)
+	}
+	sort.Ints(tags)
+
+	mapping := make(map[int]int)
+	next := minFieldNumber
+	for _, tag := range tags {
+		for next <= maxFieldNumber && me.checkTagRanges(next) != "" {
+			next = me.unavailableUntil(next, false) + 1
+		}
+		mapping[tag] = next
+		next++
+	}
+	return me.RenumberFields(mapping)
+}
+
+// checkTagRanges checks whether the given field number is a valid field number
+// which does not fall in any reserved or extensions range. If it does, the reason
+// is returned.
+func (me *MessageElement) checkTagRanges(tag int) string {
+	if tag < minFieldNumber || tag > maxFieldNumber {
+		return fmt.Sprintf("Tag %v is out of the valid range %v to %v", tag, minFieldNumber, maxFieldNumber)
+	}
+	if tag >= firstReservedImplFieldNumber && tag <= lastReservedImplFieldNumber {
+		return fmt.Sprintf("Tag %v is within the range %v to %v reserved for the protocol buffers implementation",
+			tag, firstReservedImplFieldNumber, lastReservedImplFieldNumber)
+	}
+	for _, rr := range me.ReservedRanges {
+		if tag >= rr.Start && tag <= rr.End {
+			return fmt.Sprintf("Tag %v is within the reserved range %v to %v", tag, rr.Start, rr.End)
+		}
+	}
+	for _, xe := range me.Extensions {
+		if tag >= xe.Start && tag <= xe.End {
+			return fmt.Sprintf("Tag %v is within the extensions range %v to %v", tag, xe.Start, xe.End)
+		}
+	}
+	return ""
+}
+
+// unavailableUntil returns the end of the range of unavailable field numbers
+// which the given field number falls in; taking the numbers used by existing
+// fields into account only if asked to. If the given field number is available,
+// a number lesser than it is returned.
+func (me *MessageElement) unavailableUntil(tag int, withFields bool) int {
+	end := tag - 1
+	if tag >= firstReservedImplFieldNumber && tag <= lastReservedImplFieldNumber {
+		end = lastReservedImplFieldNumber
+	}
+	if withFields {
+		for _, f := range me.allFields() {
+			if f.Tag == tag && end < tag {
+				end = tag
+			}
+		}
+	}
+	for _, rr := range me.ReservedRanges {
+		if tag >= rr.Start && tag <= rr.End && end < rr.End {
+			end = rr.End
+		}
+	}
+	for _, xe := range me.Extensions {
+		if tag >= xe.Start && tag <= xe.End && end < xe.End {
+			end = xe.End
+		}
+	}
+	return end
+}
+
+// allFields returns the fields of the message along with the fields of its oneofs.
+func (me *MessageElement) allFields() []FieldElement {
+	var fields []FieldElement
+	fields = append(fields, me.Fields...)
+	for _, oo := range me.OneOfs {
+		fields = append(fields, oo.Fields...)
+	}
+	return fields
+}
@@ -0,0 +1,91 @@
+package pbparser_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tallstoat/pbparser"
+)
+
+const tagsProto = `
+syntax = "proto2";
+package tags;
+
+message Abutting {
+  optional int32 a = 1;
+  optional int32 b = 4;
+  reserved 5 to 9;
+  oneof choice {
+    string c = 10;
+  }
+  reserved 11;
+  extensions 12 to 20;
+}
+
+message GapBelowMax {
+  optional int32 a = 1;
+  optional int32 b = 100;
+  reserved 101 to 536870911;
+}
+
+message NearImplReserved {
+  optional int32 a = 18999;
+}
+
+message Empty {
+}
+`
+
+func TestNextFreeTag(t *testing.T) {
+	pf, err := pbparser.Parse(strings.NewReader(tagsProto), nil)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+
+	var tests = []struct {
+		msg      string
+		expected int
+	}{
+		{msg: "Abutting", expected: 21},
+		{msg: "GapBelowMax", expected: 0},
+		{msg: "NearImplReserved", expected: 20000},
+		{msg: "Empty", expected: 1},
+	}
+
+	for i, tt := range tests {
+		if actual := pf.Messages[i].NextFreeTag(); actual != tt.expected {
+			t.Errorf("Message: %v, Expected: %v, Actual: %v", tt.msg, tt.expected, actual)
+		}
+	}
+}
+
+func TestIsTagAvailable(t *testing.T) {
+	pf, err := pbparser.Parse(strings.NewReader(tagsProto), nil)
+	if err != nil {
+		t.Fatalf("%v", err.Error())
+	}
+	me := pf.Messages[0]
+
+	var tests = []struct {
+		tag       int
+		available bool
+		reason    string
+	}{
+		{tag: 0, reason: "out of the valid range"},
+		{tag: 2, available: true},
+		{tag: 4, reason: "already used by field 'b'"},
+		{tag: 5, reason: "within the reserved range 5 to 9"},
+		{tag: 10, reason: "already used by field 'c'"},
+		{tag: 11, reason: "within the reserved range 11 to 11"},
+		{tag: 20, reason: "within the extensions range 12 to 20"},
+		{tag: 19500, reason: "reserved for the protocol buffers implementation"},
+		{tag: 536870912, reason: "out of the valid range"},
+	}
+
+	for _, tt := range tests {
+		available, reason := me.IsTagAvailable(tt.tag)
+		if available != tt.available || !strings.Contains(reason, tt.reason) {
+			t.Errorf("Tag: %v, Expected: (%v, %q), Actual: (%v, %q)", tt.tag, tt.available, tt.reason, available, reason)
+		}
+	}
+}
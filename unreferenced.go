@@ -0,0 +1,127 @@
+package pbparser
+
+import "sort"
+
+// typeGraph is the graph of references among the messages/enums (and services) of
+// one or more proto files keyed by their qualified names.
+type typeGraph struct {
+	defined typeNameSet
+	parents map[string]string   // qualified name of a nested type -> qualified name of its parent
+	edges   map[string][]string // qualified name of a message/service -> qualified names of the types it references
+	extends []ExtendElement
+	scopes  []string // the scopes of the extend declarations (same order)
+}
+
+// newTypeGraph builds the graph of references for the given proto files. The
+// references made by a message are the ones made by its fields (including map
+// values & oneof members) and those made by a service are the ones made by its
+// rpcs. Extend declarations are collected separately.
+func newTypeGraph(pfs []ProtoFile) *typeGraph {
+	g := &typeGraph{
+		defined: make(typeNameSet),
+		parents: make(map[string]string),
+		edges:   make(map[string][]string),
+	}
+	for i := range pfs {
+		for k := range typeNames(&pfs[i]) {
+			g.defined[k] = true
+		}
+	}
+
+	var addMessages func(msgs []MessageElement, parent string)
+	addMessages = func(msgs []MessageElement, parent string) {
+		for i := range msgs {
+			me := &msgs[i]
+			if parent != "" {
+				g.parents[me.QualifiedName] = parent
+			}
+			for _, ee := range me.Enums {
+				g.parents[ee.QualifiedName] = me.QualifiedName
+			}
+			g.edges[me.QualifiedName] = nil
+			walkFieldRefs(me.QualifiedName, me.QualifiedName, me.Fields, g.addEdge)
+			for j := range me.OneOfs {
+				walkFieldRefs(me.QualifiedName, me.QualifiedName, me.OneOfs[j].Fields, g.addEdge)
+			}
+			for _, ee := range me.ExtendDeclarations {
+				g.extends = append(g.extends, ee)
+				g.scopes = append(g.scopes, me.QualifiedName)
+			}
+			addMessages(me.Messages, me.QualifiedName)
+		}
+	}
+	for i := range pfs {
+		pf := &pfs[i]
+		addMessages(pf.Messages, "")
+		for j := range pf.Services {
+			walkServiceRefs(&pf.Services[j], g.addEdge)
+		}
+		for _, ee := range pf.ExtendDeclarations {
+			g.extends = append(g.extends, ee)
+			g.scopes = append(g.scopes, pf.PackageName)
+		}
+	}
+	return g
+}
+
+func (g *typeGraph) addEdge(ref typeRef) {
+	if qname, found := resolveTypeName(ref.scope, ref.name, g.defined.has); found {
+		g.edges[ref.owner] = append(g.edges[ref.owner], qname)
+	}
+}
+
+// FindUnreferencedTypes returns the qualified names (sorted) of the messages and
+// enums declared in the given proto files which are not reachable from the given
+// roots via references made by fields (including map values & oneof members), rpcs
+// and extend declarations. The roots are the qualified names of messages, enums or
+// services; if none are given, all the services and extend declarations (i.e. their
+// targets & fields) serve as the roots.
+//
+// A type only referenced by other unreachable types is itself unreachable. A type
+// enclosing a reachable nested type is deemed reachable as well, but the converse
+// does not hold.
+func FindUnreferencedTypes(pfs []ProtoFile, roots []string) []string {
+	g := newTypeGraph(pfs)
+
+	reachable := make(map[string]bool)
+	var visit func(qname string)
+	visit = func(qname string) {
+		if reachable[qname] {
+			return
+		}
+		reachable[qname] = true
+		for _, next := range g.edges[qname] {
+			visit(next)
+		}
+		if parent, found := g.parents[qname]; found {
+			visit(parent)
+		}
+	}
+
+	if len(roots) == 0 {
+		for _, pf := range pfs {
+			for _, se := range pf.Services {
+				roots = append(roots, se.QualifiedName)
+			}
+		}
+		for i, ee := range g.extends {
+			walkExtendRefs(g.scopes[i], g.scopes[i], []ExtendElement{ee}, func(ref typeRef) {
+				if qname, found := resolveTypeName(ref.scope, ref.name, g.defined.has); found {
+					roots = append(roots, qname)
+				}
+			})
+		}
+	}
+	for _, root := range roots {
+		visit(root)
+	}
+
+	var unreferenced []string
+	for qname := range g.defined {
+		if !reachable[qname] {
+			unreferenced = append(unreferenced, qname)
+		}
+	}
+	sort.Strings(unreferenced)
+	return unreferenced
+}
@@ -0,0 +1,109 @@
+package pbparser_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tallstoat/pbparser"
+)
+
+var unreferencedModules = mapImportModuleProvider{
+	"api.proto": `
+syntax = "proto3";
+package api;
+
+import "types.proto";
+
+service Catalog {
+  rpc Get (GetRequest) returns (types.Item);
+}
+
+message GetRequest {
+  string id = 1;
+  Filter filter = 2;
+  message Filter {
+    oneof by {
+      Kind kind = 1;
+      string tag = 2;
+    }
+  }
+  message Unused {}
+}
+
+enum Kind {
+  KIND_UNKNOWN = 0;
+}
+
+message Dead {
+  Deader other = 1;
+}
+
+message Deader {
+  map<string, Dead> back = 1;
+}
+
+message Holder {
+  message Referenced {}
+}
+
+message UsesNested {
+  Holder.Referenced r = 1;
+}
+`,
+	"types.proto": `
+syntax = "proto2";
+package types;
+
+message Item {
+  optional string name = 1;
+  map<string, Attribute> attributes = 2;
+  extensions 100 to 199;
+}
+
+message Attribute {}
+
+message Orphan {}
+
+extend Item {
+  optional Annotation annotation = 100;
+}
+
+message Annotation {}
+`,
+}
+
+func TestFindUnreferencedTypes(t *testing.T) {
+	var pfs []pbparser.ProtoFile
+	for _, f := range []string{"api.proto", "types.proto"} {
+		r, _ := unreferencedModules.Provide(f)
+		pf, err := pbparser.Parse(r, unreferencedModules)
+		if err != nil {
+			t.Fatalf("%v", err.Error())
+		}
+		pfs = append(pfs, pf)
+	}
+
+	var tests = []struct {
+		roots    []string
+		expected string
+	}{
+		{
+			expected: "api.Dead api.Deader api.GetRequest.Unused api.Holder api.Holder.Referenced api.UsesNested types.Orphan",
+		},
+		{
+			roots:    []string{"api.UsesNested"},
+			expected: "api.Dead api.Deader api.GetRequest api.GetRequest.Filter api.GetRequest.Unused api.Kind types.Annotation types.Attribute types.Item types.Orphan",
+		},
+		{
+			roots:    []string{"api.Dead", "types.Item"},
+			expected: "api.GetRequest api.GetRequest.Filter api.GetRequest.Unused api.Holder api.Holder.Referenced api.Kind api.UsesNested types.Annotation types.Orphan",
+		},
+	}
+
+	for _, tt := range tests {
+		actual := strings.Join(pbparser.FindUnreferencedTypes(pfs, tt.roots), " ")
+		if actual != tt.expected {
+			t.Errorf("Roots: %v, Expected: %v, Actual: %v", tt.roots, tt.expected, actual)
+		}
+	}
+}
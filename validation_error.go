@@ -0,0 +1,53 @@
+package pbparser
+
+import "fmt"
+
+// ValidationCode is an enumeration which represents the kinds of violations
+// which are reported by the post-parsing validation of a protobuf file.
+type ValidationCode int
+
+const (
+	MissingSyntaxCode ValidationCode = iota + 1
+	MissingImportProviderCode
+	UnresolvedImportCode
+	InvalidImportCode
+	UnusedImportCode
+	DuplicateTypeCode
+	DuplicateNameCode
+	DuplicateEnumConstantCode
+	UndefinedTypeCode
+	DuplicateFieldNumberCode
+	UnavailableFieldNumberCode
+	EnumValueAliasCode
+	InvalidOptionValueCode
+	MisplacedOptionCode
+	Proto3ConstraintCode
+	CrossSyntaxEnumCode
+	ReservedNameCode
+)
+
+// ValidationError is the Error returned when a parsed protobuf file fails the
+// post-parsing validation. Code identifies the kind of violation, Element holds
+// the qualified name of the offending element (if any), Reference holds the name
+// referenced by the element which could not be resolved (if any) and File holds
+// the import (or the path given to ParseFile()) in which the violation was found.
+// Position is populated only when the position of the element is known.
+type ValidationError struct {
+	Code      ValidationCode
+	Element   string
+	Reference string
+	File      string
+	Position  Position
+	msg       string
+}
+
+// Error returns the human-readable description of the violation.
+func (ve *ValidationError) Error() string {
+	return ve.msg
+}
+
+// newValidationError creates a ValidationError of the given code for the given
+// element; with the description formatted as per the given format specifier.
+func newValidationError(code ValidationCode, element string, format string, args ...interface{}) *ValidationError {
+	return &ValidationError{Code: code, Element: element, msg: fmt.Sprintf(format, args...)}
+}
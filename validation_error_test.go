@@ -0,0 +1,41 @@
+package pbparser_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tallstoat/pbparser"
+)
+
+func TestValidationError(t *testing.T) {
+	var tests = []struct {
+		file      string
+		code      pbparser.ValidationCode
+		element   string
+		reference string
+		source    string
+	}{
+		{file: "missing-msg.proto", code: pbparser.UndefinedTypeCode, element: "missing.Task.details", reference: "TaskDetails"},
+		{file: "wrong-rpc-datatype.proto", code: pbparser.UndefinedTypeCode, reference: "TaskId"},
+		{file: "unused-import.proto", code: pbparser.UnusedImportCode},
+		{file: "wrong-import.proto", code: pbparser.UnresolvedImportCode, source: "duh/abcd.proto"},
+		{file: "dup-type-import2.proto", code: pbparser.DuplicateTypeCode, element: "dup.Config", source: "dup-type-dep2.proto"},
+		{file: "no-syntax.proto", code: pbparser.MissingSyntaxCode},
+	}
+
+	for _, tt := range tests {
+		_, err := pbparser.ParseFile(errResourceDir + tt.file)
+		var ve *pbparser.ValidationError
+		if !errors.As(err, &ve) {
+			t.Errorf("File: %v, Expected a ValidationError, Actual: %v", tt.file, err)
+			continue
+		}
+		source := tt.source
+		if source == "" {
+			source = errResourceDir + tt.file
+		}
+		if ve.Code != tt.code || (tt.element != "" && ve.Element != tt.element) || ve.Reference != tt.reference || ve.File != source {
+			t.Errorf("File: %v, Unexpected ValidationError: %+v", tt.file, ve)
+		}
+	}
+}
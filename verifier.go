@@ -1,8 +1,8 @@
 package pbparser
 
 import (
-	"errors"
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -10,27 +10,39 @@ type protoFileOracle struct {
 	pf      *ProtoFile
 	msgmap  map[string]bool
 	enummap map[string]bool
+	// the syntax of the dependency which defines the enum (keyed by qualified name)
+	enumsyntax map[string]Syntax
 }
 
-func verify(pf *ProtoFile, p ImportModuleProvider) error {
+func verify(pf *ProtoFile, p ImportModuleProvider, opts parseOptions) error {
 	// validate syntax
 	if err := validateSyntax(pf); err != nil {
 		return err
 	}
 
-	if (len(pf.Dependencies) > 0 || len(pf.PublicDependencies) > 0) && p == nil {
-		return errors.New("ImportModuleProvider is required to validate imports")
+	if p == nil {
+		for _, d := range pf.imports() {
+			if !opts.wellKnownImports || !isWellKnownImport(d) {
+				return newValidationError(MissingImportProviderCode, "", "ImportModuleProvider is required to validate imports")
+			}
+		}
 	}
 
 	// make a map of package to its oracle...
 	m := make(map[string]protoFileOracle)
 
 	// parse the dependencies...
-	if err := parseDependencies(p, pf.Dependencies, m); err != nil {
+	pf.importedTypes = make(map[string]string)
+	pf.importedPackages = make(map[string]string)
+	if err := parseDependencies(p, pf.Dependencies, m, pf, opts); err != nil {
 		return err
 	}
 	// parse the public dependencies...
-	if err := parseDependencies(p, pf.PublicDependencies, m); err != nil {
+	if err := parseDependencies(p, pf.PublicDependencies, m, pf, opts); err != nil {
+		return err
+	}
+	// parse the weak dependencies...
+	if err := parseDependencies(p, pf.WeakDependencies, m, pf, opts); err != nil {
 		return err
 	}
 
@@ -38,6 +50,14 @@ func verify(pf *ProtoFile, p ImportModuleProvider) error {
 	orcl := protoFileOracle{pf: pf}
 	orcl.msgmap, orcl.enummap = makeQNameLookup(pf)
 	if _, found := m[pf.PackageName]; found {
+		// check that the main file does not redefine the types of its imports...
+		for _, k := range orcl.names() {
+			if m[pf.PackageName].defines(k) {
+				if err := reportDuplicateType(pf, opts, k, "the main file", pf.importedTypes[k]); err != nil {
+					return err
+				}
+			}
+		}
 		for k, v := range orcl.msgmap {
 			m[pf.PackageName].msgmap[k] = v
 		}
@@ -53,9 +73,14 @@ func verify(pf *ProtoFile, p ImportModuleProvider) error {
 
 	// collate the dependency package names...
 	packageNames := getDependencyPackageNames(pf.PackageName, m)
+	packages := newPackageSet(pf.PackageName, packageNames)
 
 	// check if imported packages are in use
-	if err := areImportedPackagesUsed(pf, packageNames); err != nil {
+	if err := areImportedPackagesUsed(pf, packages); err != nil {
+		return err
+	}
+	// check if imported files which declare no package are in use
+	if err := areImportedRootTypesUsed(pf, m); err != nil {
 		return err
 	}
 
@@ -64,7 +89,7 @@ func verify(pf *ProtoFile, p ImportModuleProvider) error {
 	fields := []fd{}
 	findFieldsToValidate(pf.Messages, &fields)
 	for _, f := range fields {
-		if err := validateFieldDataTypes(pf.PackageName, f, pf.Messages, pf.Enums, m, packageNames); err != nil {
+		if err := validateFieldDataTypes(pf.PackageName, f, pf.Messages, pf.Enums, m, packages); err != nil {
 			return err
 		}
 	}
@@ -73,17 +98,18 @@ func verify(pf *ProtoFile, p ImportModuleProvider) error {
 	// either the main model or in dependencies
 	for _, s := range pf.Services {
 		for _, rpc := range s.RPCs {
-			if err := validateRPCDataType(pf.PackageName, s.Name, rpc.Name, rpc.RequestType, pf.Messages, m, packageNames); err != nil {
+			if err := validateRPCDataType(pf.PackageName, s.Name, rpc.Name, rpc.RequestType, pf.Messages, m, packages); err != nil {
 				return err
 			}
-			if err := validateRPCDataType(pf.PackageName, s.Name, rpc.Name, rpc.ResponseType, pf.Messages, m, packageNames); err != nil {
+			if err := validateRPCDataType(pf.PackageName, s.Name, rpc.Name, rpc.ResponseType, pf.Messages, m, packages); err != nil {
 				return err
 			}
 		}
 	}
 
-	// validate that message and enum names are unique in the package as well as at the nested msg level (howsoever deep)
-	if err := validateUniqueMessageEnumNames("package "+pf.PackageName, pf.Enums, pf.Messages); err != nil {
+	// validate that message and enum names are unique in the package as well as at the nested msg level (howsoever deep);
+	// where they must not clash with the names of the fields & oneofs of the message either
+	if err := validateUniqueMessageEnumNames("package "+pf.PackageName, make(map[string]bool), pf.Enums, pf.Messages); err != nil {
 		return err
 	}
 
@@ -98,6 +124,27 @@ func verify(pf *ProtoFile, p ImportModuleProvider) error {
 		}
 	}
 
+	// validate that the well-known boolean options are specified as booleans
+	if err := validateBoolOptions(pf); err != nil {
+		return err
+	}
+
+	// validate that the options which take an enum value are specified as one of its constants
+	if err := validateEnumOptions(pf, m); err != nil {
+		return err
+	}
+
+	// validate that option allow_alias is specified only within enums
+	if err := validateAllowAlias(pf); err != nil {
+		return err
+	}
+	warnUnusedAllowAlias(pf)
+
+	// validate that option packed is specified only for repeated fields of packable types
+	if err := validatePackedOptions(pf); err != nil {
+		return err
+	}
+
 	// allow aliases in enums only if option allow_alias is specified
 	if err := validateEnumConstantTagAliases(pf.Enums); err != nil {
 		return err
@@ -109,8 +156,35 @@ func verify(pf *ProtoFile, p ImportModuleProvider) error {
 		}
 	}
 
+	// validate that field numbers are unique & not reserved in messages (howsoever deep), if asked to
+	if opts.strictFieldNumbers {
+		for _, msg := range pf.Messages {
+			if err := validateFieldTagsInMessage(msg); err != nil {
+				return err
+			}
+		}
+	}
+
+	// validate that the enums of the dependencies are used as their syntax permits
+	if err := validateCrossSyntaxEnums(pf, m); err != nil {
+		return err
+	}
+
+	// validate that the model abides by the constraints of proto3 (if applicable)
+	if pf.IsProto3() {
+		if err := validateProto3(pf); err != nil {
+			return err
+		}
+	}
+
 	// TODO: add more checks here if needed
 
+	// apply the custom verification rules (if any)
+	ctx := &VerifyContext{oracles: m, packages: packageNames}
+	if err := applyVerifyRules(pf, opts.verifyRules, ctx); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -121,90 +195,284 @@ func merge(dest *ProtoFile, src *ProtoFile) {
 	for _, d := range src.PublicDependencies {
 		dest.PublicDependencies = append(dest.PublicDependencies, d)
 	}
+	for _, d := range src.WeakDependencies {
+		dest.WeakDependencies = append(dest.WeakDependencies, d)
+	}
 	for _, d := range src.Options {
 		dest.Options = append(dest.Options, d)
 	}
+	// any (shadowed) type which dest already defines is skipped...
+	defined := make(map[string]bool)
+	for _, d := range dest.Messages {
+		defined[d.QualifiedName] = true
+	}
+	for _, d := range dest.Enums {
+		defined[d.QualifiedName] = true
+	}
 	for _, d := range src.Messages {
-		dest.Messages = append(dest.Messages, d)
+		if !defined[d.QualifiedName] {
+			dest.Messages = append(dest.Messages, d)
+		}
 	}
 	for _, d := range src.Enums {
-		dest.Enums = append(dest.Enums, d)
+		if !defined[d.QualifiedName] {
+			dest.Enums = append(dest.Enums, d)
+		}
 	}
 	for _, d := range src.ExtendDeclarations {
 		dest.ExtendDeclarations = append(dest.ExtendDeclarations, d)
 	}
 }
 
-func areImportedPackagesUsed(pf *ProtoFile, packageNames []string) error {
-	for _, pkg := range packageNames {
-		var inuse bool
-		// check if any request/response types are referring to this imported package...
-		for _, service := range pf.Services {
-			for _, rpc := range service.RPCs {
-				if usesPackage(rpc.RequestType.Name(), pkg, packageNames) {
-					inuse = true
-					goto LABEL
-				}
-				if usesPackage(rpc.ResponseType.Name(), pkg, packageNames) {
-					inuse = true
-					goto LABEL
-				}
+func areImportedPackagesUsed(pf *ProtoFile, packages packageSet) error {
+	// the packages imported only by weak imports are not required to be used...
+	weak := make(map[string]bool)
+	for _, d := range pf.WeakDependencies {
+		weak[pf.importedPackages[d]] = true
+	}
+	for _, d := range append(append([]string{}, pf.Dependencies...), pf.PublicDependencies...) {
+		delete(weak, pf.importedPackages[d])
+	}
+
+	used := usedPackages(pf, packages)
+	for _, pkg := range packages.deps {
+		if !used[pkg] && !weak[pkg] {
+			return newValidationError(UnusedImportCode, "", "Imported package: %v but not used", pkg)
+		}
+	}
+	return nil
+}
+
+// usedPackages returns the packages (other than the main one) which the types
+// referenced by the proto file and the names of its custom options belong to.
+func usedPackages(pf *ProtoFile, packages packageSet) map[string]bool {
+	used := make(map[string]bool)
+	use := func(name string) {
+		if inSamePkg, pkg := isDatatypeInSamePackage(strings.TrimPrefix(name, "."), packages); !inSamePkg {
+			used[pkg] = true
+		}
+	}
+	// note the imported packages which any fields, rpcs & extend declarations are referring to...
+	walkFileRefs(pf, func(ref typeRef) {
+		use(ref.name)
+	})
+	// note the imported packages which define any custom options...
+	_ = walkOptions(pf, func(kind string, name string, options []OptionElement) error {
+		for _, op := range options {
+			if op.IsParenthesized {
+				use(op.Name)
 			}
 		}
-		// check if any fields in messages (nested or not) are referring to this imported package...
-		if checkImportedPackageUsage(pf.Messages, pkg, packageNames) {
-			inuse = true
+		return nil
+	})
+	return used
+}
+
+// areImportedRootTypesUsed checks that each imported file which declares no
+// package (& hence defines its types in the root scope) is in use.
+func areImportedRootTypesUsed(pf *ProtoFile, m map[string]protoFileOracle) error {
+	orcl, found := m[""]
+	if !found || pf.PackageName == "" {
+		return nil
+	}
+
+	// the imports which define types in the root scope...
+	rootImports := make(map[string]bool)
+	for k := range orcl.msgmap {
+		rootImports[pf.importedTypes[k]] = true
+	}
+	for k := range orcl.enummap {
+		rootImports[pf.importedTypes[k]] = true
+	}
+
+	used := make(map[string]bool)
+	use := func(name string) {
+		name = strings.TrimPrefix(name, ".")
+		if orcl.msgmap[name] || orcl.enummap[name] {
+			used[pf.importedTypes[name]] = true
 		}
-	LABEL:
-		if !inuse {
-			return errors.New("Imported package: " + pkg + " but not used")
+	}
+	walkFileRefs(pf, func(ref typeRef) {
+		use(ref.name)
+	})
+
+	// the weak imports are optional by design, so those are not required to be used...
+	for _, d := range append(append([]string{}, pf.Dependencies...), pf.PublicDependencies...) {
+		if rootImports[d] && !used[d] {
+			ve := newValidationError(UnusedImportCode, "", "Imported file: %v declares no package and is not used", d)
+			ve.File = d
+			return ve
 		}
 	}
 	return nil
 }
 
-func checkImportedPackageUsage(msgs []MessageElement, pkg string, packageNames []string) bool {
+func validateUniqueMessageEnumNames(ctxName string, names map[string]bool, enums []EnumElement, msgs []MessageElement) error {
+	for _, en := range enums {
+		if names[en.Name] {
+			return newValidationError(DuplicateNameCode, en.QualifiedName, "Duplicate name %v in %v", en.Name, ctxName)
+		}
+		names[en.Name] = true
+	}
 	for _, msg := range msgs {
-		for _, f := range msg.Fields {
-			if f.Type.Category() == NamedDataTypeCategory && usesPackage(f.Type.Name(), pkg, packageNames) {
-				return true
+		if names[msg.Name] {
+			return newValidationError(DuplicateNameCode, msg.QualifiedName, "Duplicate name %v in %v", msg.Name, ctxName)
+		}
+		names[msg.Name] = true
+	}
+	for _, msg := range msgs {
+		// the fields & oneofs of a message share its namespace with the nested types...
+		m := make(map[string]bool)
+		for _, f := range msg.allFields() {
+			if m[f.Name] {
+				return newValidationError(DuplicateNameCode, msg.QualifiedName+"."+f.Name, "Duplicate name %v in message %v", f.Name, msg.Name)
 			}
+			m[f.Name] = true
 		}
-		if len(msg.Messages) > 0 {
-			if checkImportedPackageUsage(msg.Messages, pkg, packageNames) {
+		for _, oo := range msg.OneOfs {
+			if m[oo.Name] {
+				return newValidationError(DuplicateNameCode, msg.QualifiedName+"."+oo.Name, "Duplicate name %v in message %v", oo.Name, msg.Name)
+			}
+			m[oo.Name] = true
+		}
+		if err := validateUniqueMessageEnumNames("message "+msg.Name, m, msg.Enums, msg.Messages); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateProto3(pf *ProtoFile) error {
+	if err := validateProto3Enums(pf.Enums); err != nil {
+		return err
+	}
+	for _, msg := range pf.Messages {
+		if err := validateProto3Message(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateProto3Message(msg MessageElement) error {
+	if len(msg.Extensions) > 0 {
+		return newValidationError(Proto3ConstraintCode, msg.QualifiedName, "Extension ranges are not allowed in proto3. Found in message %v", msg.QualifiedName)
+	}
+	for _, f := range msg.allFields() {
+		if f.IsRequired() || (f.Label == optional && !f.Proto3Optional) {
+			return newValidationError(Proto3ConstraintCode, msg.QualifiedName+"."+f.Name,
+				"Field '%v' in message %v has the label '%v' which is not allowed in proto3", f.Name, msg.QualifiedName, f.Label)
+		}
+		for _, op := range f.Options {
+			if op.Name == "default" && !op.IsParenthesized {
+				return newValidationError(Proto3ConstraintCode, msg.QualifiedName+"."+f.Name,
+					"Field '%v' in message %v has an explicit default value which is not allowed in proto3", f.Name, msg.QualifiedName)
+			}
+		}
+	}
+	if err := validateProto3Enums(msg.Enums); err != nil {
+		return err
+	}
+	for _, nestedmsg := range msg.Messages {
+		if err := validateProto3Message(nestedmsg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateProto3Enums(enums []EnumElement) error {
+	for _, en := range enums {
+		if len(en.EnumConstants) > 0 && en.EnumConstants[0].Tag != 0 {
+			return newValidationError(Proto3ConstraintCode, en.QualifiedName, "The first enum value must be zero in proto3. Found otherwise in enum %v", en.QualifiedName)
+		}
+	}
+	return nil
+}
+
+// validateCrossSyntaxEnums checks that a proto3 file does not use the (closed)
+// enums of the proto2 dependencies and warns when a proto2 file specifies a
+// default value for a field of the (open) enum of a proto3 dependency.
+func validateCrossSyntaxEnums(pf *ProtoFile, m map[string]protoFileOracle) error {
+	defined := func(qname string) bool {
+		for _, orcl := range m {
+			if orcl.msgmap[qname] || orcl.enummap[qname] {
 				return true
 			}
 		}
+		return false
 	}
-	return false
+	enumSyntax := func(qname string) (Syntax, bool) {
+		for _, orcl := range m {
+			if syntax, found := orcl.enumsyntax[qname]; found {
+				return syntax, true
+			}
+		}
+		return "", false
+	}
+
+	var walk func(msgs []MessageElement) error
+	walk = func(msgs []MessageElement) error {
+		for _, msg := range msgs {
+			for _, f := range msg.allFields() {
+				dt := f.Type
+				if mdt, ok := dt.(MapDataType); ok {
+					dt = mdt.ValueType()
+				}
+				if dt.Category() != NamedDataTypeCategory {
+					continue
+				}
+				qname, found := resolveTypeName(msg.QualifiedName, dt.Name(), defined)
+				if !found {
+					continue
+				}
+				syntax, found := enumSyntax(qname)
+				if !found {
+					continue
+				}
+				if pf.IsProto3() && syntax == SyntaxProto2 {
+					ve := newValidationError(CrossSyntaxEnumCode, msg.QualifiedName+"."+f.Name,
+						"Field '%v' in message %v uses the proto2 enum %v which is not allowed in proto3", f.Name, msg.QualifiedName, qname)
+					ve.Reference = qname
+					return ve
+				}
+				if pf.IsProto2() && syntax == SyntaxProto3 && hasDefault(f) {
+					pf.Warnings = append(pf.Warnings, fmt.Sprintf("Field '%v' in message %v uses the proto3 enum %v with a default value", f.Name, msg.QualifiedName, qname))
+				}
+			}
+			if err := walk(msg.Messages); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(pf.Messages)
 }
 
-func usesPackage(s string, pkg string, packageNames []string) bool {
-	if strings.ContainsRune(s, '.') {
-		inSamePkg, pkgName := isDatatypeInSamePackage(s, packageNames)
-		if !inSamePkg && pkg == pkgName {
+func hasDefault(f FieldElement) bool {
+	for _, op := range f.Options {
+		if op.Name == "default" && !op.IsParenthesized {
 			return true
 		}
 	}
 	return false
 }
 
-func validateUniqueMessageEnumNames(ctxName string, enums []EnumElement, msgs []MessageElement) error {
-	m := make(map[string]bool)
-	for _, en := range enums {
-		if m[en.Name] {
-			return errors.New("Duplicate name " + en.Name + " in " + ctxName)
+func validateFieldTagsInMessage(msg MessageElement) error {
+	m := make(map[int]string)
+	for _, f := range msg.allFields() {
+		if other, found := m[f.Tag]; found {
+			return newValidationError(DuplicateFieldNumberCode, msg.QualifiedName+"."+f.Name,
+				"Field number %v has already been used in message %v by field '%v'", f.Tag, msg.QualifiedName, other)
 		}
-		m[en.Name] = true
-	}
-	for _, msg := range msgs {
-		if m[msg.Name] {
-			return errors.New("Duplicate name " + msg.Name + " in " + ctxName)
+		m[f.Tag] = f.Name
+		if reason := msg.checkTagRanges(f.Tag); reason != "" {
+			return newValidationError(UnavailableFieldNumberCode, msg.QualifiedName+"."+f.Name,
+				"Field '%v' in message %v uses an unavailable number. Reason:: %v", f.Name, msg.QualifiedName, reason)
 		}
-		m[msg.Name] = true
 	}
-	for _, msg := range msgs {
-		if err := validateUniqueMessageEnumNames("message "+msg.Name, msg.Enums, msg.Messages); err != nil {
+	for _, nestedmsg := range msg.Messages {
+		if err := validateFieldTagsInMessage(nestedmsg); err != nil {
 			return err
 		}
 	}
@@ -217,7 +485,8 @@ func validateEnumConstantTagAliases(enums []EnumElement) error {
 		for _, enc := range en.EnumConstants {
 			if m[enc.Tag] {
 				if !isAllowAlias(&en) {
-					return errors.New(enc.Name + " is reusing an enum value. If this is intended, set 'option allow_alias = true;' in the enum")
+					return newValidationError(EnumValueAliasCode, en.QualifiedName+"."+enc.Name,
+						"%v is reusing an enum value. If this is intended, set 'option allow_alias = true;' in the enum", enc.Name)
 				}
 			}
 			m[enc.Tag] = true
@@ -238,6 +507,216 @@ func validateEnumConstantTagAliasesInMessage(msg MessageElement) error {
 	return nil
 }
 
+// the well-known options which take a boolean value; options not listed here
+// (including the custom ones) are not type-checked...
+var boolOptions = map[string]bool{
+	"allow_alias":                            true,
+	"cc_enable_arenas":                       true,
+	"cc_generic_services":                    true,
+	"deprecated":                             true,
+	"deprecated_legacy_json_field_conflicts": true,
+	"java_generate_equals_and_hash":          true,
+	"java_generic_services":                  true,
+	"java_multiple_files":                    true,
+	"java_string_check_utf8":                 true,
+	"lazy":                                   true,
+	"map_entry":                              true,
+	"message_set_wire_format":                true,
+	"no_standard_descriptor_accessor":        true,
+	"packed":                                 true,
+	"php_generic_services":                   true,
+	"py_generic_services":                    true,
+	"unverified_lazy":                        true,
+	"weak":                                   true,
+}
+
+// walkOptions calls the given function for the options of each element of the
+// proto file (howsoever deep); passing the kind & the name of the element. The
+// enum values which have no options are not visited.
+func walkOptions(pf *ProtoFile, fn func(kind string, name string, options []OptionElement) error) error {
+	walkEnums := func(enums []EnumElement) error {
+		for _, en := range enums {
+			if err := fn("enum", en.QualifiedName, en.Options); err != nil {
+				return err
+			}
+			for _, enc := range en.EnumConstants {
+				// enums can be very large; so skip building the names of values without options...
+				if len(enc.Options) == 0 {
+					continue
+				}
+				if err := fn("enum value", en.QualifiedName+"."+enc.Name, enc.Options); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	walkExtends := func(extends []ExtendElement) error {
+		for _, ee := range extends {
+			for _, f := range ee.Fields {
+				if err := fn("field", ee.QualifiedName+"."+f.Name, f.Options); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	var walkMessages func(msgs []MessageElement) error
+	walkMessages = func(msgs []MessageElement) error {
+		for _, msg := range msgs {
+			if err := fn("message", msg.QualifiedName, msg.Options); err != nil {
+				return err
+			}
+			for _, oo := range msg.OneOfs {
+				if err := fn("oneof", msg.QualifiedName+"."+oo.Name, oo.Options); err != nil {
+					return err
+				}
+			}
+			for _, f := range msg.allFields() {
+				if err := fn("field", msg.QualifiedName+"."+f.Name, f.Options); err != nil {
+					return err
+				}
+			}
+			if err := walkEnums(msg.Enums); err != nil {
+				return err
+			}
+			if err := walkExtends(msg.ExtendDeclarations); err != nil {
+				return err
+			}
+			if err := walkMessages(msg.Messages); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := fn("package", pf.PackageName, pf.Options); err != nil {
+		return err
+	}
+	if err := walkEnums(pf.Enums); err != nil {
+		return err
+	}
+	if err := walkMessages(pf.Messages); err != nil {
+		return err
+	}
+	if err := walkExtends(pf.ExtendDeclarations); err != nil {
+		return err
+	}
+	for _, se := range pf.Services {
+		if err := fn("service", se.QualifiedName, se.Options); err != nil {
+			return err
+		}
+		for _, rpc := range se.RPCs {
+			if err := fn("rpc", se.QualifiedName+"."+rpc.Name, rpc.Options); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func validateBoolOptions(pf *ProtoFile) error {
+	return walkOptions(pf, func(kind string, name string, options []OptionElement) error {
+		for _, op := range options {
+			if boolOptions[op.Name] && !op.IsParenthesized && op.Value != "true" && op.Value != "false" {
+				return newValidationError(InvalidOptionValueCode, name, "Option %v in %v %v must be either true or false. Found: '%v'", op.Name, kind, name, op.Value)
+			}
+		}
+		return nil
+	})
+}
+
+func validateAllowAlias(pf *ProtoFile) error {
+	return walkOptions(pf, func(kind string, name string, options []OptionElement) error {
+		for _, op := range options {
+			if op.Name != "allow_alias" || op.IsParenthesized {
+				continue
+			}
+			if kind != "enum" {
+				return newValidationError(MisplacedOptionCode, name, "Option allow_alias is only allowed within enums. Found in %v %v", kind, name)
+			}
+		}
+		return nil
+	})
+}
+
+// validatePackedOptions validates that option packed is set (to true) only for the
+// repeated fields of the scalar types which can be packed. The fields of named types
+// are left alone since enums can be packed but messages can not.
+func validatePackedOptions(pf *ProtoFile) error {
+	validate := func(parent string, fields []FieldElement) error {
+		for _, f := range fields {
+			if v, _ := findOption(f.Options, "packed"); v != "true" {
+				continue
+			}
+			name := parent + "." + f.Name
+			if !f.IsRepeated() {
+				return newValidationError(MisplacedOptionCode, name, "Option packed is only allowed for repeated fields. Found in field %v", name)
+			}
+			if sdt, ok := f.Type.(ScalarDataType); ok && !sdt.IsPackable() {
+				return newValidationError(MisplacedOptionCode, name, "Option packed is only allowed for repeated fields of numeric, bool or enum types. Found in field %v of type %v", name, sdt.Name())
+			}
+		}
+		return nil
+	}
+	var walk func(msgs []MessageElement) error
+	walk = func(msgs []MessageElement) error {
+		for _, msg := range msgs {
+			if err := validate(msg.QualifiedName, msg.allFields()); err != nil {
+				return err
+			}
+			for _, ee := range msg.ExtendDeclarations {
+				if err := validate(ee.QualifiedName, ee.Fields); err != nil {
+					return err
+				}
+			}
+			if err := walk(msg.Messages); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, ee := range pf.ExtendDeclarations {
+		if err := validate(ee.QualifiedName, ee.Fields); err != nil {
+			return err
+		}
+	}
+	return walk(pf.Messages)
+}
+
+// warnUnusedAllowAlias raises a warning for each enum which sets the allow_alias
+// option but has no aliased values; as protoc does.
+func warnUnusedAllowAlias(pf *ProtoFile) {
+	warn := func(enums []EnumElement) {
+		for _, en := range enums {
+			if isAllowAlias(&en) && !hasEnumAliases(en) {
+				pf.Warnings = append(pf.Warnings, "Option allow_alias is set in enum "+en.QualifiedName+" but none of its values are aliases")
+			}
+		}
+	}
+	var walk func(msgs []MessageElement)
+	walk = func(msgs []MessageElement) {
+		for _, msg := range msgs {
+			warn(msg.Enums)
+			walk(msg.Messages)
+		}
+	}
+	warn(pf.Enums)
+	walk(pf.Messages)
+}
+
+func hasEnumAliases(en EnumElement) bool {
+	m := make(map[int]bool)
+	for _, enc := range en.EnumConstants {
+		if m[enc.Tag] {
+			return true
+		}
+		m[enc.Tag] = true
+	}
+	return false
+}
+
 func isAllowAlias(en *EnumElement) bool {
 	for _, op := range en.Options {
 		if op.Name == "allow_alias" && op.Value == "true" {
@@ -252,9 +731,15 @@ func validateEnumConstants(ctxName string, enums []EnumElement) error {
 	for _, en := range enums {
 		for _, enc := range en.EnumConstants {
 			if m[enc.Name] {
-				return errors.New("Enum constant " + enc.Name + " is already defined in " + ctxName)
+				return newValidationError(DuplicateEnumConstantCode, en.QualifiedName+"."+enc.Name, "Enum constant %v is already defined in %v", enc.Name, ctxName)
 			}
 			m[enc.Name] = true
+			if isTagReserved(en.ReservedRanges, enc.Tag) {
+				return newValidationError(UnavailableFieldNumberCode, en.QualifiedName+"."+enc.Name, "Enum constant %v in enum %v uses the reserved value %v", enc.Name, en.QualifiedName, enc.Tag)
+			}
+			if containsString(en.ReservedNames, enc.Name) {
+				return newValidationError(ReservedNameCode, en.QualifiedName+"."+enc.Name, "Enum constant %v in enum %v uses a reserved name", enc.Name, en.QualifiedName)
+			}
 		}
 	}
 	return nil
@@ -274,7 +759,7 @@ func validateEnumConstantsInMessage(msg MessageElement) error {
 
 func validateSyntax(pf *ProtoFile) error {
 	if pf.Syntax == "" {
-		return errors.New("No syntax specified in the proto file")
+		return newValidationError(MissingSyntaxCode, "", "No syntax specified in the proto file")
 	}
 	return nil
 }
@@ -282,7 +767,9 @@ func validateSyntax(pf *ProtoFile) error {
 func getDependencyPackageNames(mainPkgName string, m map[string]protoFileOracle) []string {
 	var keys []string
 	for k := range m {
-		if k == mainPkgName {
+		// the types of files which declare no package are in the root scope
+		// & are not qualified by a package name...
+		if k == mainPkgName || k == "" {
 			continue
 		}
 		keys = append(keys, k)
@@ -332,10 +819,13 @@ func findFieldsToValidate(msgs []MessageElement, fields *[]fd) {
 	}
 }
 
-func validateFieldDataTypes(mainpkg string, f fd, msgs []MessageElement, enums []EnumElement, m map[string]protoFileOracle, packageNames []string) error {
+func validateFieldDataTypes(mainpkg string, f fd, msgs []MessageElement, enums []EnumElement, m map[string]protoFileOracle, packages packageSet) error {
 	var found bool
-	if strings.ContainsRune(f.category, '.') {
-		inSamePkg, pkgName := isDatatypeInSamePackage(f.category, packageNames)
+	if strings.HasPrefix(f.category, ".") {
+		// a fully qualified name is looked up as is...
+		found = isAbsoluteTypeDefined(f.category[1:], m, true)
+	} else if strings.ContainsRune(f.category, '.') {
+		inSamePkg, pkgName := isDatatypeInSamePackage(f.category, packages)
 		if inSamePkg {
 			orcl := m[mainpkg]
 
@@ -369,17 +859,30 @@ func validateFieldDataTypes(mainpkg string, f fd, msgs []MessageElement, enums [
 			found = checkMsgOrEnumName(f.category, msgs, enums)
 		}
 	}
+	if !found && mainpkg != "" {
+		// Check against messages & enums of the imported files which declare no package
+		orcl := m[""]
+		found = orcl.msgmap[f.category] || orcl.enummap[f.category]
+	}
 	if !found {
-		msg := fmt.Sprintf("Datatype: '%v' referenced in field: '%v' is not defined", f.category, f.name)
-		return errors.New(msg)
+		ve := newValidationError(UndefinedTypeCode, f.msg.QualifiedName+"."+f.name, "Datatype: '%v' referenced in field: '%v' is not defined", f.category, f.name)
+		if !strings.ContainsRune(f.category, '.') {
+			if s := suggestScalarType(f.category); s != "" {
+				ve.msg += ". Did you mean '" + s + "'?"
+			}
+		}
+		ve.Reference = f.category
+		return ve
 	}
 	return nil
 }
 
-func validateRPCDataType(mainpkg string, service string, rpc string, datatype NamedDataType, msgs []MessageElement, m map[string]protoFileOracle, packageNames []string) error {
+func validateRPCDataType(mainpkg string, service string, rpc string, datatype NamedDataType, msgs []MessageElement, m map[string]protoFileOracle, packages packageSet) error {
 	var found bool
-	if strings.ContainsRune(datatype.Name(), '.') {
-		inSamePkg, pkgName := isDatatypeInSamePackage(datatype.Name(), packageNames)
+	if datatype.IsFullyQualified() {
+		found = isAbsoluteTypeDefined(datatype.Name()[1:], m, false)
+	} else if strings.ContainsRune(datatype.Name(), '.') {
+		inSamePkg, pkgName := isDatatypeInSamePackage(datatype.Name(), packages)
 		if inSamePkg {
 			// Check against normal as well as nested types in same package
 			orcl := m[mainpkg]
@@ -392,19 +895,34 @@ func validateRPCDataType(mainpkg string, service string, rpc string, datatype Na
 	} else {
 		found = checkMsgName(datatype.Name(), msgs)
 	}
+	if !found && mainpkg != "" {
+		// Check against messages of the imported files which declare no package
+		found = m[""].msgmap[datatype.Name()]
+	}
 	if !found {
-		msg := fmt.Sprintf("Datatype: '%v' referenced in RPC: '%v' of Service: '%v' is not defined OR is not a message type", datatype.Name(), rpc, service)
-		return errors.New(msg)
+		ve := newValidationError(UndefinedTypeCode, qualify(mainpkg, service+"."+rpc),
+			"Datatype: '%v' referenced in RPC: '%v' of Service: '%v' is not defined OR is not a message type", datatype.Name(), rpc, service)
+		ve.Reference = datatype.Name()
+		return ve
 	}
 	return nil
 }
 
-func isDatatypeInSamePackage(datatypeName string, packageNames []string) (bool, string) {
-	for _, pkg := range packageNames {
-		if strings.HasPrefix(datatypeName, pkg+".") {
-			return false, pkg
+// isAbsoluteTypeDefined returns true if a message (or an enum, if allowed) of the
+// given qualified name is defined by the main file or any of its imports.
+func isAbsoluteTypeDefined(qname string, m map[string]protoFileOracle, allowEnums bool) bool {
+	for _, orcl := range m {
+		if orcl.msgmap[qname] || (allowEnums && orcl.enummap[qname]) {
+			return true
 		}
 	}
+	return false
+}
+
+func isDatatypeInSamePackage(datatypeName string, packages packageSet) (bool, string) {
+	if pkg := packages.packageOf(datatypeName); pkg != "" && pkg != packages.main {
+		return false, pkg
+	}
 	return true, ""
 }
 
@@ -433,31 +951,71 @@ func checkEnumName(s string, enums []EnumElement) bool {
 	return false
 }
 
-func parseDependencies(impr ImportModuleProvider, dependencies []string, m map[string]protoFileOracle) error {
+// names returns the qualified names of all the messages & enums known to the oracle.
+func (orcl protoFileOracle) names() []string {
+	var l []string
+	for k := range orcl.msgmap {
+		l = append(l, k)
+	}
+	for k := range orcl.enummap {
+		l = append(l, k)
+	}
+	sort.Strings(l)
+	return l
+}
+
+// defines returns true if the oracle knows of a message or enum with the given qualified name.
+func (orcl protoFileOracle) defines(qname string) bool {
+	return orcl.msgmap[qname] || orcl.enummap[qname]
+}
+
+// reportDuplicateType returns an Error for the given type being defined in both the
+// given sources; unless asked to report it as a warning instead.
+func reportDuplicateType(pf *ProtoFile, opts parseOptions, qname string, source string, other string) error {
+	ve := newValidationError(DuplicateTypeCode, qname, "Type %v is defined in both %v and %v", qname, other, source)
+	if opts.duplicateTypeWarnings {
+		pf.Warnings = append(pf.Warnings, ve.Error())
+		return nil
+	}
+	if source != "the main file" {
+		ve.File = source
+	}
+	return ve
+}
+
+func parseDependencies(impr ImportModuleProvider, dependencies []string, m map[string]protoFileOracle, pf *ProtoFile, opts parseOptions) error {
+	imported := pf.importedTypes
 	for _, d := range dependencies {
-		r, err := impr.Provide(d)
+		dpf, err := provideDependency(impr, d, opts)
 		if err != nil {
-			msg := fmt.Sprintf("ImportModuleReader is unable to provide content of dependency module %v. Reason:: %v", d, err.Error())
-			return errors.New(msg)
-		}
-		if r == nil {
-			msg := fmt.Sprintf("ImportModuleReader is unable to provide reader for dependency module %v", d)
-			return errors.New(msg)
-		}
-
-		dpf := ProtoFile{}
-		if err := parse(r, &dpf); err != nil {
-			msg := fmt.Sprintf("Unable to parse dependency %v. Reason:: %v", d, err.Error())
-			return errors.New(msg)
+			return err
 		}
 
 		// validate syntax
 		if err := validateSyntax(&dpf); err != nil {
+			err.(*ValidationError).File = d
 			return err
 		}
 
-		orcl := protoFileOracle{pf: &dpf}
+		pf.importedPackages[d] = dpf.PackageName
+
+		orcl := protoFileOracle{pf: &dpf, enumsyntax: make(map[string]Syntax)}
 		orcl.msgmap, orcl.enummap = makeQNameLookup(&dpf)
+		for k := range orcl.enummap {
+			orcl.enumsyntax[k] = dpf.Syntax
+		}
+
+		// note which dependency declares which type; checking that no other
+		// dependency declares it as well...
+		for _, k := range orcl.names() {
+			if other, found := imported[k]; found && other != d {
+				if err := reportDuplicateType(pf, opts, k, d, other); err != nil {
+					return err
+				}
+				continue
+			}
+			imported[k] = d
+		}
 
 		if _, found := m[dpf.PackageName]; found {
 			for k, v := range orcl.msgmap {
@@ -466,9 +1024,81 @@ func parseDependencies(impr ImportModuleProvider, dependencies []string, m map[s
 			for k, v := range orcl.enummap {
 				m[dpf.PackageName].enummap[k] = v
 			}
+			for k, v := range orcl.enumsyntax {
+				if _, found := m[dpf.PackageName].enumsyntax[k]; !found {
+					m[dpf.PackageName].enumsyntax[k] = v
+				}
+			}
+			// keep the model of the package complete as well...
+			merge(m[dpf.PackageName].pf, &dpf)
 		} else {
 			m[dpf.PackageName] = orcl
 		}
 	}
 	return nil
 }
+
+// provideDependency parses the given dependency as provided by the given ImportModuleProvider;
+// falling back on the built-in knowledge of the well-known imports if there is no provider.
+// The dependencies are parsed only once if the parse options share the ones parsed so far.
+func provideDependency(impr ImportModuleProvider, d string, opts parseOptions) (ProtoFile, error) {
+	if impr == nil {
+		return wellKnownProtoFile(d)
+	}
+	if opts.dependencies != nil {
+		if dep, found := opts.dependencies[d]; found {
+			return dep.model(), dep.err
+		}
+		dpf, err := provideDependencyContent(impr, d, opts)
+		dep := dependency{pf: dpf, err: err}
+		opts.dependencies[d] = dep
+		return dep.model(), err
+	}
+	return provideDependencyContent(impr, d, opts)
+}
+
+// provideDependencyContent parses the given dependency as provided by the given
+// ImportModuleProvider.
+func provideDependencyContent(impr ImportModuleProvider, d string, opts parseOptions) (ProtoFile, error) {
+
+	r, err := impr.Provide(d)
+	if err != nil {
+		ve := newValidationError(UnresolvedImportCode, "", "ImportModuleReader is unable to provide content of dependency module %v. Reason:: %v", d, err.Error())
+		ve.File = d
+		return ProtoFile{}, ve
+	}
+	if r == nil {
+		ve := newValidationError(UnresolvedImportCode, "", "ImportModuleReader is unable to provide reader for dependency module %v", d)
+		ve.File = d
+		return ProtoFile{}, ve
+	}
+
+	dpf := ProtoFile{}
+	if err := parse(r, &dpf, opts); err != nil {
+		ve := newValidationError(InvalidImportCode, "", "Unable to parse dependency %v. Reason:: %v", d, err.Error())
+		ve.File = d
+		return ProtoFile{}, ve
+	}
+	return dpf, nil
+}
+
+// dependency is a dependency parsed (or which failed to be parsed) earlier.
+type dependency struct {
+	pf  ProtoFile
+	err error
+}
+
+// model returns a copy of the model of the dependency which can be merged into
+// without affecting the other copies i.e. the appends to its slices reallocate.
+func (dep dependency) model() ProtoFile {
+	pf := dep.pf
+	pf.Dependencies = pf.Dependencies[:len(pf.Dependencies):len(pf.Dependencies)]
+	pf.PublicDependencies = pf.PublicDependencies[:len(pf.PublicDependencies):len(pf.PublicDependencies)]
+	pf.WeakDependencies = pf.WeakDependencies[:len(pf.WeakDependencies):len(pf.WeakDependencies)]
+	pf.Options = pf.Options[:len(pf.Options):len(pf.Options)]
+	pf.Enums = pf.Enums[:len(pf.Enums):len(pf.Enums)]
+	pf.Messages = pf.Messages[:len(pf.Messages):len(pf.Messages)]
+	pf.Services = pf.Services[:len(pf.Services):len(pf.Services)]
+	pf.ExtendDeclarations = pf.ExtendDeclarations[:len(pf.ExtendDeclarations):len(pf.ExtendDeclarations)]
+	return pf
+}
@@ -0,0 +1,63 @@
+package pbparser
+
+import "sort"
+
+// VerifyRule is the interface which must be implemented by the custom verification
+// rules which are registered via the WithVerifyRules() option. Check() is invoked
+// with the parsed model of the main proto file once all the built-in checks have
+// passed and returns the violations of the rule (if any).
+type VerifyRule interface {
+	Check(pf *ProtoFile, ctx *VerifyContext) []error
+}
+
+// VerifyContext gives the custom verification rules access to the knowledge
+// gathered during verification about the main proto file & its dependencies.
+type VerifyContext struct {
+	oracles  map[string]protoFileOracle
+	packages []string
+}
+
+// ResolveType resolves the given (possibly relative) message/enum name referenced
+// within the given scope (e.g. the qualified name of a message) the way protoc
+// does. The qualified name of the message/enum is returned along with true if it
+// is defined in the main proto file or in any of its dependencies.
+func (ctx *VerifyContext) ResolveType(scope string, name string) (string, bool) {
+	return resolveTypeName(scope, name, func(qname string) bool {
+		for _, orcl := range ctx.oracles {
+			if orcl.defines(qname) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// IsEnum returns true if the given qualified name is that of an enum defined
+// in the main proto file or in any of its dependencies.
+func (ctx *VerifyContext) IsEnum(qname string) bool {
+	for _, orcl := range ctx.oracles {
+		if orcl.enummap[qname] {
+			return true
+		}
+	}
+	return false
+}
+
+// DependencyPackages returns the (sorted) names of the packages of the dependencies
+// other than the package of the main proto file.
+func (ctx *VerifyContext) DependencyPackages() []string {
+	l := append([]string{}, ctx.packages...)
+	sort.Strings(l)
+	return l
+}
+
+// applyVerifyRules applies the given custom verification rules in order; returning
+// the first violation found.
+func applyVerifyRules(pf *ProtoFile, rules []VerifyRule, ctx *VerifyContext) error {
+	for _, rule := range rules {
+		if errs := rule.Check(pf, ctx); len(errs) > 0 {
+			return errs[0]
+		}
+	}
+	return nil
+}
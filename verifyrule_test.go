@@ -0,0 +1,54 @@
+package pbparser_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tallstoat/pbparser"
+)
+
+// ruleFunc adapts a function to the VerifyRule interface.
+type ruleFunc func(pf *pbparser.ProtoFile, ctx *pbparser.VerifyContext) []error
+
+func (f ruleFunc) Check(pf *pbparser.ProtoFile, ctx *pbparser.VerifyContext) []error {
+	return f(pf, ctx)
+}
+
+func TestVerifyRules(t *testing.T) {
+	var applied []string
+	resolve := ruleFunc(func(pf *pbparser.ProtoFile, ctx *pbparser.VerifyContext) []error {
+		applied = append(applied, "resolve")
+		var errs []error
+		for _, f := range pf.Messages[0].Fields {
+			qname, found := ctx.ResolveType(pf.Messages[0].QualifiedName, f.Type.Name())
+			errs = append(errs, fmt.Errorf("%v:%v:%v:%v", f.Name, qname, found, ctx.IsEnum(qname)))
+		}
+		return errs
+	})
+	never := ruleFunc(func(pf *pbparser.ProtoFile, ctx *pbparser.VerifyContext) []error {
+		applied = append(applied, "never")
+		return nil
+	})
+
+	_, err := pbparser.ParseFile("./resources/dep/proto2-dependent.proto", pbparser.WithVerifyRules(never, resolve, never))
+	if err == nil || err.Error() != "color:mixed.Color:true:true" {
+		t.Errorf("Expected error: color:mixed.Color:true:true, Actual: %v", err)
+	}
+	if strings.Join(applied, ",") != "never,resolve" {
+		t.Errorf("Expected rules applied: never,resolve, Actual: %v", applied)
+	}
+
+	pass := ruleFunc(func(pf *pbparser.ProtoFile, ctx *pbparser.VerifyContext) []error {
+		if pkgs := ctx.DependencyPackages(); len(pkgs) != 1 || pkgs[0] != "mixed" {
+			return []error{fmt.Errorf("Unexpected dependency packages: %v", pkgs)}
+		}
+		if _, found := ctx.ResolveType("paint.Paint", "Missing"); found {
+			return []error{fmt.Errorf("Unexpected resolution of Missing")}
+		}
+		return nil
+	})
+	if _, err := pbparser.ParseFile("./resources/dep/proto2-dependent.proto", pbparser.WithVerifyRules(pass)); err != nil {
+		t.Errorf("%v", err.Error())
+	}
+}
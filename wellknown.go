@@ -0,0 +1,107 @@
+package pbparser
+
+import "strings"
+
+// the prefixes of the well-known imports & of the qualified names of their types...
+const (
+	wellKnownImportPrefix = "google/protobuf/"
+	wellKnownTypesPrefix  = "google.protobuf."
+)
+
+// wellKnownFile lists the messages & enums (named relative to the google.protobuf
+// package; nested ones being dot separated) defined by a well-known import.
+type wellKnownFile struct {
+	syntax   Syntax
+	messages []string
+	enums    []string
+}
+
+// the built-in knowledge of the well-known imports; used to verify the references
+// to their types when no ImportModuleProvider is given...
+var wellKnownFiles = map[string]wellKnownFile{
+	"any.proto": {syntax: SyntaxProto3, messages: []string{"Any"}},
+	"api.proto": {syntax: SyntaxProto3, messages: []string{"Api", "Method", "Mixin"}},
+	"descriptor.proto": {
+		syntax: SyntaxProto2,
+		messages: []string{
+			"FileDescriptorSet", "FileDescriptorProto", "DescriptorProto", "DescriptorProto.ExtensionRange",
+			"DescriptorProto.ReservedRange", "ExtensionRangeOptions", "FieldDescriptorProto", "OneofDescriptorProto",
+			"EnumDescriptorProto", "EnumDescriptorProto.EnumReservedRange", "EnumValueDescriptorProto",
+			"ServiceDescriptorProto", "MethodDescriptorProto", "FileOptions", "MessageOptions", "FieldOptions",
+			"OneofOptions", "EnumOptions", "EnumValueOptions", "ServiceOptions", "MethodOptions",
+			"UninterpretedOption", "UninterpretedOption.NamePart", "SourceCodeInfo", "SourceCodeInfo.Location",
+			"GeneratedCodeInfo", "GeneratedCodeInfo.Annotation",
+		},
+		enums: []string{
+			"FieldDescriptorProto.Type", "FieldDescriptorProto.Label", "FileOptions.OptimizeMode",
+			"FieldOptions.CType", "FieldOptions.JSType", "MethodOptions.IdempotencyLevel",
+		},
+	},
+	"duration.proto":       {syntax: SyntaxProto3, messages: []string{"Duration"}},
+	"empty.proto":          {syntax: SyntaxProto3, messages: []string{"Empty"}},
+	"field_mask.proto":     {syntax: SyntaxProto3, messages: []string{"FieldMask"}},
+	"source_context.proto": {syntax: SyntaxProto3, messages: []string{"SourceContext"}},
+	"struct.proto":         {syntax: SyntaxProto3, messages: []string{"Struct", "Value", "ListValue"}, enums: []string{"NullValue"}},
+	"timestamp.proto":      {syntax: SyntaxProto3, messages: []string{"Timestamp"}},
+	"type.proto": {
+		syntax:   SyntaxProto3,
+		messages: []string{"Type", "Field", "Enum", "EnumValue", "Option"},
+		enums:    []string{"Field.Kind", "Field.Cardinality", "Syntax"},
+	},
+	"wrappers.proto": {
+		syntax: SyntaxProto3,
+		messages: []string{"DoubleValue", "FloatValue", "Int64Value", "UInt64Value", "Int32Value", "UInt32Value",
+			"BoolValue", "StringValue", "BytesValue"},
+	},
+}
+
+// isWellKnownImport returns true if the given import is one of the well-known imports.
+func isWellKnownImport(d string) bool {
+	return strings.HasPrefix(d, wellKnownImportPrefix)
+}
+
+// wellKnownProtoFile returns a model of the given well-known import which carries
+// just the names of its messages & enums. An Error is returned if the import is
+// not known to the library.
+func wellKnownProtoFile(d string) (ProtoFile, error) {
+	wkf, found := wellKnownFiles[strings.TrimPrefix(d, wellKnownImportPrefix)]
+	if !found {
+		ve := newValidationError(UnresolvedImportCode, "", "Unknown well-known import %v", d)
+		ve.File = d
+		return ProtoFile{}, ve
+	}
+
+	pf := ProtoFile{PackageName: "google.protobuf", Syntax: wkf.syntax}
+	// the parents are listed before their nested types; so look them up as we go...
+	var find func(msgs []MessageElement, qname string) *MessageElement
+	find = func(msgs []MessageElement, qname string) *MessageElement {
+		for i := range msgs {
+			if msgs[i].QualifiedName == qname {
+				return &msgs[i]
+			}
+			if strings.HasPrefix(qname, msgs[i].QualifiedName+".") {
+				return find(msgs[i].Messages, qname)
+			}
+		}
+		return nil
+	}
+	for _, name := range wkf.messages {
+		me := MessageElement{Name: name[strings.LastIndex(name, ".")+1:], QualifiedName: pf.PackageName + "." + name}
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			parent := find(pf.Messages, pf.PackageName+"."+name[:i])
+			parent.Messages = append(parent.Messages, me)
+		} else {
+			pf.Messages = append(pf.Messages, me)
+		}
+	}
+	for _, name := range wkf.enums {
+		ee := EnumElement{Name: name[strings.LastIndex(name, ".")+1:], QualifiedName: pf.PackageName + "." + name}
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			parent := find(pf.Messages, pf.PackageName+"."+name[:i])
+			parent.Enums = append(parent.Enums, ee)
+		} else {
+			pf.Enums = append(pf.Enums, ee)
+		}
+	}
+	return pf, nil
+}
@@ -0,0 +1,67 @@
+package pbparser_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tallstoat/pbparser"
+)
+
+func TestWellKnownImports(t *testing.T) {
+	const content = `syntax = "proto3";
+package tasks;
+
+import "google/protobuf/timestamp.proto";
+import "google/protobuf/empty.proto";
+import "google/protobuf/type.proto";
+
+message Task {
+  google.protobuf.Timestamp created = 1;
+  google.protobuf.Field.Kind kind = 2;
+}
+
+service Tasks {
+  rpc Purge (google.protobuf.Empty) returns (google.protobuf.Empty);
+}
+`
+	if _, err := pbparser.Parse(strings.NewReader(content), nil, pbparser.WithWellKnownImports()); err != nil {
+		t.Errorf("%v", err.Error())
+	}
+
+	var tests = []struct {
+		content  string
+		opts     []pbparser.ParseOption
+		errorstr string
+	}{
+		{
+			content:  content,
+			errorstr: "ImportModuleProvider is required to validate imports",
+		},
+		{
+			content:  strings.Replace(content, "google/protobuf/empty.proto", "tasks/common.proto", 1),
+			opts:     []pbparser.ParseOption{pbparser.WithWellKnownImports()},
+			errorstr: "ImportModuleProvider is required to validate imports",
+		},
+		{
+			content:  strings.Replace(content, "empty.proto", "unknown.proto", 1),
+			opts:     []pbparser.ParseOption{pbparser.WithWellKnownImports()},
+			errorstr: "Unknown well-known import google/protobuf/unknown.proto",
+		},
+		{
+			content:  strings.Replace(content, "google.protobuf.Timestamp", "google.protobuf.Date", 1),
+			opts:     []pbparser.ParseOption{pbparser.WithWellKnownImports()},
+			errorstr: "Datatype: 'google.protobuf.Date' referenced in field: 'created' is not defined",
+		},
+		{
+			content:  "syntax = \"proto3\";\npackage tasks;\nimport \"google/protobuf/empty.proto\";\nmessage Task {}\n",
+			opts:     []pbparser.ParseOption{pbparser.WithWellKnownImports()},
+			errorstr: "Imported package: google.protobuf but not used",
+		},
+	}
+	for _, tt := range tests {
+		_, err := pbparser.Parse(strings.NewReader(tt.content), nil, tt.opts...)
+		if err == nil || !strings.Contains(err.Error(), tt.errorstr) {
+			t.Errorf("Expected error containing %q, Actual: %v", tt.errorstr, err)
+		}
+	}
+}